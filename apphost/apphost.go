@@ -0,0 +1,591 @@
+// Package apphost models the ApplicationHost.config hwc hands to the hostable
+// web core. hwc builds a Configuration in Go, changes it like any other value
+// and marshals it with encoding/xml.
+//
+// Only the elements and attributes hwc sets are modelled. Optional attributes
+// are omitted when empty.
+package apphost
+
+import (
+	"bytes"
+	"encoding/xml"
+	"io/ioutil"
+	"regexp"
+)
+
+type Configuration struct {
+	XMLName               xml.Name              `xml:"configuration"`
+	ConfigSections        ConfigSections        `xml:"configSections"`
+	SystemApplicationHost SystemApplicationHost `xml:"system.applicationHost"`
+	SystemWebServer       SystemWebServer       `xml:"system.webServer"`
+}
+
+// ConfigSections declares the sections the configuration may contain.
+type ConfigSections struct {
+	Entries []ConfigSection `xml:",any"`
+}
+
+// ConfigSection is a <section>, or a <sectionGroup> of further Entries, as
+// named by XMLName.
+type ConfigSection struct {
+	XMLName             xml.Name
+	Name                string          `xml:"name,attr"`
+	AllowDefinition     string          `xml:"allowDefinition,attr,omitempty"`
+	OverrideModeDefault string          `xml:"overrideModeDefault,attr,omitempty"`
+	Entries             []ConfigSection `xml:",any"`
+}
+
+// Section declares a configuration section.
+func Section(name, overrideModeDefault, allowDefinition string) ConfigSection {
+	return ConfigSection{
+		XMLName:             xml.Name{Local: "section"},
+		Name:                name,
+		AllowDefinition:     allowDefinition,
+		OverrideModeDefault: overrideModeDefault,
+	}
+}
+
+// SectionGroup declares a group of configuration sections.
+func SectionGroup(name string, entries ...ConfigSection) ConfigSection {
+	return ConfigSection{
+		XMLName: xml.Name{Local: "sectionGroup"},
+		Name:    name,
+		Entries: entries,
+	}
+}
+
+// Group returns the section group named by path, such as
+// "system.webServer", "security", or nil if it isn't declared.
+func (cs *ConfigSections) Group(path ...string) *ConfigSection {
+	entries := cs.Entries
+	var group *ConfigSection
+	for _, name := range path {
+		group = nil
+		for i := range entries {
+			if entries[i].XMLName.Local == "sectionGroup" && entries[i].Name == name {
+				group = &entries[i]
+				break
+			}
+		}
+		if group == nil {
+			return nil
+		}
+		entries = group.Entries
+	}
+	return group
+}
+
+type SystemApplicationHost struct {
+	ApplicationPools []ApplicationPool `xml:"applicationPools>add"`
+	ListenerAdapters []ListenerAdapter `xml:"listenerAdapters>add"`
+	Sites            Sites             `xml:"sites"`
+	WebLimits        struct{}          `xml:"webLimits"`
+}
+
+type ApplicationPool struct {
+	Name                  string `xml:"name,attr"`
+	ManagedRuntimeVersion string `xml:"managedRuntimeVersion,attr"`
+	ManagedPipelineMode   string `xml:"managedPipelineMode,attr"`
+	CLRConfigFile         string `xml:"CLRConfigFile,attr,omitempty"`
+	AutoStart             bool   `xml:"autoStart,attr"`
+	StartMode             string `xml:"startMode,attr,omitempty"`
+}
+
+type ListenerAdapter struct {
+	Name string `xml:"name,attr"`
+}
+
+type Sites struct {
+	SiteDefaults             SiteDefaults             `xml:"siteDefaults"`
+	ApplicationDefaults      ApplicationDefaults      `xml:"applicationDefaults"`
+	VirtualDirectoryDefaults VirtualDirectoryDefaults `xml:"virtualDirectoryDefaults"`
+	Sites                    []Site                   `xml:"site"`
+}
+
+type SiteDefaults struct {
+	LogFile                    LogFile                    `xml:"logFile"`
+	TraceFailedRequestsLogging TraceFailedRequestsLogging `xml:"traceFailedRequestsLogging"`
+}
+
+type LogFile struct {
+	LogFormat       string           `xml:"logFormat,attr"`
+	Directory       string           `xml:"directory,attr"`
+	LogExtFileFlags string           `xml:"logExtFileFlags,attr,omitempty"`
+	Period          string           `xml:"period,attr,omitempty"`
+	TruncateSize    uint64           `xml:"truncateSize,attr,omitempty"`
+	CustomFields    []CustomLogField `xml:"customFields>add"`
+}
+
+type CustomLogField struct {
+	LogFieldName string `xml:"logFieldName,attr"`
+	SourceName   string `xml:"sourceName,attr"`
+	SourceType   string `xml:"sourceType,attr"`
+}
+
+type TraceFailedRequestsLogging struct {
+	Enabled     bool   `xml:"enabled,attr"`
+	Directory   string `xml:"directory,attr"`
+	MaxLogFiles uint64 `xml:"maxLogFiles,attr"`
+}
+
+type ApplicationDefaults struct {
+	ApplicationPool string `xml:"applicationPool,attr"`
+}
+
+type VirtualDirectoryDefaults struct {
+	AllowSubDirConfig bool `xml:"allowSubDirConfig,attr"`
+}
+
+type Site struct {
+	Name            string        `xml:"name,attr"`
+	ID              int           `xml:"id,attr"`
+	ServerAutoStart bool          `xml:"serverAutoStart,attr"`
+	Applications    []Application `xml:"application"`
+	Bindings        []Binding     `xml:"bindings>binding"`
+}
+
+type Application struct {
+	Path               string             `xml:"path,attr"`
+	ApplicationPool    string             `xml:"applicationPool,attr"`
+	VirtualDirectories []VirtualDirectory `xml:"virtualDirectory"`
+}
+
+type VirtualDirectory struct {
+	Path         string `xml:"path,attr"`
+	PhysicalPath string `xml:"physicalPath,attr"`
+}
+
+type Binding struct {
+	Protocol           string `xml:"protocol,attr"`
+	BindingInformation string `xml:"bindingInformation,attr"`
+}
+
+type SystemWebServer struct {
+	ASP               ASP             `xml:"asp"`
+	Caching           Caching         `xml:"caching"`
+	CGI               struct{}        `xml:"cgi"`
+	DefaultDocument   DefaultDocument `xml:"defaultDocument"`
+	DirectoryBrowse   DirectoryBrowse `xml:"directoryBrowse"`
+	FastCGI           FastCGI         `xml:"fastCgi"`
+	GlobalModules     []GlobalModule  `xml:"globalModules>add"`
+	HTTPCompression   HTTPCompression `xml:"httpCompression"`
+	HTTPErrors        HTTPErrors      `xml:"httpErrors"`
+	HTTPLogging       HTTPLogging     `xml:"httpLogging"`
+	HTTPProtocol      HTTPProtocol    `xml:"httpProtocol"`
+	HTTPRedirect      struct{}        `xml:"httpRedirect"`
+	HTTPTracing       struct{}        `xml:"httpTracing"`
+	ISAPIFilters      []ISAPIFilter   `xml:"isapiFilters>filter"`
+	ODBCLogging       struct{}        `xml:"odbcLogging"`
+	Security          Security        `xml:"security"`
+	ServerRuntime     struct{}        `xml:"serverRuntime"`
+	ServerSideInclude struct{}        `xml:"serverSideInclude"`
+	StaticContent     StaticContent   `xml:"staticContent"`
+	Tracing           Tracing         `xml:"tracing"`
+	URLCompression    struct{}        `xml:"urlCompression"`
+	Validation        struct{}        `xml:"validation"`
+	WebSocket         *WebSocket      `xml:"webSocket"`
+	Rewrite           *Rewrite        `xml:"rewrite"`
+	AspNetCore        *AspNetCore     `xml:"aspNetCore"`
+	HTTPPlatform      *HTTPPlatform   `xml:"httpPlatform"`
+	Modules           []Module        `xml:"modules>add"`
+	Handlers          Handlers        `xml:"handlers"`
+}
+
+type ASP struct {
+	Cache ASPCache `xml:"cache"`
+}
+
+type ASPCache struct {
+	DiskTemplateCacheDirectory string `xml:"diskTemplateCacheDirectory,attr"`
+}
+
+type Caching struct {
+	Enabled           bool `xml:"enabled,attr"`
+	EnableKernelCache bool `xml:"enableKernelCache,attr"`
+}
+
+type DefaultDocument struct {
+	Enabled bool                  `xml:"enabled,attr"`
+	Files   []DefaultDocumentFile `xml:"files>add"`
+}
+
+type DefaultDocumentFile struct {
+	Value string `xml:"value,attr"`
+}
+
+type DirectoryBrowse struct {
+	Enabled bool `xml:"enabled,attr"`
+}
+
+// GlobalModule loads a native module into the web core.
+type GlobalModule struct {
+	Name         string `xml:"name,attr"`
+	Image        string `xml:"image,attr"`
+	PreCondition string `xml:"preCondition,attr,omitempty"`
+}
+
+// HTTPCompression leaves out MinFileSizeForComp and
+// StaticCompressionDisableCpuUsage, so IIS uses its defaults, when they're nil.
+type HTTPCompression struct {
+	Directory                        string              `xml:"directory,attr"`
+	NoCompressionForProxies          bool                `xml:"noCompressionForProxies,attr"`
+	MinFileSizeForComp               *uint64             `xml:"minFileSizeForComp,attr,omitempty"`
+	StaticCompressionDisableCpuUsage *uint64             `xml:"staticCompressionDisableCpuUsage,attr,omitempty"`
+	Schemes                          []CompressionScheme `xml:"scheme"`
+	StaticTypes                      []CompressionType   `xml:"staticTypes>add"`
+	DynamicTypes                     []CompressionType   `xml:"dynamicTypes>add"`
+}
+
+type CompressionScheme struct {
+	Name                    string `xml:"name,attr"`
+	DLL                     string `xml:"dll,attr"`
+	DynamicCompressionLevel int    `xml:"dynamicCompressionLevel,attr"`
+	StaticCompressionLevel  int    `xml:"staticCompressionLevel,attr"`
+}
+
+type CompressionType struct {
+	MimeType string `xml:"mimeType,attr"`
+	Enabled  bool   `xml:"enabled,attr"`
+}
+
+type HTTPErrors struct {
+	ErrorMode        string      `xml:"errorMode,attr"`
+	ExistingResponse string      `xml:"existingResponse,attr"`
+	LockAttributes   string      `xml:"lockAttributes,attr,omitempty"`
+	Errors           []HTTPError `xml:"error"`
+}
+
+type HTTPError struct {
+	StatusCode             int    `xml:"statusCode,attr"`
+	PrefixLanguageFilePath string `xml:"prefixLanguageFilePath,attr"`
+	Path                   string `xml:"path,attr"`
+}
+
+type HTTPLogging struct {
+	DontLog bool `xml:"dontLog,attr"`
+}
+
+type HTTPProtocol struct {
+	CustomHeaders   Headers `xml:"customHeaders"`
+	RedirectHeaders Headers `xml:"redirectHeaders"`
+}
+
+// Headers is a header collection. Clear drops the headers inherited from
+// the parent configuration.
+type Headers struct {
+	Clear   *Clear   `xml:"clear"`
+	Headers []Header `xml:"add"`
+}
+
+// Clear is a <clear /> element.
+type Clear struct{}
+
+type Header struct {
+	Name  string `xml:"name,attr"`
+	Value string `xml:"value,attr"`
+}
+
+type ISAPIFilter struct {
+	Name         string `xml:"name,attr"`
+	Path         string `xml:"path,attr"`
+	EnableCache  bool   `xml:"enableCache,attr"`
+	PreCondition string `xml:"preCondition,attr,omitempty"`
+}
+
+type Security struct {
+	Access                  Access                `xml:"access"`
+	ApplicationDependencies struct{}              `xml:"applicationDependencies"`
+	Authentication          Authentication        `xml:"authentication"`
+	Authorization           []AuthorizationRule   `xml:"authorization>add"`
+	IPSecurity              struct{}              `xml:"ipSecurity"`
+	ISAPICgiRestriction     []ISAPICgiRestriction `xml:"isapiCgiRestriction>add"`
+	RequestFiltering        RequestFiltering      `xml:"requestFiltering"`
+}
+
+type Access struct {
+	SSLFlags string `xml:"sslFlags,attr"`
+}
+
+type Authentication struct {
+	Anonymous                   AnonymousAuthentication `xml:"anonymousAuthentication"`
+	Basic                       struct{}                `xml:"basicAuthentication"`
+	ClientCertificateMapping    struct{}                `xml:"clientCertificateMappingAuthentication"`
+	Digest                      struct{}                `xml:"digestAuthentication"`
+	IISClientCertificateMapping struct{}                `xml:"iisClientCertificateMappingAuthentication"`
+	Windows                     WindowsAuthentication   `xml:"windowsAuthentication"`
+}
+
+type AnonymousAuthentication struct {
+	Enabled  bool   `xml:"enabled,attr"`
+	UserName string `xml:"userName,attr"`
+}
+
+type WindowsAuthentication struct {
+	AuthPersistNonNTLM       bool                     `xml:"authPersistNonNTLM,attr"`
+	AuthPersistSingleRequest bool                     `xml:"authPersistSingleRequest,attr"`
+	Enabled                  bool                     `xml:"enabled,attr"`
+	Providers                []AuthenticationProvider `xml:"providers>add"`
+}
+
+type AuthenticationProvider struct {
+	Value string `xml:"value,attr"`
+}
+
+type AuthorizationRule struct {
+	AccessType string `xml:"accessType,attr"`
+	Users      string `xml:"users,attr"`
+}
+
+type ISAPICgiRestriction struct {
+	Path        string `xml:"path,attr"`
+	Allowed     bool   `xml:"allowed,attr"`
+	GroupID     string `xml:"groupId,attr"`
+	Description string `xml:"description,attr"`
+}
+
+type RequestFiltering struct {
+	AllowDoubleEscaping    bool              `xml:"allowDoubleEscaping,attr"`
+	AllowHighBitCharacters bool              `xml:"allowHighBitCharacters,attr"`
+	RemoveServerHeader     bool              `xml:"removeServerHeader,attr,omitempty"`
+	DenyURLSequences       []DenyURLSequence `xml:"denyUrlSequences>add"`
+	FileExtensions         FileExtensions    `xml:"fileExtensions"`
+	RequestLimits          RequestLimits     `xml:"requestLimits"`
+	Verbs                  Verbs             `xml:"verbs"`
+	HiddenSegments         HiddenSegments    `xml:"hiddenSegments"`
+}
+
+type DenyURLSequence struct {
+	Sequence string `xml:"sequence,attr"`
+}
+
+type FileExtensions struct {
+	AllowUnlisted  bool            `xml:"allowUnlisted,attr"`
+	ApplyToWebDAV  bool            `xml:"applyToWebDAV,attr"`
+	FileExtensions []FileExtension `xml:"add"`
+}
+
+type FileExtension struct {
+	FileExtension string `xml:"fileExtension,attr"`
+	Allowed       bool   `xml:"allowed,attr"`
+}
+
+type RequestLimits struct {
+	MaxAllowedContentLength uint64        `xml:"maxAllowedContentLength,attr"`
+	MaxURL                  uint64        `xml:"maxUrl,attr"`
+	MaxQueryString          uint64        `xml:"maxQueryString,attr"`
+	HeaderLimits            []HeaderLimit `xml:"headerLimits>add"`
+}
+
+type HeaderLimit struct {
+	Header    string `xml:"header,attr"`
+	SizeLimit uint64 `xml:"sizeLimit,attr"`
+}
+
+type Verbs struct {
+	AllowUnlisted bool   `xml:"allowUnlisted,attr"`
+	ApplyToWebDAV bool   `xml:"applyToWebDAV,attr"`
+	Verbs         []Verb `xml:"add"`
+}
+
+type Verb struct {
+	Verb    string `xml:"verb,attr"`
+	Allowed bool   `xml:"allowed,attr"`
+}
+
+type HiddenSegments struct {
+	ApplyToWebDAV bool            `xml:"applyToWebDAV,attr"`
+	Segments      []HiddenSegment `xml:"add"`
+}
+
+type HiddenSegment struct {
+	Segment string `xml:"segment,attr"`
+}
+
+type StaticContent struct {
+	LockAttributes string    `xml:"lockAttributes,attr,omitempty"`
+	MimeMaps       []MimeMap `xml:"mimeMap"`
+}
+
+type MimeMap struct {
+	FileExtension string `xml:"fileExtension,attr"`
+	MimeType      string `xml:"mimeType,attr"`
+}
+
+type Tracing struct {
+	TraceProviderDefinitions []TraceProvider      `xml:"traceProviderDefinitions>add"`
+	TraceFailedRequests      []TraceFailedRequest `xml:"traceFailedRequests>add"`
+}
+
+type TraceProvider struct {
+	Name  string     `xml:"name,attr"`
+	GUID  string     `xml:"guid,attr"`
+	Areas TraceAreas `xml:"areas"`
+}
+
+type TraceAreas struct {
+	Clear *Clear      `xml:"clear"`
+	Areas []TraceArea `xml:"add"`
+}
+
+type TraceArea struct {
+	Name  string `xml:"name,attr"`
+	Value int    `xml:"value,attr"`
+}
+
+// TraceFailedRequest is a failed request tracing rule for the requests
+// matching Path.
+type TraceFailedRequest struct {
+	Path               string             `xml:"path,attr"`
+	TraceAreas         []TraceRuleArea    `xml:"traceAreas>add"`
+	FailureDefinitions FailureDefinitions `xml:"failureDefinitions"`
+}
+
+type TraceRuleArea struct {
+	Provider  string `xml:"provider,attr"`
+	Areas     string `xml:"areas,attr,omitempty"`
+	Verbosity string `xml:"verbosity,attr"`
+}
+
+type FailureDefinitions struct {
+	StatusCodes string `xml:"statusCodes,attr,omitempty"`
+	TimeTaken   string `xml:"timeTaken,attr,omitempty"`
+}
+
+// Rewrite configures the URL Rewrite module, which hwc only loads when it is
+// installed.
+type Rewrite struct {
+	GlobalRules []RewriteRule `xml:"globalRules>rule"`
+}
+
+type RewriteRule struct {
+	Name            string           `xml:"name,attr"`
+	Match           RewriteMatch     `xml:"match"`
+	ServerVariables []ServerVariable `xml:"serverVariables>set"`
+	Action          RewriteAction    `xml:"action"`
+}
+
+type RewriteMatch struct {
+	URL string `xml:"url,attr"`
+}
+
+type ServerVariable struct {
+	Name  string `xml:"name,attr"`
+	Value string `xml:"value,attr"`
+}
+
+type RewriteAction struct {
+	Type string `xml:"type,attr"`
+}
+
+// WebSocket is only written when hwc changes the IIS defaults.
+type WebSocket struct {
+	Enabled            bool   `xml:"enabled,attr"`
+	ReceiveBufferLimit uint64 `xml:"receiveBufferLimit,attr"`
+	PingInterval       string `xml:"pingInterval,attr"`
+}
+
+// AspNetCore configures the ASP.NET Core Module, which hwc only loads when it
+// is installed.
+type AspNetCore struct {
+	ProcessPath  string `xml:"processPath,attr"`
+	Arguments    string `xml:"arguments,attr,omitempty"`
+	HostingModel string `xml:"hostingModel,attr,omitempty"`
+}
+
+type FastCGI struct {
+	Applications []FastCGIApplication `xml:"application"`
+}
+
+// FastCGIApplication is a FastCGI process pool, identified by FullPath and
+// Arguments. Zero limits and timeouts, in seconds, are left to IIS.
+type FastCGIApplication struct {
+	FullPath             string                `xml:"fullPath,attr"`
+	Arguments            string                `xml:"arguments,attr,omitempty"`
+	MaxInstances         uint64                `xml:"maxInstances,attr,omitempty"`
+	InstanceMaxRequests  uint64                `xml:"instanceMaxRequests,attr,omitempty"`
+	ActivityTimeout      uint64                `xml:"activityTimeout,attr,omitempty"`
+	IdleTimeout          uint64                `xml:"idleTimeout,attr,omitempty"`
+	RequestTimeout       uint64                `xml:"requestTimeout,attr,omitempty"`
+	EnvironmentVariables []EnvironmentVariable `xml:"environmentVariables>environmentVariable"`
+}
+
+// HTTPPlatform configures the HttpPlatformHandler module, which hwc only
+// loads when it is installed.
+type HTTPPlatform struct {
+	ProcessPath          string                `xml:"processPath,attr,omitempty"`
+	Arguments            string                `xml:"arguments,attr,omitempty"`
+	StdoutLogEnabled     bool                  `xml:"stdoutLogEnabled,attr"`
+	StdoutLogFile        string                `xml:"stdoutLogFile,attr"`
+	EnvironmentVariables []EnvironmentVariable `xml:"environmentVariables>environmentVariable"`
+}
+
+type EnvironmentVariable struct {
+	Name  string `xml:"name,attr"`
+	Value string `xml:"value,attr"`
+}
+
+// Module enables a native module loaded by <globalModules>, or a managed
+// module when Type is set. Locked modules can't be removed by the app's
+// Web.config.
+type Module struct {
+	Name         string `xml:"name,attr"`
+	Type         string `xml:"type,attr,omitempty"`
+	PreCondition string `xml:"preCondition,attr,omitempty"`
+	LockItem     bool   `xml:"lockItem,attr,omitempty"`
+}
+
+type Handlers struct {
+	AccessPolicy string    `xml:"accessPolicy,attr"`
+	Handlers     []Handler `xml:"add"`
+}
+
+type Handler struct {
+	Name                string `xml:"name,attr"`
+	Path                string `xml:"path,attr"`
+	Verb                string `xml:"verb,attr"`
+	Modules             string `xml:"modules,attr,omitempty"`
+	Type                string `xml:"type,attr,omitempty"`
+	ScriptProcessor     string `xml:"scriptProcessor,attr,omitempty"`
+	ResourceType        string `xml:"resourceType,attr,omitempty"`
+	RequireAccess       string `xml:"requireAccess,attr,omitempty"`
+	AllowPathInfo       bool   `xml:"allowPathInfo,attr,omitempty"`
+	PreCondition        string `xml:"preCondition,attr,omitempty"`
+	ResponseBufferLimit string `xml:"responseBufferLimit,attr,omitempty"`
+}
+
+// Marshal renders c as an indented XML document.
+func (c *Configuration) Marshal() ([]byte, error) {
+	data, err := xml.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	var b bytes.Buffer
+	b.WriteString(xml.Header)
+	b.Write(emptyElement.ReplaceAll(data, []byte("<$1$2 />")))
+	b.WriteByte('\n')
+	return b.Bytes(), nil
+}
+
+// emptyElement matches an element encoding/xml wrote with no content: a
+// start tag immediately followed by an end tag, which in well formed output
+// must be its own. Attribute values can't contain '<' or '>', which
+// encoding/xml escapes.
+var emptyElement = regexp.MustCompile(`<([\w.:-]+)((?:\s[^<>]*)?)></[\w.:-]+>`)
+
+// WriteFile writes c to path.
+func (c *Configuration) WriteFile(path string) error {
+	data, err := c.Marshal()
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, data, 0644)
+}
+
+// Unmarshal parses an ApplicationHost.config into a Configuration.
+func Unmarshal(data []byte) (*Configuration, error) {
+	var c Configuration
+	if err := xml.Unmarshal(data, &c); err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
@@ -0,0 +1,13 @@
+package apphost_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func TestApphost(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Apphost Suite")
+}
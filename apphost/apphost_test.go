@@ -0,0 +1,110 @@
+package apphost_test
+
+import (
+	"encoding/xml"
+	"strings"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"code.cloudfoundry.org/hwc/apphost"
+)
+
+var _ = Describe("Configuration", func() {
+	var config *apphost.Configuration
+
+	BeforeEach(func() {
+		config = &apphost.Configuration{
+			ConfigSections: apphost.ConfigSections{Entries: []apphost.ConfigSection{
+				apphost.SectionGroup("system.webServer",
+					apphost.Section("asp", "Allow", ""),
+					apphost.SectionGroup("security",
+						apphost.Section("requestFiltering", "Allow", ""),
+					),
+					apphost.Section("webSocket", "Deny", ""),
+				),
+			}},
+		}
+		config.SystemWebServer.GlobalModules = []apphost.GlobalModule{
+			{Name: "StaticFileModule", Image: `%windir%\System32\inetsrv\static.dll`},
+		}
+		config.SystemWebServer.Modules = []apphost.Module{
+			{Name: "StaticFileModule", LockItem: true},
+			{Name: "OutputCache", Type: "System.Web.Caching.OutputCacheModule", PreCondition: "managedHandler"},
+		}
+		config.SystemWebServer.HTTPProtocol.CustomHeaders = apphost.Headers{
+			Clear:   &apphost.Clear{},
+			Headers: []apphost.Header{{Name: "X-Frame-Options", Value: "SAMEORIGIN"}},
+		}
+	})
+
+	Describe("Marshal", func() {
+		It("writes an indented document with self closing empty elements", func() {
+			data, err := config.Marshal()
+			Expect(err).ToNot(HaveOccurred())
+
+			document := string(data)
+			Expect(document).To(HavePrefix(xml.Header + "<configuration>\n  <configSections>\n"))
+			Expect(document).To(ContainSubstring(`
+    <sectionGroup name="system.webServer">
+      <section name="asp" overrideModeDefault="Allow" />
+      <sectionGroup name="security">
+        <section name="requestFiltering" overrideModeDefault="Allow" />
+      </sectionGroup>
+      <section name="webSocket" overrideModeDefault="Deny" />
+    </sectionGroup>
+`))
+			Expect(document).To(ContainSubstring(`<add name="StaticFileModule" image="%windir%\System32\inetsrv\static.dll" />`))
+			Expect(document).To(ContainSubstring(`<add name="StaticFileModule" lockItem="true" />`))
+			Expect(document).To(ContainSubstring(`<add name="OutputCache" type="System.Web.Caching.OutputCacheModule" preCondition="managedHandler" />`))
+			Expect(document).To(ContainSubstring("<customHeaders>\n        <clear />\n        <add name=\"X-Frame-Options\" value=\"SAMEORIGIN\" />\n"))
+			Expect(document).To(ContainSubstring("<cgi />"))
+			Expect(document).ToNot(ContainSubstring("<rewrite"))
+			Expect(document).To(HaveSuffix("</configuration>\n"))
+		})
+
+		It("writes empty collections as empty elements", func() {
+			config.SystemWebServer.Modules = nil
+			data, err := config.Marshal()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(string(data)).To(ContainSubstring("<modules />"))
+			Expect(strings.Count(string(data), "<system.webServer>")).To(Equal(1))
+		})
+	})
+
+	Describe("Unmarshal", func() {
+		It("reads back what Marshal wrote", func() {
+			data, err := config.Marshal()
+			Expect(err).ToNot(HaveOccurred())
+
+			parsed, err := apphost.Unmarshal(data)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(parsed.SystemWebServer.GlobalModules).To(Equal(config.SystemWebServer.GlobalModules))
+			Expect(parsed.SystemWebServer.Modules).To(Equal(config.SystemWebServer.Modules))
+			Expect(parsed.SystemWebServer.HTTPProtocol.CustomHeaders).To(Equal(config.SystemWebServer.HTTPProtocol.CustomHeaders))
+
+			reparsed, err := parsed.Marshal()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(string(reparsed)).To(Equal(string(data)))
+		})
+	})
+
+	Describe("ConfigSections.Group", func() {
+		It("finds nested section groups", func() {
+			group := config.ConfigSections.Group("system.webServer", "security")
+			Expect(group).ToNot(BeNil())
+			Expect(group.Entries).To(Equal([]apphost.ConfigSection{apphost.Section("requestFiltering", "Allow", "")}))
+		})
+
+		It("returns a group that can be changed in place", func() {
+			group := config.ConfigSections.Group("system.webServer")
+			group.Entries = append(group.Entries, apphost.Section("aspNetCore", "Allow", ""))
+			Expect(config.ConfigSections.Entries[0].Entries).To(HaveLen(4))
+		})
+
+		It("returns nil for undeclared groups and for sections", func() {
+			Expect(config.ConfigSections.Group("system.webServer", "rewrite")).To(BeNil())
+			Expect(config.ConfigSections.Group("system.webServer", "asp")).To(BeNil())
+		})
+	})
+})
@@ -0,0 +1,180 @@
+// Package configdiff compares IIS configuration files by their settings
+// rather than their text. Whitespace, comments and the order of attributes
+// don't matter; the order of elements does, since IIS applies handlers and
+// modules in the order they're listed.
+package configdiff
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"sort"
+	"strings"
+)
+
+// Files are the configuration files hwc generates, in the order they're
+// compared.
+var Files = []string{"ApplicationHost.config", "Aspnet.config", "Web.config"}
+
+type Attr struct {
+	Name  string
+	Value string
+}
+
+// Element is an element with its attributes sorted by name and its text
+// trimmed of surrounding whitespace.
+type Element struct {
+	Name     string
+	Attrs    []Attr
+	Text     string
+	Children []*Element
+}
+
+func (e *Element) Attr(name string) (string, bool) {
+	for _, a := range e.Attrs {
+		if a.Name == name {
+			return a.Value, true
+		}
+	}
+	return "", false
+}
+
+// Parse returns the document element of data.
+func Parse(data []byte) (*Element, error) {
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	var stack []*Element
+	var root *Element
+	for {
+		token, err := decoder.RawToken()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			e := &Element{Name: qualifiedName(t.Name)}
+			for _, a := range t.Attr {
+				e.Attrs = append(e.Attrs, Attr{Name: qualifiedName(a.Name), Value: a.Value})
+			}
+			sort.Slice(e.Attrs, func(i, j int) bool { return e.Attrs[i].Name < e.Attrs[j].Name })
+			if len(stack) == 0 {
+				if root != nil {
+					return nil, fmt.Errorf("more than one document element")
+				}
+				root = e
+			} else {
+				parent := stack[len(stack)-1]
+				parent.Children = append(parent.Children, e)
+			}
+			stack = append(stack, e)
+		case xml.EndElement:
+			if len(stack) == 0 {
+				return nil, fmt.Errorf("unexpected </%s>", qualifiedName(t.Name))
+			}
+			e := stack[len(stack)-1]
+			e.Text = strings.TrimSpace(e.Text)
+			stack = stack[:len(stack)-1]
+		case xml.CharData:
+			if len(stack) > 0 {
+				stack[len(stack)-1].Text += string(t)
+			}
+		}
+	}
+	if len(stack) != 0 {
+		return nil, fmt.Errorf("<%s> is not closed", stack[len(stack)-1].Name)
+	}
+	if root == nil {
+		return nil, fmt.Errorf("no document element")
+	}
+	return root, nil
+}
+
+func ParseFile(path string) (*Element, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	e, err := Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("Parsing %s: %v", path, err)
+	}
+	return e, nil
+}
+
+// Fingerprint is a SHA-256 hash of the settings in data. Documents that Diff
+// finds no differences between have the same fingerprint. The value of every
+// element named by one of masked, such as <add name="X-CF-Instance-Index"
+// value="0" />, is left out, so settings that differ between instances of an
+// app by design don't change the fingerprint.
+func Fingerprint(data []byte, masked ...string) (string, error) {
+	root, err := Parse(data)
+	if err != nil {
+		return "", err
+	}
+	if len(masked) > 0 {
+		names := map[string]bool{}
+		for _, name := range masked {
+			names[name] = true
+		}
+		root.mask(names)
+	}
+	hash := sha256.New()
+	root.writeCanonical(hash)
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+func FingerprintFile(path string, masked ...string) (string, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	fingerprint, err := Fingerprint(data, masked...)
+	if err != nil {
+		return "", fmt.Errorf("Parsing %s: %v", path, err)
+	}
+	return fingerprint, nil
+}
+
+func (e *Element) mask(names map[string]bool) {
+	if name, ok := e.Attr("name"); ok && names[name] {
+		for i := range e.Attrs {
+			if e.Attrs[i].Name == "value" {
+				e.Attrs[i].Value = ""
+			}
+		}
+	}
+	for _, child := range e.Children {
+		child.mask(names)
+	}
+}
+
+// writeCanonical writes e as XML with sorted attributes and no whitespace
+// between elements.
+func (e *Element) writeCanonical(w io.Writer) {
+	io.WriteString(w, "<"+e.Name)
+	for _, a := range e.Attrs {
+		io.WriteString(w, " "+a.Name+`="`)
+		xml.EscapeText(w, []byte(a.Value))
+		io.WriteString(w, `"`)
+	}
+	io.WriteString(w, ">")
+	xml.EscapeText(w, []byte(e.Text))
+	for _, child := range e.Children {
+		child.writeCanonical(w)
+	}
+	io.WriteString(w, "</"+e.Name+">")
+}
+
+func qualifiedName(name xml.Name) string {
+	if name.Space == "" {
+		return name.Local
+	}
+	return name.Space + ":" + name.Local
+}
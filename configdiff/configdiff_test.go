@@ -0,0 +1,117 @@
+package configdiff_test
+
+import (
+	"bytes"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"code.cloudfoundry.org/hwc/configdiff"
+)
+
+var _ = Describe("Configdiff", func() {
+	var diff = func(a, b string) []configdiff.Change {
+		aRoot, err := configdiff.Parse([]byte(a))
+		Expect(err).ToNot(HaveOccurred())
+		bRoot, err := configdiff.Parse([]byte(b))
+		Expect(err).ToNot(HaveOccurred())
+		return configdiff.Diff(aRoot, bRoot)
+	}
+
+	Describe("Fingerprint", func() {
+		It("ignores whitespace, comments and attribute order", func() {
+			a, err := configdiff.Fingerprint([]byte(`<configuration><add name="a" path="*" /></configuration>`))
+			Expect(err).ToNot(HaveOccurred())
+			b, err := configdiff.Fingerprint([]byte("<?xml version=\"1.0\"?>\n<!-- x -->\n<configuration>\n  <add path=\"*\"\n       name=\"a\"></add>\n</configuration>\n"))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(a).To(Equal(b))
+			Expect(a).To(HaveLen(64))
+		})
+
+		It("changes with the settings and their order", func() {
+			a, err := configdiff.Fingerprint([]byte(`<configuration><add name="a" /><add name="b" /></configuration>`))
+			Expect(err).ToNot(HaveOccurred())
+			b, err := configdiff.Fingerprint([]byte(`<configuration><add name="b" /><add name="a" /></configuration>`))
+			Expect(err).ToNot(HaveOccurred())
+			c, err := configdiff.Fingerprint([]byte(`<configuration><add name="a" /><add name="b" value="1" /></configuration>`))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(a).ToNot(Equal(b))
+			Expect(a).ToNot(Equal(c))
+		})
+
+		It("leaves out the values of masked elements", func() {
+			a, err := configdiff.Fingerprint([]byte(`<configuration><add name="X-CF-Instance-Index" value="0" /><add name="X-CF-App-Name" value="app" /></configuration>`), "X-CF-Instance-Index")
+			Expect(err).ToNot(HaveOccurred())
+			b, err := configdiff.Fingerprint([]byte(`<configuration><add name="X-CF-Instance-Index" value="1" /><add name="X-CF-App-Name" value="app" /></configuration>`), "X-CF-Instance-Index")
+			Expect(err).ToNot(HaveOccurred())
+			c, err := configdiff.Fingerprint([]byte(`<configuration><add name="X-CF-Instance-Index" value="1" /><add name="X-CF-App-Name" value="other" /></configuration>`), "X-CF-Instance-Index")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(a).To(Equal(b))
+			Expect(a).ToNot(Equal(c))
+		})
+
+		It("fails on documents that aren't XML", func() {
+			_, err := configdiff.Fingerprint([]byte(`<configuration>`))
+			Expect(err).To(MatchError("<configuration> is not closed"))
+		})
+	})
+
+	Describe("Diff", func() {
+		It("finds nothing between documents with the same settings", func() {
+			Expect(diff(`<configuration><a x="1" y="2">text</a></configuration>`,
+				"<configuration>\n  <a y=\"2\" x=\"1\">\n    text\n  </a>\n</configuration>")).To(BeEmpty())
+		})
+
+		It("reports changed, added and removed attributes and text", func() {
+			Expect(diff(`<configuration><a x="1" y="2">old</a></configuration>`,
+				`<configuration><a x="3" z="4">new</a></configuration>`)).To(Equal([]configdiff.Change{
+				{Kind: configdiff.Changed, Path: "a", Attribute: "x", Old: "1", New: "3"},
+				{Kind: configdiff.Removed, Path: "a", Attribute: "y", Old: "2"},
+				{Kind: configdiff.Added, Path: "a", Attribute: "z", New: "4"},
+				{Kind: configdiff.Changed, Path: "a", Attribute: configdiff.Text, Old: "old", New: "new"},
+			}))
+		})
+
+		It("matches elements by their key attributes", func() {
+			Expect(diff(`<configuration><handlers><add name="a" verb="GET" /><add name="b" /></handlers></configuration>`,
+				`<configuration><handlers><add name="b" /><add name="c" /><add name="a" verb="POST" /></handlers></configuration>`)).To(Equal([]configdiff.Change{
+				{Kind: configdiff.Changed, Path: "handlers/add[@name='a']", Attribute: "verb", Old: "GET", New: "POST"},
+				{Kind: configdiff.Added, Path: "handlers/add[@name='c']"},
+				{Kind: configdiff.Reordered, Path: "handlers"},
+			}))
+		})
+
+		It("numbers siblings that have the same key", func() {
+			Expect(diff(`<configuration><providerOption /><providerOption /></configuration>`,
+				`<configuration><providerOption /><providerOption enabled="true" /></configuration>`)).To(Equal([]configdiff.Change{
+				{Kind: configdiff.Added, Path: "providerOption[2]", Attribute: "enabled", New: "true"},
+			}))
+		})
+	})
+
+	Describe("Dirs", func() {
+		It("compares each generated file and prints the changes with their paths", func() {
+			fixtures := filepath.Join("..", "fixtures", "configdiff")
+			diffs, err := configdiff.Dirs(filepath.Join(fixtures, "a"), filepath.Join(fixtures, "b"))
+			Expect(err).ToNot(HaveOccurred())
+
+			var out bytes.Buffer
+			Expect(configdiff.Write(&out, diffs)).To(BeTrue())
+			Expect(out.String()).To(Equal(`ApplicationHost.config:
+  ~ system.webServer/security/requestFiltering/requestLimits @maxUrl: "260" -> "8192"
+  + system.webServer/security/requestFiltering/requestLimits @maxQueryString="4096"
+  - system.webServer/handlers/add[@name='TRACEVerbHandler']
+  + system.webServer/staticContent/mimeMap[@fileExtension='.avif']
+Aspnet.config: no differences
+Web.config:
+  + system.web/compilation @debug="true"
+`))
+		})
+
+		It("fails when a file is missing", func() {
+			_, err := configdiff.Dirs(filepath.Join("..", "fixtures", "configdiff", "a"), filepath.Join("..", "fixtures", "configdiff"))
+			Expect(err).To(HaveOccurred())
+		})
+	})
+})
@@ -0,0 +1,210 @@
+package configdiff
+
+import (
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+)
+
+type Kind string
+
+const (
+	Added     Kind = "added"
+	Removed   Kind = "removed"
+	Changed   Kind = "changed"
+	Reordered Kind = "reordered"
+)
+
+// Text is the Attribute of changes to an element's text.
+const Text = "text()"
+
+// Change is one difference between two documents. Path addresses the element
+// below the document element, in the syntax overlays use. Attribute is empty
+// when a whole element was added, removed or had its children reordered.
+type Change struct {
+	Kind      Kind
+	Path      string
+	Attribute string
+	Old       string
+	New       string
+}
+
+func (c Change) String() string {
+	path := c.Path
+	if path == "" {
+		path = "/"
+	}
+	switch {
+	case c.Kind == Reordered:
+		return fmt.Sprintf("~ %s: children reordered", path)
+	case c.Attribute == "" && c.Kind == Added:
+		return "+ " + path
+	case c.Attribute == "" && c.Kind == Removed:
+		return "- " + path
+	case c.Kind == Added:
+		return fmt.Sprintf("+ %s %s=%q", path, c.displayAttribute(), c.New)
+	case c.Kind == Removed:
+		return fmt.Sprintf("- %s %s=%q", path, c.displayAttribute(), c.Old)
+	default:
+		return fmt.Sprintf("~ %s %s: %q -> %q", path, c.displayAttribute(), c.Old, c.New)
+	}
+}
+
+func (c Change) displayAttribute() string {
+	if c.Attribute == Text {
+		return Text
+	}
+	return "@" + c.Attribute
+}
+
+// keyAttributes identify an element among its siblings, like the key
+// attributes of IIS collections. The first one an element has is used.
+var keyAttributes = []string{
+	"name", "fileExtension", "mimeType", "statusCode", "path", "assemblyName",
+	"assembly", "extension", "expressionPrefix", "language", "alias", "verb",
+	"segment", "sequence", "key", "value", "fullPath", "id",
+}
+
+// Diff returns the changes that turn a into b.
+func Diff(a, b *Element) []Change {
+	if a.Name != b.Name {
+		return []Change{{Kind: Removed, Path: a.Name}, {Kind: Added, Path: b.Name}}
+	}
+	return diffElement("", a, b)
+}
+
+func diffElement(path string, a, b *Element) []Change {
+	var changes []Change
+
+	for _, attr := range a.Attrs {
+		if value, ok := b.Attr(attr.Name); !ok {
+			changes = append(changes, Change{Kind: Removed, Path: path, Attribute: attr.Name, Old: attr.Value})
+		} else if value != attr.Value {
+			changes = append(changes, Change{Kind: Changed, Path: path, Attribute: attr.Name, Old: attr.Value, New: value})
+		}
+	}
+	for _, attr := range b.Attrs {
+		if _, ok := a.Attr(attr.Name); !ok {
+			changes = append(changes, Change{Kind: Added, Path: path, Attribute: attr.Name, New: attr.Value})
+		}
+	}
+	if a.Text != b.Text {
+		changes = append(changes, Change{Kind: Changed, Path: path, Attribute: Text, Old: a.Text, New: b.Text})
+	}
+
+	aSteps, bSteps := steps(a.Children), steps(b.Children)
+	bIndex := map[string]int{}
+	for i, s := range bSteps {
+		bIndex[s] = i
+	}
+	aIndex := map[string]int{}
+	for i, s := range aSteps {
+		aIndex[s] = i
+	}
+
+	var aCommon, bCommon []string
+	for i, s := range aSteps {
+		j, ok := bIndex[s]
+		if !ok {
+			changes = append(changes, Change{Kind: Removed, Path: join(path, s)})
+			continue
+		}
+		aCommon = append(aCommon, s)
+		changes = append(changes, diffElement(join(path, s), a.Children[i], b.Children[j])...)
+	}
+	for _, s := range bSteps {
+		if _, ok := aIndex[s]; !ok {
+			changes = append(changes, Change{Kind: Added, Path: join(path, s)})
+		} else {
+			bCommon = append(bCommon, s)
+		}
+	}
+	if strings.Join(aCommon, "\x00") != strings.Join(bCommon, "\x00") {
+		changes = append(changes, Change{Kind: Reordered, Path: path})
+	}
+	return changes
+}
+
+// steps names each child by its element name and key attribute, adding its
+// position among siblings with the same name and key when there's more than
+// one.
+func steps(children []*Element) []string {
+	names := make([]string, len(children))
+	count := map[string]int{}
+	for i, child := range children {
+		names[i] = child.Name
+		for _, key := range keyAttributes {
+			if value, ok := child.Attr(key); ok {
+				names[i] = fmt.Sprintf("%s[@%s=%s]", child.Name, key, quote(value))
+				break
+			}
+		}
+		count[names[i]]++
+	}
+
+	seen := map[string]int{}
+	for i, name := range names {
+		if count[name] > 1 {
+			seen[name]++
+			names[i] = fmt.Sprintf("%s[%d]", name, seen[name])
+		}
+	}
+	return names
+}
+
+func quote(value string) string {
+	if strings.Contains(value, "'") {
+		return `"` + value + `"`
+	}
+	return "'" + value + "'"
+}
+
+func join(path, step string) string {
+	if path == "" {
+		return step
+	}
+	return path + "/" + step
+}
+
+// FileDiff holds the changes to one of the Files.
+type FileDiff struct {
+	File    string
+	Changes []Change
+}
+
+// Dirs compares the Files in two directories, such as the config directories
+// of two instances.
+func Dirs(a, b string) ([]FileDiff, error) {
+	var diffs []FileDiff
+	for _, file := range Files {
+		aRoot, err := ParseFile(filepath.Join(a, file))
+		if err != nil {
+			return nil, err
+		}
+		bRoot, err := ParseFile(filepath.Join(b, file))
+		if err != nil {
+			return nil, err
+		}
+		diffs = append(diffs, FileDiff{File: file, Changes: Diff(aRoot, bRoot)})
+	}
+	return diffs, nil
+}
+
+// Write prints the changes to each file under its name and reports whether
+// there were any.
+func Write(w io.Writer, diffs []FileDiff) bool {
+	different := false
+	for _, d := range diffs {
+		if len(d.Changes) == 0 {
+			fmt.Fprintf(w, "%s: no differences\n", d.File)
+			continue
+		}
+		different = true
+		fmt.Fprintf(w, "%s:\n", d.File)
+		for _, c := range d.Changes {
+			fmt.Fprintf(w, "  %s\n", c)
+		}
+	}
+	return different
+}
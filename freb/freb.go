@@ -0,0 +1,189 @@
+// Package freb summarizes the trace files written by IIS Failed Request
+// Tracing so that each traced request can be reported as a single line.
+package freb
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Summary describes a traced request. Module and HTTPReason come from the
+// first module that set an error status, if any did.
+type Summary struct {
+	File              string
+	URL               string
+	Verb              string
+	StatusCode        string
+	TriggerStatusCode string
+	TimeTaken         string
+	FailureReason     string
+	Module            string
+	HTTPReason        string
+}
+
+type failedRequest struct {
+	XMLName           xml.Name `xml:"failedRequest"`
+	URL               string   `xml:"url,attr"`
+	Verb              string   `xml:"verb,attr"`
+	StatusCode        string   `xml:"statusCode,attr"`
+	TriggerStatusCode string   `xml:"triggerStatusCode,attr"`
+	TimeTaken         string   `xml:"timeTaken,attr"`
+	FailureReason     string   `xml:"failureReason,attr"`
+	Events            []struct {
+		Data []struct {
+			Name  string `xml:"Name,attr"`
+			Value string `xml:",chardata"`
+		} `xml:"EventData>Data"`
+		Opcode string `xml:"RenderingInfo>Opcode"`
+	} `xml:"Event"`
+}
+
+func ParseFile(path string) (Summary, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return Summary{}, err
+	}
+
+	var fr failedRequest
+	if err := xml.Unmarshal(data, &fr); err != nil {
+		return Summary{}, fmt.Errorf("Parsing failed request trace %s: %v", path, err)
+	}
+
+	summary := Summary{
+		File:              filepath.Base(path),
+		URL:               fr.URL,
+		Verb:              fr.Verb,
+		StatusCode:        fr.StatusCode,
+		TriggerStatusCode: fr.TriggerStatusCode,
+		TimeTaken:         fr.TimeTaken,
+		FailureReason:     fr.FailureReason,
+	}
+
+	for _, event := range fr.Events {
+		if event.Opcode != "MODULE_SET_RESPONSE_ERROR_STATUS" {
+			continue
+		}
+		for _, data := range event.Data {
+			switch data.Name {
+			case "ModuleName":
+				summary.Module = data.Value
+			case "HttpReason":
+				summary.HTTPReason = data.Value
+			}
+		}
+		break
+	}
+	return summary, nil
+}
+
+func (s Summary) String() string {
+	parts := []string{
+		"Failed request trace:",
+		s.Verb,
+		s.URL,
+		"status=" + s.StatusCode,
+		"timeTaken=" + s.TimeTaken + "ms",
+		"reason=" + s.FailureReason,
+	}
+	if s.Module != "" {
+		parts = append(parts, "module="+s.Module)
+	}
+	if s.HTTPReason != "" {
+		parts = append(parts, fmt.Sprintf("httpReason=%q", s.HTTPReason))
+	}
+	parts = append(parts, "file="+s.File)
+	return strings.Join(parts, " ")
+}
+
+// Watcher reports the trace files IIS writes to Directory after the Watcher
+// starts. The directory does not need to exist yet.
+type Watcher struct {
+	Directory    string
+	PollInterval time.Duration
+
+	seen map[string]bool
+}
+
+func NewWatcher(directory string) *Watcher {
+	return &Watcher{
+		Directory:    directory,
+		PollInterval: time.Second,
+	}
+}
+
+// Run calls handle for each new trace file until stop is closed. Files that
+// can't be parsed yet are retried on the next poll, since IIS may still be
+// writing them.
+func (w *Watcher) Run(stop <-chan struct{}, handle func(Summary)) error {
+	w.seen = map[string]bool{}
+	existing, err := w.traceFiles()
+	if err != nil {
+		return err
+	}
+	for _, path := range existing {
+		w.seen[path] = true
+	}
+
+	ticker := time.NewTicker(w.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return w.poll(handle)
+		case <-ticker.C:
+			if err := w.poll(handle); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func (w *Watcher) poll(handle func(Summary)) error {
+	paths, err := w.traceFiles()
+	if err != nil {
+		return err
+	}
+
+	current := map[string]bool{}
+	for _, path := range paths {
+		current[path] = true
+		if w.seen[path] {
+			continue
+		}
+
+		summary, err := ParseFile(path)
+		if err != nil {
+			continue
+		}
+		w.seen[path] = true
+		handle(summary)
+	}
+
+	for path := range w.seen {
+		if !current[path] {
+			delete(w.seen, path)
+		}
+	}
+	return nil
+}
+
+func (w *Watcher) traceFiles() ([]string, error) {
+	paths, err := filepath.Glob(filepath.Join(w.Directory, "fr*.xml"))
+	if err != nil {
+		return nil, err
+	}
+
+	var files []string
+	for _, path := range paths {
+		if info, err := os.Stat(path); err == nil && !info.IsDir() {
+			files = append(files, path)
+		}
+	}
+	return files, nil
+}
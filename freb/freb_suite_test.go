@@ -0,0 +1,13 @@
+package freb_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func TestFreb(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Freb Suite")
+}
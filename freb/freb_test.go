@@ -0,0 +1,131 @@
+package freb_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"code.cloudfoundry.org/hwc/freb"
+)
+
+var _ = Describe("Freb", func() {
+	Describe("ParseFile", func() {
+		It("summarizes the failed request", func() {
+			summary, err := freb.ParseFile("../fixtures/freb/fr000001.xml")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(summary).To(Equal(freb.Summary{
+				File:              "fr000001.xml",
+				URL:               "http://localhost:8080/api/orders?id=7",
+				Verb:              "POST",
+				StatusCode:        "500",
+				TriggerStatusCode: "500",
+				TimeTaken:         "1042",
+				FailureReason:     "STATUS_CODE",
+				Module:            "ManagedPipelineHandler",
+				HTTPReason:        "Internal Server Error",
+			}))
+		})
+
+		It("formats the summary as a single line", func() {
+			summary, err := freb.ParseFile("../fixtures/freb/fr000001.xml")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(summary.String()).To(Equal(`Failed request trace: POST http://localhost:8080/api/orders?id=7 status=500 timeTaken=1042ms reason=STATUS_CODE module=ManagedPipelineHandler httpReason="Internal Server Error" file=fr000001.xml`))
+		})
+
+		It("returns an error for an incomplete trace", func() {
+			dir, err := ioutil.TempDir("", "freb")
+			Expect(err).ToNot(HaveOccurred())
+			defer os.RemoveAll(dir)
+
+			path := filepath.Join(dir, "fr000002.xml")
+			Expect(ioutil.WriteFile(path, []byte(`<failedRequest url="/" `), 0666)).To(Succeed())
+			_, err = freb.ParseFile(path)
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	Describe("Watcher", func() {
+		var (
+			traceDirectory string
+			watcher        *freb.Watcher
+			stop           chan struct{}
+			done           chan error
+			summaries      chan freb.Summary
+			fixture        []byte
+		)
+
+		BeforeEach(func() {
+			dir, err := ioutil.TempDir("", "freb")
+			Expect(err).ToNot(HaveOccurred())
+			traceDirectory = filepath.Join(dir, "W3SVC8080")
+
+			fixture, err = ioutil.ReadFile("../fixtures/freb/fr000001.xml")
+			Expect(err).ToNot(HaveOccurred())
+
+			watcher = freb.NewWatcher(traceDirectory)
+			watcher.PollInterval = 10 * time.Millisecond
+			stop = make(chan struct{})
+			done = make(chan error, 1)
+			summaries = make(chan freb.Summary, 10)
+		})
+
+		JustBeforeEach(func() {
+			go func() {
+				done <- watcher.Run(stop, func(s freb.Summary) { summaries <- s })
+			}()
+		})
+
+		AfterEach(func() {
+			close(stop)
+			Eventually(done).Should(Receive(BeNil()))
+			Expect(os.RemoveAll(filepath.Dir(traceDirectory))).To(Succeed())
+		})
+
+		It("reports traces written after it starts", func() {
+			Consistently(summaries, 50*time.Millisecond).ShouldNot(Receive())
+
+			Expect(os.MkdirAll(traceDirectory, 0777)).To(Succeed())
+			Expect(ioutil.WriteFile(filepath.Join(traceDirectory, "fr000001.xml"), fixture, 0666)).To(Succeed())
+			Expect(ioutil.WriteFile(filepath.Join(traceDirectory, "freb.xsl"), []byte("<xsl/>"), 0666)).To(Succeed())
+
+			var s freb.Summary
+			Eventually(summaries).Should(Receive(&s))
+			Expect(s.File).To(Equal("fr000001.xml"))
+			Consistently(summaries, 50*time.Millisecond).ShouldNot(Receive())
+		})
+
+		It("retries traces that are still being written", func() {
+			Consistently(summaries, 50*time.Millisecond).ShouldNot(Receive())
+
+			Expect(os.MkdirAll(traceDirectory, 0777)).To(Succeed())
+			path := filepath.Join(traceDirectory, "fr000002.xml")
+			Expect(ioutil.WriteFile(path, fixture[:200], 0666)).To(Succeed())
+			Consistently(summaries, 50*time.Millisecond).ShouldNot(Receive())
+
+			Expect(ioutil.WriteFile(path, fixture, 0666)).To(Succeed())
+			var s freb.Summary
+			Eventually(summaries).Should(Receive(&s))
+			Expect(s.File).To(Equal("fr000002.xml"))
+		})
+
+		Context("when traces already exist", func() {
+			BeforeEach(func() {
+				Expect(os.MkdirAll(traceDirectory, 0777)).To(Succeed())
+				Expect(ioutil.WriteFile(filepath.Join(traceDirectory, "fr000001.xml"), fixture, 0666)).To(Succeed())
+			})
+
+			It("skips them", func() {
+				Consistently(summaries, 50*time.Millisecond).ShouldNot(Receive())
+
+				Expect(ioutil.WriteFile(filepath.Join(traceDirectory, "fr000002.xml"), fixture, 0666)).To(Succeed())
+				var s freb.Summary
+				Eventually(summaries).Should(Receive(&s))
+				Expect(s.File).To(Equal("fr000002.xml"))
+			})
+		})
+	})
+})
@@ -0,0 +1,82 @@
+// Package healthcheck probes the site hwc hosts over HTTP, so hwc can tell
+// whether the app is actually serving requests rather than just whether IIS
+// is listening.
+package healthcheck
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"time"
+)
+
+// Probe requests URL and expects ExpectedStatus. An ExpectedStatus of 0
+// accepts any response that isn't a server error. OnCheck, when set, is
+// called with the result of every check.
+type Probe struct {
+	URL            string
+	ExpectedStatus int
+	Timeout        time.Duration
+	Interval       time.Duration
+	OnCheck        func(error)
+}
+
+func New(url string, expectedStatus int, timeout time.Duration) *Probe {
+	return &Probe{
+		URL:            url,
+		ExpectedStatus: expectedStatus,
+		Timeout:        timeout,
+		Interval:       time.Second,
+	}
+}
+
+// Check requests URL once. Redirects are not followed, so a redirect to a
+// login page doesn't count as healthy.
+func (p *Probe) Check() error {
+	err := p.check()
+	if p.OnCheck != nil {
+		p.OnCheck(err)
+	}
+	return err
+}
+
+func (p *Probe) check() error {
+	client := &http.Client{
+		Timeout: p.Timeout,
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+
+	res, err := client.Get(p.URL)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	io.Copy(ioutil.Discard, res.Body)
+
+	if p.ExpectedStatus == 0 && res.StatusCode >= 500 {
+		return fmt.Errorf("GET %s returned %d", p.URL, res.StatusCode)
+	}
+	if p.ExpectedStatus != 0 && res.StatusCode != p.ExpectedStatus {
+		return fmt.Errorf("GET %s returned %d, expected %d", p.URL, res.StatusCode, p.ExpectedStatus)
+	}
+	return nil
+}
+
+// WaitUntilReady checks URL every Interval until a check passes, or returns
+// the last failure once deadline has passed.
+func (p *Probe) WaitUntilReady(deadline time.Duration) error {
+	expires := time.Now().Add(deadline)
+	for {
+		err := p.Check()
+		if err == nil {
+			return nil
+		}
+		if time.Now().Add(p.Interval).After(expires) {
+			return fmt.Errorf("App did not become ready within %s: %v", deadline, err)
+		}
+		time.Sleep(p.Interval)
+	}
+}
@@ -0,0 +1,153 @@
+package healthcheck_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"code.cloudfoundry.org/hwc/healthcheck"
+)
+
+var _ = Describe("Probe", func() {
+	var (
+		server   *httptest.Server
+		requests int32
+		failures int32
+		status   int
+		delay    time.Duration
+		probe    *healthcheck.Probe
+	)
+
+	BeforeEach(func() {
+		requests = 0
+		failures = 0
+		status = http.StatusOK
+		delay = 0
+	})
+
+	JustBeforeEach(func() {
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			n := atomic.AddInt32(&requests, 1)
+			time.Sleep(delay)
+			switch {
+			case r.URL.Path == "/login":
+				w.WriteHeader(http.StatusOK)
+			case n <= atomic.LoadInt32(&failures):
+				w.WriteHeader(http.StatusServiceUnavailable)
+			case status == http.StatusFound:
+				http.Redirect(w, r, "/login", http.StatusFound)
+			default:
+				w.WriteHeader(status)
+			}
+		}))
+		probe = healthcheck.New(server.URL+"/health", http.StatusOK, 100*time.Millisecond)
+		probe.Interval = 10 * time.Millisecond
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	Describe("Check", func() {
+		It("passes when the site returns the expected status", func() {
+			Expect(probe.Check()).To(Succeed())
+		})
+
+		Context("when the site returns another status", func() {
+			BeforeEach(func() {
+				status = http.StatusInternalServerError
+			})
+
+			It("fails", func() {
+				Expect(probe.Check()).To(MatchError("GET " + server.URL + "/health returned 500, expected 200"))
+			})
+		})
+
+		Context("when any status is expected", func() {
+			JustBeforeEach(func() {
+				probe.ExpectedStatus = 0
+			})
+
+			Context("and the site returns a client error", func() {
+				BeforeEach(func() {
+					status = http.StatusNotFound
+				})
+
+				It("passes", func() {
+					Expect(probe.Check()).To(Succeed())
+				})
+			})
+
+			Context("and the site returns a server error", func() {
+				BeforeEach(func() {
+					status = http.StatusServiceUnavailable
+				})
+
+				It("fails", func() {
+					Expect(probe.Check()).To(MatchError("GET " + server.URL + "/health returned 503"))
+				})
+			})
+		})
+
+		Context("when the site redirects", func() {
+			BeforeEach(func() {
+				status = http.StatusFound
+			})
+
+			It("does not follow the redirect", func() {
+				Expect(probe.Check()).To(MatchError("GET " + server.URL + "/health returned 302, expected 200"))
+			})
+		})
+
+		Context("when the site is slower than the timeout", func() {
+			BeforeEach(func() {
+				delay = 200 * time.Millisecond
+			})
+
+			It("fails", func() {
+				Expect(probe.Check()).To(HaveOccurred())
+			})
+		})
+	})
+
+	Describe("WaitUntilReady", func() {
+		Context("when the app takes a while to start", func() {
+			BeforeEach(func() {
+				failures = 3
+			})
+
+			It("retries until a check passes", func() {
+				Expect(probe.WaitUntilReady(time.Second)).To(Succeed())
+				Expect(atomic.LoadInt32(&requests)).To(Equal(int32(4)))
+			})
+
+			It("reports every check to OnCheck", func() {
+				var results []error
+				probe.OnCheck = func(err error) {
+					results = append(results, err)
+				}
+				Expect(probe.WaitUntilReady(time.Second)).To(Succeed())
+				Expect(results).To(HaveLen(4))
+				Expect(results[0]).To(MatchError(HaveSuffix("returned 503, expected 200")))
+				Expect(results[3]).ToNot(HaveOccurred())
+			})
+		})
+
+		Context("when the app never becomes healthy", func() {
+			BeforeEach(func() {
+				status = http.StatusServiceUnavailable
+			})
+
+			It("gives up after the deadline", func() {
+				start := time.Now()
+				err := probe.WaitUntilReady(100 * time.Millisecond)
+				Expect(err).To(MatchError(HavePrefix("App did not become ready within 100ms: GET")))
+				Expect(time.Since(start)).To(BeNumerically("<", 500*time.Millisecond))
+			})
+		})
+	})
+})
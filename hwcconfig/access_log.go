@@ -0,0 +1,127 @@
+package hwcconfig
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// AccessLog controls whether IIS logs requests, which fields the site's W3C
+// log contains, how it rolls over, and how hwc re-emits the requests on
+// stdout. Logging is disabled when Format is empty.
+type AccessLog struct {
+	Format       string           `json:"format"`
+	Fields       []string         `json:"fields"`
+	Period       string           `json:"period"`
+	TruncateSize uint64           `json:"truncateSize"`
+	CustomFields []CustomLogField `json:"customFields"`
+}
+
+// CustomLogField adds a request header, response header or server variable to
+// the W3C log.
+type CustomLogField struct {
+	LogFieldName string `json:"logFieldName"`
+	SourceName   string `json:"sourceName"`
+	SourceType   string `json:"sourceType"`
+}
+
+var logExtFileFlags = []string{
+	"Date", "Time", "ClientIP", "UserName", "SiteName", "ComputerName", "ServerIP",
+	"Method", "UriStem", "UriQuery", "HttpStatus", "Win32Status", "BytesSent",
+	"BytesRecv", "TimeTaken", "ServerPort", "UserAgent", "Cookie", "Referer",
+	"ProtocolVersion", "Host", "HttpSubStatus",
+}
+
+func DefaultAccessLog() AccessLog {
+	return AccessLog{
+		Fields: []string{
+			"Date", "Time", "ClientIP", "UserName", "ServerIP", "Method", "UriStem", "UriQuery",
+			"HttpStatus", "Win32Status", "TimeTaken", "ServerPort", "UserAgent", "Referer", "HttpSubStatus",
+		},
+		Period:       "Daily",
+		TruncateSize: 20971520,
+		CustomFields: []CustomLogField{
+			requestHeaderLogField("X-Forwarded-For"),
+			requestHeaderLogField("X-Vcap-Request-Id"),
+			requestHeaderLogField("X-B3-TraceId"),
+		},
+	}
+}
+
+func requestHeaderLogField(header string) CustomLogField {
+	return CustomLogField{LogFieldName: header, SourceName: header, SourceType: "RequestHeader"}
+}
+
+func (al AccessLog) Enabled() bool {
+	return al.Format != ""
+}
+
+// CustomFieldNames are the names CustomFields are logged under.
+func (al AccessLog) CustomFieldNames() []string {
+	var names []string
+	for _, field := range al.CustomFields {
+		names = append(names, field.LogFieldName)
+	}
+	return names
+}
+
+// LogExtFileFlags is the logExtFileFlags attribute value for Fields.
+func (al AccessLog) LogExtFileFlags() string {
+	return strings.Join(al.Fields, ", ")
+}
+
+func (al *AccessLog) loadEnv() error {
+	if s, ok := os.LookupEnv("HWC_ACCESS_LOG"); ok {
+		al.Format = s
+	}
+	envList("HWC_ACCESS_LOG_FIELDS", &al.Fields)
+	if s := os.Getenv("HWC_ACCESS_LOG_PERIOD"); s != "" {
+		al.Period = s
+	}
+	if err := envUint("HWC_ACCESS_LOG_TRUNCATE_SIZE", &al.TruncateSize); err != nil {
+		return err
+	}
+	if _, ok := os.LookupEnv("HWC_ACCESS_LOG_HEADERS"); ok {
+		var headers []string
+		envList("HWC_ACCESS_LOG_HEADERS", &headers)
+		al.CustomFields = nil
+		for _, header := range headers {
+			al.CustomFields = append(al.CustomFields, requestHeaderLogField(header))
+		}
+	}
+	return al.validate()
+}
+
+func (al *AccessLog) validate() error {
+	if al.Enabled() {
+		if err := oneOf("accessLog format", al.Format, "text", "json"); err != nil {
+			return err
+		}
+	}
+	for _, field := range al.Fields {
+		if err := oneOf("accessLog field", field, logExtFileFlags...); err != nil {
+			return err
+		}
+	}
+	if err := oneOf("accessLog period", al.Period, "Daily", "Hourly", "Weekly", "Monthly", "MaxSize"); err != nil {
+		return err
+	}
+	if al.TruncateSize < 1048576 {
+		return fmt.Errorf("Invalid accessLog truncateSize: %d (must be at least 1048576)", al.TruncateSize)
+	}
+	for _, field := range al.CustomFields {
+		if field.LogFieldName == "" || field.SourceName == "" {
+			return fmt.Errorf("Invalid accessLog custom field: %+v (logFieldName and sourceName are required)", field)
+		}
+		if err := oneOf("accessLog custom field sourceType", field.SourceType, "RequestHeader", "ResponseHeader", "ServerVariable"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SiteLogDirectory is where IIS writes the W3C log files for the site.
+func (c *HwcConfig) SiteLogDirectory() string {
+	return filepath.Join(c.LogDirectory, fmt.Sprintf("W3SVC%d", c.Port))
+}
@@ -0,0 +1,146 @@
+package hwcconfig_test
+
+import (
+	"encoding/xml"
+	"io/ioutil"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"code.cloudfoundry.org/hwc/hwcconfig"
+)
+
+type accessLogConfiguration struct {
+	SystemApplicationHost struct {
+		Sites struct {
+			SiteDefaults struct {
+				LogFile struct {
+					LogFormat       string `xml:"logFormat,attr"`
+					Directory       string `xml:"directory,attr"`
+					LogExtFileFlags string `xml:"logExtFileFlags,attr"`
+					Period          string `xml:"period,attr"`
+					TruncateSize    string `xml:"truncateSize,attr"`
+					CustomFields    []struct {
+						LogFieldName string `xml:"logFieldName,attr"`
+						SourceName   string `xml:"sourceName,attr"`
+						SourceType   string `xml:"sourceType,attr"`
+					} `xml:"customFields>add"`
+				} `xml:"logFile"`
+			} `xml:"siteDefaults"`
+		} `xml:"sites"`
+	} `xml:"system.applicationHost"`
+	SystemWebServer struct {
+		HTTPLogging struct {
+			DontLog string `xml:"dontLog,attr"`
+		} `xml:"httpLogging"`
+	} `xml:"system.webServer"`
+}
+
+var _ = Describe("AccessLog", func() {
+	app := newTestApp()
+
+	var renderAccessLog = func() (*hwcconfig.HwcConfig, accessLogConfiguration) {
+		err, hwcConfig := app.newConfig()
+		Expect(err).ToNot(HaveOccurred())
+
+		configFileContents, err := ioutil.ReadFile(hwcConfig.ApplicationHostConfigPath)
+		Expect(err).ToNot(HaveOccurred())
+
+		var config accessLogConfiguration
+		Expect(xml.Unmarshal(configFileContents, &config)).To(Succeed())
+		return hwcConfig, config
+	}
+
+	It("disables HTTP logging by default", func() {
+		hwcConfig, config := renderAccessLog()
+		Expect(hwcConfig.AccessLog.Enabled()).To(BeFalse())
+		Expect(config.SystemWebServer.HTTPLogging.DontLog).To(Equal("true"))
+
+		logFile := config.SystemApplicationHost.Sites.SiteDefaults.LogFile
+		Expect(logFile.LogFormat).To(Equal("W3C"))
+		Expect(logFile.Directory).To(Equal(hwcConfig.LogDirectory))
+		Expect(hwcConfig.SiteLogDirectory()).To(Equal(filepath.Join(app.workingDirectoryPath, "tmpPath", "LogFiles", "W3SVC8080")))
+	})
+
+	It("logs the default fields and correlation headers", func() {
+		_, config := renderAccessLog()
+
+		logFile := config.SystemApplicationHost.Sites.SiteDefaults.LogFile
+		Expect(logFile.LogExtFileFlags).To(Equal("Date, Time, ClientIP, UserName, ServerIP, Method, UriStem, UriQuery, HttpStatus, Win32Status, TimeTaken, ServerPort, UserAgent, Referer, HttpSubStatus"))
+		Expect(logFile.Period).To(Equal("Daily"))
+		Expect(logFile.TruncateSize).To(Equal("20971520"))
+
+		Expect(logFile.CustomFields).To(HaveLen(3))
+		Expect(logFile.CustomFields[1].LogFieldName).To(Equal("X-Vcap-Request-Id"))
+		Expect(logFile.CustomFields[1].SourceName).To(Equal("X-Vcap-Request-Id"))
+		Expect(logFile.CustomFields[1].SourceType).To(Equal("RequestHeader"))
+		Expect(logFile.CustomFields[2].LogFieldName).To(Equal("X-B3-TraceId"))
+	})
+
+	Context("when HWC_ACCESS_LOG is set", func() {
+		BeforeEach(func() {
+			app.env["HWC_ACCESS_LOG"] = "json"
+		})
+
+		It("enables HTTP logging", func() {
+			hwcConfig, config := renderAccessLog()
+			Expect(hwcConfig.AccessLog.Format).To(Equal("json"))
+			Expect(config.SystemWebServer.HTTPLogging.DontLog).To(Equal("false"))
+		})
+	})
+
+	Context("when the fields and rollover are set", func() {
+		BeforeEach(func() {
+			app.env["HWC_ACCESS_LOG_FIELDS"] = "Date,Time,Method,UriStem,HttpStatus,BytesSent"
+			app.env["HWC_ACCESS_LOG_PERIOD"] = "MaxSize"
+			app.env["HWC_ACCESS_LOG_TRUNCATE_SIZE"] = "1048576"
+			app.env["HWC_ACCESS_LOG_HEADERS"] = "X-Request-Start"
+		})
+
+		It("overrides the defaults", func() {
+			_, config := renderAccessLog()
+
+			logFile := config.SystemApplicationHost.Sites.SiteDefaults.LogFile
+			Expect(logFile.LogExtFileFlags).To(Equal("Date, Time, Method, UriStem, HttpStatus, BytesSent"))
+			Expect(logFile.Period).To(Equal("MaxSize"))
+			Expect(logFile.TruncateSize).To(Equal("1048576"))
+			Expect(logFile.CustomFields).To(HaveLen(1))
+			Expect(logFile.CustomFields[0].LogFieldName).To(Equal("X-Request-Start"))
+		})
+	})
+
+	Context("when a log field is unknown", func() {
+		BeforeEach(func() {
+			app.env["HWC_ACCESS_LOG_FIELDS"] = "Date,Bogus"
+		})
+
+		It("fails", func() {
+			err, _ := app.newConfig()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(HavePrefix("Invalid accessLog field: Bogus"))
+		})
+	})
+
+	Context("when the truncate size is below 1 MB", func() {
+		BeforeEach(func() {
+			app.env["HWC_ACCESS_LOG_TRUNCATE_SIZE"] = "1024"
+		})
+
+		It("fails", func() {
+			err, _ := app.newConfig()
+			Expect(err).To(MatchError("Invalid accessLog truncateSize: 1024 (must be at least 1048576)"))
+		})
+	})
+
+	Context("when the format is unknown", func() {
+		BeforeEach(func() {
+			app.env["HWC_ACCESS_LOG"] = "xml"
+		})
+
+		It("fails", func() {
+			err, _ := app.newConfig()
+			Expect(err).To(MatchError("Invalid accessLog format: xml (expected one of text, json)"))
+		})
+	})
+})
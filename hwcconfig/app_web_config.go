@@ -0,0 +1,55 @@
+package hwcconfig
+
+import (
+	"encoding/xml"
+	"os"
+	"path/filepath"
+)
+
+// appConfigures reports whether the Web.config of the app in root has the
+// system.webServer section name, at the top level or in a <location>.
+func appConfigures(root, name string) (bool, error) {
+	found := false
+	err := decodeAppSections(root, name, func(d *xml.Decoder, start xml.StartElement) error {
+		found = true
+		return d.Skip()
+	})
+	return found, err
+}
+
+// decodeAppSections calls decode with each system.webServer section name in
+// the Web.config of the app in root, at the top level or in a <location>.
+// decode must consume the section. A Web.config that isn't valid XML is left
+// for the validator to report.
+func decodeAppSections(root, name string, decode func(*xml.Decoder, xml.StartElement) error) error {
+	file, err := os.Open(filepath.Join(root, "Web.config"))
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	decoder := xml.NewDecoder(file)
+	var stack []string
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			return nil
+		}
+		switch t := token.(type) {
+		case xml.StartElement:
+			if t.Name.Local == name && len(stack) > 0 && stack[len(stack)-1] == "system.webServer" {
+				if err := decode(decoder, t); err != nil {
+					return nil
+				}
+				continue
+			}
+			stack = append(stack, t.Name.Local)
+		case xml.EndElement:
+			if len(stack) > 0 {
+				stack = stack[:len(stack)-1]
+			}
+		}
+	}
+}
@@ -9,6 +9,7 @@ import (
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 
+	"code.cloudfoundry.org/hwc/apphost"
 	"code.cloudfoundry.org/hwc/hwcconfig"
 )
 
@@ -73,10 +74,35 @@ var _ bool = Describe("ApplicationHostConfig", func() {
 
 			listenPort, rootPath, tmpPath, contextPath, uuid := basicDeps(workingDirectoryPath)
 
-			err, hwcConfig := hwcconfig.New(listenPort, rootPath, tmpPath, contextPath, uuid)
+			err, hwcConfig := hwcconfig.New(listenPort, rootPath, tmpPath, contextPath, uuid, nil, "")
 			_, err = os.Stat(hwcConfig.ApplicationHostConfigPath)
 			Expect(err).ToNot(HaveOccurred())
 		})
+
+		It("writes the model it exposes", func() {
+			listenPort, rootPath, tmpPath, contextPath, uuid := basicDeps(workingDirectoryPath)
+
+			err, hwcConfig := hwcconfig.New(listenPort, rootPath, tmpPath, contextPath, uuid, nil, "")
+			Expect(err).ToNot(HaveOccurred())
+
+			configFileContents, err := ioutil.ReadFile(hwcConfig.ApplicationHostConfigPath)
+			Expect(err).ToNot(HaveOccurred())
+			model, err := hwcConfig.ApplicationHost.Marshal()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(string(configFileContents)).To(Equal(string(model)))
+
+			sites := hwcConfig.ApplicationHost.SystemApplicationHost.Sites.Sites
+			Expect(sites).To(HaveLen(1))
+			Expect(sites[0].Bindings).To(Equal([]apphost.Binding{{Protocol: "http", BindingInformation: "*:8080:"}}))
+			Expect(sites[0].Applications[0].ApplicationPool).To(Equal("AppPool8080"))
+
+			server := hwcConfig.ApplicationHost.SystemWebServer
+			Expect(server.GlobalModules).To(ContainElement(apphost.GlobalModule{Name: "StaticFileModule", Image: `%windir%\System32\inetsrv\static.dll`}))
+			Expect(server.Modules).To(ContainElement(apphost.Module{Name: "StaticFileModule", LockItem: true}))
+			Expect(server.Modules).ToNot(ContainElement(apphost.Module{Name: "RewriteModule"}))
+			Expect(server.Rewrite).To(BeNil())
+			Expect(hwcConfig.ApplicationHost.ConfigSections.Group("system.webServer", "rewrite")).To(BeNil())
+		})
 	})
 
 	Context("When custom modules are specified", func() {
@@ -113,7 +139,7 @@ var _ bool = Describe("ApplicationHostConfig", func() {
 
 			listenPort, rootPath, tmpPath, contextPath, uuid := basicDeps(workingDirectoryPath)
 
-			err, hwcConfig := hwcconfig.New(listenPort, rootPath, tmpPath, contextPath, uuid)
+			err, hwcConfig := hwcconfig.New(listenPort, rootPath, tmpPath, contextPath, uuid, nil, "")
 			Expect(err).ToNot(HaveOccurred())
 			configFileContents, err := ioutil.ReadFile(hwcConfig.ApplicationHostConfigPath)
 			Expect(err).ToNot(HaveOccurred())
@@ -123,6 +149,7 @@ var _ bool = Describe("ApplicationHostConfig", func() {
 
 			Expect(string(configFileContents)).To(ContainSubstring("<add name=\"myLinkedModule\" image=\"" + linkFilePath + "\""))
 			Expect(string(configFileContents)).To(ContainSubstring("<add name=\"myLinkedModule\" lockItem=\"true\" />"))
+			Expect(hwcConfig.NativeModules).To(ConsistOf("exampleModule", "myLinkedModule"))
 		})
 
 		It("returns error when user provided directory is empty", func() {
@@ -136,7 +163,7 @@ var _ bool = Describe("ApplicationHostConfig", func() {
 			Expect(err).ToNot(HaveOccurred())
 
 			listenPort, rootPath, tmpPath, contextPath, uuid := basicDeps(workingDirectoryPath)
-			err, _ = hwcconfig.New(listenPort, rootPath, tmpPath, contextPath, uuid)
+			err, _ = hwcconfig.New(listenPort, rootPath, tmpPath, contextPath, uuid, nil, "")
 			Expect(err).To(HaveOccurred())
 			Expect(err).To(MatchError("HWC_NATIVE_MODULES does not match required directory structure. See hwc README for detailed instructions."))
 		})
@@ -148,7 +175,7 @@ var _ bool = Describe("ApplicationHostConfig", func() {
 
 			listenPort, rootPath, tmpPath, contextPath, uuid := basicDeps(workingDirectoryPath)
 
-			err, hwcConfig := hwcconfig.New(listenPort, rootPath, tmpPath, contextPath, uuid)
+			err, hwcConfig := hwcconfig.New(listenPort, rootPath, tmpPath, contextPath, uuid, nil, "")
 			Expect(err).ToNot(HaveOccurred())
 			configFileContents, err := ioutil.ReadFile(hwcConfig.ApplicationHostConfigPath)
 			Expect(err).ToNot(HaveOccurred())
@@ -165,4 +192,46 @@ var _ bool = Describe("ApplicationHostConfig", func() {
 			Expect(config.SystemWebServer.Security.Authentication.WindowsAuthentication.Providers.Add[0].Value).To(Equal("Negotiate"), "Not Negotiate")
 		})
 	})
+
+	Context("When paths contain XML special characters", func() {
+		var hostilePath string
+
+		BeforeEach(func() {
+			// < > and " can't appear in Windows file names, so they only go in
+			// the context path.
+			hostilePath = filepath.Join(workingDirectoryPath, "R&D's files")
+		})
+
+		AfterEach(func() {
+			Expect(os.Unsetenv("HWC_NATIVE_MODULES")).To(Succeed())
+		})
+
+		It("escapes them so the config parses back to the same values", func() {
+			modulesDirectoryPath := filepath.Join(hostilePath, "native-modules")
+			dllFilePath := filepath.Join(modulesDirectoryPath, "R&DModule", "module.dll")
+			createAllFiles(dllFilePath)
+			Expect(os.Setenv("HWC_NATIVE_MODULES", modulesDirectoryPath)).To(Succeed())
+
+			rootPath := filepath.Join(hostilePath, "rootPath")
+			tmpPath := filepath.Join(hostilePath, "tmpPath")
+			contextPath := `/R&D/<it's "x">`
+
+			err, hwcConfig := hwcconfig.New(8080, rootPath, tmpPath, contextPath, "someuid12345", nil, "")
+			Expect(err).ToNot(HaveOccurred())
+			configFileContents, err := ioutil.ReadFile(hwcConfig.ApplicationHostConfigPath)
+			Expect(err).ToNot(HaveOccurred())
+
+			config, err := apphost.Unmarshal(configFileContents)
+			Expect(err).ToNot(HaveOccurred())
+			remarshalled, err := config.Marshal()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(string(remarshalled)).To(Equal(string(configFileContents)))
+
+			site := config.SystemApplicationHost.Sites.Sites[0]
+			Expect(site.Applications[0].Path).To(Equal(contextPath))
+			Expect(site.Applications[0].VirtualDirectories[0].PhysicalPath).To(Equal(rootPath))
+			Expect(config.SystemApplicationHost.Sites.SiteDefaults.LogFile.Directory).To(Equal(filepath.Join(tmpPath, "LogFiles")))
+			Expect(config.SystemWebServer.GlobalModules).To(ContainElement(apphost.GlobalModule{Name: "R&DModule", Image: dllFilePath}))
+		})
+	})
 })
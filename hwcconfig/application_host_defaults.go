@@ -0,0 +1,683 @@
+package hwcconfig
+
+import "code.cloudfoundry.org/hwc/apphost"
+
+// The IIS defaults hwc doesn't tune. Each call returns a new value, so the
+// model built from them can be changed freely.
+
+func defaultConfigSections() apphost.ConfigSections {
+	return apphost.ConfigSections{Entries: []apphost.ConfigSection{
+		apphost.SectionGroup("system.applicationHost",
+			apphost.Section("applicationPools", "Deny", "AppHostOnly"),
+			apphost.Section("configHistory", "Deny", "AppHostOnly"),
+			apphost.Section("customMetadata", "Deny", "AppHostOnly"),
+			apphost.Section("listenerAdapters", "Deny", "AppHostOnly"),
+			apphost.Section("log", "Deny", "AppHostOnly"),
+			apphost.Section("serviceAutoStartProviders", "Deny", "AppHostOnly"),
+			apphost.Section("sites", "Deny", "AppHostOnly"),
+			apphost.Section("webLimits", "Deny", "AppHostOnly"),
+		),
+		apphost.SectionGroup("system.webServer",
+			apphost.Section("asp", "Allow", ""),
+			apphost.Section("caching", "Allow", ""),
+			apphost.Section("cgi", "Deny", ""),
+			apphost.Section("defaultDocument", "Allow", ""),
+			apphost.Section("directoryBrowse", "Allow", ""),
+			apphost.Section("fastCgi", "Deny", "AppHostOnly"),
+			apphost.Section("globalModules", "Deny", "AppHostOnly"),
+			apphost.Section("handlers", "Allow", ""),
+			apphost.Section("httpCompression", "Allow", ""),
+			apphost.Section("httpErrors", "Allow", ""),
+			apphost.Section("httpLogging", "Deny", ""),
+			apphost.Section("httpProtocol", "Allow", ""),
+			apphost.Section("httpRedirect", "Allow", ""),
+			apphost.Section("httpTracing", "Allow", ""),
+			apphost.Section("isapiFilters", "Deny", "MachineToApplication"),
+			apphost.Section("modules", "Allow", "MachineToApplication"),
+			apphost.Section("odbcLogging", "Deny", ""),
+			apphost.SectionGroup("security",
+				apphost.Section("access", "Deny", ""),
+				apphost.Section("applicationDependencies", "Deny", ""),
+				apphost.SectionGroup("authentication",
+					apphost.Section("anonymousAuthentication", "Deny", ""),
+					apphost.Section("basicAuthentication", "Deny", ""),
+					apphost.Section("clientCertificateMappingAuthentication", "Deny", ""),
+					apphost.Section("digestAuthentication", "Deny", ""),
+					apphost.Section("iisClientCertificateMappingAuthentication", "Deny", ""),
+					apphost.Section("windowsAuthentication", "Allow", ""),
+				),
+				apphost.Section("authorization", "Allow", ""),
+				apphost.Section("ipSecurity", "Deny", ""),
+				apphost.Section("isapiCgiRestriction", "Deny", "AppHostOnly"),
+				apphost.Section("requestFiltering", "Allow", ""),
+			),
+			apphost.Section("serverRuntime", "Deny", ""),
+			apphost.Section("serverSideInclude", "Deny", ""),
+			apphost.Section("staticContent", "Allow", ""),
+			apphost.SectionGroup("tracing",
+				apphost.Section("traceFailedRequests", "Allow", ""),
+				apphost.Section("traceProviderDefinitions", "Allow", ""),
+			),
+			apphost.Section("urlCompression", "Allow", ""),
+			apphost.Section("validation", "Allow", ""),
+			apphost.SectionGroup("webdav",
+				apphost.Section("globalSettings", "Deny", ""),
+				apphost.Section("authoring", "Deny", ""),
+				apphost.Section("authoringRules", "Deny", ""),
+			),
+			apphost.SectionGroup("wdeploy",
+				apphost.Section("backup", "Deny", "MachineToApplication"),
+			),
+			apphost.Section("webSocket", "Deny", ""),
+		),
+	}}
+}
+
+// rewriteSections declares the URL Rewrite sections, which only exist when the
+// module is installed.
+func rewriteSections() apphost.ConfigSection {
+	return apphost.SectionGroup("rewrite",
+		apphost.Section("rules", "Allow", ""),
+		apphost.Section("globalRules", "Deny", "AppHostOnly"),
+		apphost.Section("outboundRules", "Allow", ""),
+		apphost.Section("providers", "Allow", ""),
+		apphost.Section("rewriteMaps", "Allow", ""),
+		apphost.Section("allowedServerVariables", "Allow", ""),
+	)
+}
+
+func defaultDocuments() []apphost.DefaultDocumentFile {
+	return []apphost.DefaultDocumentFile{
+		{Value: "Default.htm"},
+		{Value: "Default.asp"},
+		{Value: "index.htm"},
+		{Value: "index.html"},
+		{Value: "iisstart.htm"},
+		{Value: "default.aspx"},
+	}
+}
+
+// defaultSecurity is the <security> section without <requestFiltering>, which
+// comes from RequestFiltering.
+func defaultSecurity() apphost.Security {
+	return apphost.Security{
+		Access: apphost.Access{SSLFlags: "None"},
+		Authentication: apphost.Authentication{
+			Anonymous: apphost.AnonymousAuthentication{Enabled: true, UserName: "IUSR"},
+			Windows: apphost.WindowsAuthentication{
+				AuthPersistNonNTLM:       true,
+				AuthPersistSingleRequest: true,
+				Enabled:                  true,
+				Providers:                []apphost.AuthenticationProvider{{Value: "Negotiate"}},
+			},
+		},
+		Authorization:       []apphost.AuthorizationRule{{AccessType: "Allow", Users: "*"}},
+		ISAPICgiRestriction: defaultISAPICgiRestrictions(),
+	}
+}
+
+func defaultTraceProviders() []apphost.TraceProvider {
+	return []apphost.TraceProvider{
+		{Name: "ASPNET", GUID: "{AFF081FE-0247-4275-9C4E-021F3DC1DA35}", Areas: apphost.TraceAreas{
+			Areas: []apphost.TraceArea{
+				{Name: "Infrastructure", Value: 1},
+				{Name: "Module", Value: 2},
+				{Name: "Page", Value: 4},
+				{Name: "AppServices", Value: 8},
+			},
+		}},
+		{Name: "WWW Server", GUID: "{3a2a4e84-4c21-4981-ae10-3fda0d9b0f83}", Areas: apphost.TraceAreas{
+			Clear: &apphost.Clear{},
+			Areas: []apphost.TraceArea{
+				{Name: "Authentication", Value: 2},
+				{Name: "Security", Value: 4},
+				{Name: "Filter", Value: 8},
+				{Name: "StaticFile", Value: 16},
+				{Name: "CGI", Value: 32},
+				{Name: "Compression", Value: 64},
+				{Name: "Cache", Value: 128},
+				{Name: "RequestNotifications", Value: 256},
+				{Name: "Module", Value: 512},
+				{Name: "FastCGI", Value: 4096},
+			},
+		}},
+		{Name: "ASP", GUID: "{06b94d9a-b15e-456e-a4ef-37c984a2cb4b}", Areas: apphost.TraceAreas{Clear: &apphost.Clear{}}},
+		{Name: "ISAPI Extension", GUID: "{a1c2040e-8840-4c31-ba11-9871031a19ea}", Areas: apphost.TraceAreas{Clear: &apphost.Clear{}}},
+	}
+}
+
+// defaultTraceAreas are the providers a failed request trace records.
+func defaultTraceAreas() []apphost.TraceRuleArea {
+	return []apphost.TraceRuleArea{
+		{Provider: "ASP", Verbosity: "Verbose"},
+		{Provider: "ASPNET", Areas: "Infrastructure,Module,Page,AppServices", Verbosity: "Verbose"},
+		{Provider: "ISAPI Extension", Verbosity: "Verbose"},
+		{Provider: "WWW Server", Areas: "Authentication,Security,Filter,StaticFile,CGI,Compression,Cache,RequestNotifications,Module", Verbosity: "Verbose"},
+	}
+}
+
+func defaultMimeMaps() []apphost.MimeMap {
+	return []apphost.MimeMap{
+		{FileExtension: ".323", MimeType: "text/h323"},
+		{FileExtension: ".3g2", MimeType: "video/3gpp2"},
+		{FileExtension: ".3gp2", MimeType: "video/3gpp2"},
+		{FileExtension: ".3gp", MimeType: "video/3gpp"},
+		{FileExtension: ".3gpp", MimeType: "video/3gpp"},
+		{FileExtension: ".aac", MimeType: "audio/aac"},
+		{FileExtension: ".aaf", MimeType: "application/octet-stream"},
+		{FileExtension: ".aca", MimeType: "application/octet-stream"},
+		{FileExtension: ".accdb", MimeType: "application/msaccess"},
+		{FileExtension: ".accde", MimeType: "application/msaccess"},
+		{FileExtension: ".accdt", MimeType: "application/msaccess"},
+		{FileExtension: ".acx", MimeType: "application/internet-property-stream"},
+		{FileExtension: ".adt", MimeType: "audio/vnd.dlna.adts"},
+		{FileExtension: ".adts", MimeType: "audio/vnd.dlna.adts"},
+		{FileExtension: ".afm", MimeType: "application/octet-stream"},
+		{FileExtension: ".ai", MimeType: "application/postscript"},
+		{FileExtension: ".aif", MimeType: "audio/x-aiff"},
+		{FileExtension: ".aifc", MimeType: "audio/aiff"},
+		{FileExtension: ".aiff", MimeType: "audio/aiff"},
+		{FileExtension: ".application", MimeType: "application/x-ms-application"},
+		{FileExtension: ".art", MimeType: "image/x-jg"},
+		{FileExtension: ".asd", MimeType: "application/octet-stream"},
+		{FileExtension: ".asf", MimeType: "video/x-ms-asf"},
+		{FileExtension: ".asi", MimeType: "application/octet-stream"},
+		{FileExtension: ".asm", MimeType: "text/plain"},
+		{FileExtension: ".asr", MimeType: "video/x-ms-asf"},
+		{FileExtension: ".asx", MimeType: "video/x-ms-asf"},
+		{FileExtension: ".atom", MimeType: "application/atom+xml"},
+		{FileExtension: ".au", MimeType: "audio/basic"},
+		{FileExtension: ".avi", MimeType: "video/x-msvideo"},
+		{FileExtension: ".axs", MimeType: "application/olescript"},
+		{FileExtension: ".bas", MimeType: "text/plain"},
+		{FileExtension: ".bcpio", MimeType: "application/x-bcpio"},
+		{FileExtension: ".bin", MimeType: "application/octet-stream"},
+		{FileExtension: ".bmp", MimeType: "image/bmp"},
+		{FileExtension: ".c", MimeType: "text/plain"},
+		{FileExtension: ".cab", MimeType: "application/vnd.ms-cab-compressed"},
+		{FileExtension: ".calx", MimeType: "application/vnd.ms-office.calx"},
+		{FileExtension: ".cat", MimeType: "application/vnd.ms-pki.seccat"},
+		{FileExtension: ".cdf", MimeType: "application/x-cdf"},
+		{FileExtension: ".chm", MimeType: "application/octet-stream"},
+		{FileExtension: ".class", MimeType: "application/x-java-applet"},
+		{FileExtension: ".clp", MimeType: "application/x-msclip"},
+		{FileExtension: ".cmx", MimeType: "image/x-cmx"},
+		{FileExtension: ".cnf", MimeType: "text/plain"},
+		{FileExtension: ".cod", MimeType: "image/cis-cod"},
+		{FileExtension: ".cpio", MimeType: "application/x-cpio"},
+		{FileExtension: ".cpp", MimeType: "text/plain"},
+		{FileExtension: ".crd", MimeType: "application/x-mscardfile"},
+		{FileExtension: ".crl", MimeType: "application/pkix-crl"},
+		{FileExtension: ".crt", MimeType: "application/x-x509-ca-cert"},
+		{FileExtension: ".csh", MimeType: "application/x-csh"},
+		{FileExtension: ".css", MimeType: "text/css"},
+		{FileExtension: ".csv", MimeType: "application/octet-stream"},
+		{FileExtension: ".cur", MimeType: "application/octet-stream"},
+		{FileExtension: ".dcr", MimeType: "application/x-director"},
+		{FileExtension: ".deploy", MimeType: "application/octet-stream"},
+		{FileExtension: ".der", MimeType: "application/x-x509-ca-cert"},
+		{FileExtension: ".dib", MimeType: "image/bmp"},
+		{FileExtension: ".dir", MimeType: "application/x-director"},
+		{FileExtension: ".disco", MimeType: "text/xml"},
+		{FileExtension: ".dll", MimeType: "application/x-msdownload"},
+		{FileExtension: ".dll.config", MimeType: "text/xml"},
+		{FileExtension: ".dlm", MimeType: "text/dlm"},
+		{FileExtension: ".doc", MimeType: "application/msword"},
+		{FileExtension: ".docm", MimeType: "application/vnd.ms-word.document.macroEnabled.12"},
+		{FileExtension: ".docx", MimeType: "application/vnd.openxmlformats-officedocument.wordprocessingml.document"},
+		{FileExtension: ".dot", MimeType: "application/msword"},
+		{FileExtension: ".dotm", MimeType: "application/vnd.ms-word.template.macroEnabled.12"},
+		{FileExtension: ".dotx", MimeType: "application/vnd.openxmlformats-officedocument.wordprocessingml.template"},
+		{FileExtension: ".dsp", MimeType: "application/octet-stream"},
+		{FileExtension: ".dtd", MimeType: "text/xml"},
+		{FileExtension: ".dvi", MimeType: "application/x-dvi"},
+		{FileExtension: ".dvr-ms", MimeType: "video/x-ms-dvr"},
+		{FileExtension: ".dwf", MimeType: "drawing/x-dwf"},
+		{FileExtension: ".dwp", MimeType: "application/octet-stream"},
+		{FileExtension: ".dxr", MimeType: "application/x-director"},
+		{FileExtension: ".eml", MimeType: "message/rfc822"},
+		{FileExtension: ".emz", MimeType: "application/octet-stream"},
+		{FileExtension: ".eot", MimeType: "application/vnd.ms-fontobject"},
+		{FileExtension: ".eps", MimeType: "application/postscript"},
+		{FileExtension: ".etx", MimeType: "text/x-setext"},
+		{FileExtension: ".evy", MimeType: "application/envoy"},
+		{FileExtension: ".exe", MimeType: "application/octet-stream"},
+		{FileExtension: ".exe.config", MimeType: "text/xml"},
+		{FileExtension: ".fdf", MimeType: "application/vnd.fdf"},
+		{FileExtension: ".fif", MimeType: "application/fractals"},
+		{FileExtension: ".fla", MimeType: "application/octet-stream"},
+		{FileExtension: ".flr", MimeType: "x-world/x-vrml"},
+		{FileExtension: ".flv", MimeType: "video/x-flv"},
+		{FileExtension: ".gif", MimeType: "image/gif"},
+		{FileExtension: ".gtar", MimeType: "application/x-gtar"},
+		{FileExtension: ".gz", MimeType: "application/x-gzip"},
+		{FileExtension: ".h", MimeType: "text/plain"},
+		{FileExtension: ".hdf", MimeType: "application/x-hdf"},
+		{FileExtension: ".hdml", MimeType: "text/x-hdml"},
+		{FileExtension: ".hhc", MimeType: "application/x-oleobject"},
+		{FileExtension: ".hhk", MimeType: "application/octet-stream"},
+		{FileExtension: ".hhp", MimeType: "application/octet-stream"},
+		{FileExtension: ".hlp", MimeType: "application/winhlp"},
+		{FileExtension: ".hqx", MimeType: "application/mac-binhex40"},
+		{FileExtension: ".hta", MimeType: "application/hta"},
+		{FileExtension: ".htc", MimeType: "text/x-component"},
+		{FileExtension: ".htm", MimeType: "text/html"},
+		{FileExtension: ".html", MimeType: "text/html"},
+		{FileExtension: ".htt", MimeType: "text/webviewhtml"},
+		{FileExtension: ".hxt", MimeType: "text/html"},
+		{FileExtension: ".ical", MimeType: "text/calendar"},
+		{FileExtension: ".icalendar", MimeType: "text/calendar"},
+		{FileExtension: ".ico", MimeType: "image/x-icon"},
+		{FileExtension: ".ics", MimeType: "text/calendar"},
+		{FileExtension: ".ief", MimeType: "image/ief"},
+		{FileExtension: ".ifb", MimeType: "text/calendar"},
+		{FileExtension: ".iii", MimeType: "application/x-iphone"},
+		{FileExtension: ".inf", MimeType: "application/octet-stream"},
+		{FileExtension: ".ins", MimeType: "application/x-internet-signup"},
+		{FileExtension: ".isp", MimeType: "application/x-internet-signup"},
+		{FileExtension: ".IVF", MimeType: "video/x-ivf"},
+		{FileExtension: ".jar", MimeType: "application/java-archive"},
+		{FileExtension: ".java", MimeType: "application/octet-stream"},
+		{FileExtension: ".jck", MimeType: "application/liquidmotion"},
+		{FileExtension: ".jcz", MimeType: "application/liquidmotion"},
+		{FileExtension: ".jfif", MimeType: "image/pjpeg"},
+		{FileExtension: ".jpb", MimeType: "application/octet-stream"},
+		{FileExtension: ".jpe", MimeType: "image/jpeg"},
+		{FileExtension: ".jpeg", MimeType: "image/jpeg"},
+		{FileExtension: ".jpg", MimeType: "image/jpeg"},
+		{FileExtension: ".js", MimeType: "application/javascript"},
+		{FileExtension: ".json", MimeType: "application/json"},
+		{FileExtension: ".jsx", MimeType: "text/jscript"},
+		{FileExtension: ".latex", MimeType: "application/x-latex"},
+		{FileExtension: ".lit", MimeType: "application/x-ms-reader"},
+		{FileExtension: ".lpk", MimeType: "application/octet-stream"},
+		{FileExtension: ".lsf", MimeType: "video/x-la-asf"},
+		{FileExtension: ".lsx", MimeType: "video/x-la-asf"},
+		{FileExtension: ".lzh", MimeType: "application/octet-stream"},
+		{FileExtension: ".m13", MimeType: "application/x-msmediaview"},
+		{FileExtension: ".m14", MimeType: "application/x-msmediaview"},
+		{FileExtension: ".m1v", MimeType: "video/mpeg"},
+		{FileExtension: ".m2ts", MimeType: "video/vnd.dlna.mpeg-tts"},
+		{FileExtension: ".m3u", MimeType: "audio/x-mpegurl"},
+		{FileExtension: ".m4a", MimeType: "audio/mp4"},
+		{FileExtension: ".m4v", MimeType: "video/mp4"},
+		{FileExtension: ".man", MimeType: "application/x-troff-man"},
+		{FileExtension: ".manifest", MimeType: "application/x-ms-manifest"},
+		{FileExtension: ".map", MimeType: "text/plain"},
+		{FileExtension: ".mdb", MimeType: "application/x-msaccess"},
+		{FileExtension: ".mdp", MimeType: "application/octet-stream"},
+		{FileExtension: ".me", MimeType: "application/x-troff-me"},
+		{FileExtension: ".mht", MimeType: "message/rfc822"},
+		{FileExtension: ".mhtml", MimeType: "message/rfc822"},
+		{FileExtension: ".mid", MimeType: "audio/mid"},
+		{FileExtension: ".midi", MimeType: "audio/mid"},
+		{FileExtension: ".mix", MimeType: "application/octet-stream"},
+		{FileExtension: ".mmf", MimeType: "application/x-smaf"},
+		{FileExtension: ".mno", MimeType: "text/xml"},
+		{FileExtension: ".mny", MimeType: "application/x-msmoney"},
+		{FileExtension: ".mov", MimeType: "video/quicktime"},
+		{FileExtension: ".movie", MimeType: "video/x-sgi-movie"},
+		{FileExtension: ".mp2", MimeType: "video/mpeg"},
+		{FileExtension: ".mp3", MimeType: "audio/mpeg"},
+		{FileExtension: ".mp4", MimeType: "video/mp4"},
+		{FileExtension: ".mp4v", MimeType: "video/mp4"},
+		{FileExtension: ".mpa", MimeType: "video/mpeg"},
+		{FileExtension: ".mpe", MimeType: "video/mpeg"},
+		{FileExtension: ".mpeg", MimeType: "video/mpeg"},
+		{FileExtension: ".mpg", MimeType: "video/mpeg"},
+		{FileExtension: ".mpp", MimeType: "application/vnd.ms-project"},
+		{FileExtension: ".mpv2", MimeType: "video/mpeg"},
+		{FileExtension: ".ms", MimeType: "application/x-troff-ms"},
+		{FileExtension: ".msi", MimeType: "application/octet-stream"},
+		{FileExtension: ".mso", MimeType: "application/octet-stream"},
+		{FileExtension: ".mvb", MimeType: "application/x-msmediaview"},
+		{FileExtension: ".mvc", MimeType: "application/x-miva-compiled"},
+		{FileExtension: ".nc", MimeType: "application/x-netcdf"},
+		{FileExtension: ".nsc", MimeType: "video/x-ms-asf"},
+		{FileExtension: ".nws", MimeType: "message/rfc822"},
+		{FileExtension: ".ocx", MimeType: "application/octet-stream"},
+		{FileExtension: ".oda", MimeType: "application/oda"},
+		{FileExtension: ".odc", MimeType: "text/x-ms-odc"},
+		{FileExtension: ".ods", MimeType: "application/oleobject"},
+		{FileExtension: ".oga", MimeType: "audio/ogg"},
+		{FileExtension: ".ogg", MimeType: "video/ogg"},
+		{FileExtension: ".ogv", MimeType: "video/ogg"},
+		{FileExtension: ".ogx", MimeType: "application/ogg"},
+		{FileExtension: ".one", MimeType: "application/onenote"},
+		{FileExtension: ".onea", MimeType: "application/onenote"},
+		{FileExtension: ".onetoc", MimeType: "application/onenote"},
+		{FileExtension: ".onetoc2", MimeType: "application/onenote"},
+		{FileExtension: ".onetmp", MimeType: "application/onenote"},
+		{FileExtension: ".onepkg", MimeType: "application/onenote"},
+		{FileExtension: ".osdx", MimeType: "application/opensearchdescription+xml"},
+		{FileExtension: ".otf", MimeType: "font/otf"},
+		{FileExtension: ".p10", MimeType: "application/pkcs10"},
+		{FileExtension: ".p12", MimeType: "application/x-pkcs12"},
+		{FileExtension: ".p7b", MimeType: "application/x-pkcs7-certificates"},
+		{FileExtension: ".p7c", MimeType: "application/pkcs7-mime"},
+		{FileExtension: ".p7m", MimeType: "application/pkcs7-mime"},
+		{FileExtension: ".p7r", MimeType: "application/x-pkcs7-certreqresp"},
+		{FileExtension: ".p7s", MimeType: "application/pkcs7-signature"},
+		{FileExtension: ".pbm", MimeType: "image/x-portable-bitmap"},
+		{FileExtension: ".pcx", MimeType: "application/octet-stream"},
+		{FileExtension: ".pcz", MimeType: "application/octet-stream"},
+		{FileExtension: ".pdf", MimeType: "application/pdf"},
+		{FileExtension: ".pfb", MimeType: "application/octet-stream"},
+		{FileExtension: ".pfm", MimeType: "application/octet-stream"},
+		{FileExtension: ".pfx", MimeType: "application/x-pkcs12"},
+		{FileExtension: ".pgm", MimeType: "image/x-portable-graymap"},
+		{FileExtension: ".pko", MimeType: "application/vnd.ms-pki.pko"},
+		{FileExtension: ".pma", MimeType: "application/x-perfmon"},
+		{FileExtension: ".pmc", MimeType: "application/x-perfmon"},
+		{FileExtension: ".pml", MimeType: "application/x-perfmon"},
+		{FileExtension: ".pmr", MimeType: "application/x-perfmon"},
+		{FileExtension: ".pmw", MimeType: "application/x-perfmon"},
+		{FileExtension: ".png", MimeType: "image/png"},
+		{FileExtension: ".pnm", MimeType: "image/x-portable-anymap"},
+		{FileExtension: ".pnz", MimeType: "image/png"},
+		{FileExtension: ".pot", MimeType: "application/vnd.ms-powerpoint"},
+		{FileExtension: ".potm", MimeType: "application/vnd.ms-powerpoint.template.macroEnabled.12"},
+		{FileExtension: ".potx", MimeType: "application/vnd.openxmlformats-officedocument.presentationml.template"},
+		{FileExtension: ".ppam", MimeType: "application/vnd.ms-powerpoint.addin.macroEnabled.12"},
+		{FileExtension: ".ppm", MimeType: "image/x-portable-pixmap"},
+		{FileExtension: ".pps", MimeType: "application/vnd.ms-powerpoint"},
+		{FileExtension: ".ppsm", MimeType: "application/vnd.ms-powerpoint.slideshow.macroEnabled.12"},
+		{FileExtension: ".ppsx", MimeType: "application/vnd.openxmlformats-officedocument.presentationml.slideshow"},
+		{FileExtension: ".ppt", MimeType: "application/vnd.ms-powerpoint"},
+		{FileExtension: ".pptm", MimeType: "application/vnd.ms-powerpoint.presentation.macroEnabled.12"},
+		{FileExtension: ".pptx", MimeType: "application/vnd.openxmlformats-officedocument.presentationml.presentation"},
+		{FileExtension: ".prf", MimeType: "application/pics-rules"},
+		{FileExtension: ".prm", MimeType: "application/octet-stream"},
+		{FileExtension: ".prx", MimeType: "application/octet-stream"},
+		{FileExtension: ".ps", MimeType: "application/postscript"},
+		{FileExtension: ".psd", MimeType: "application/octet-stream"},
+		{FileExtension: ".psm", MimeType: "application/octet-stream"},
+		{FileExtension: ".psp", MimeType: "application/octet-stream"},
+		{FileExtension: ".pub", MimeType: "application/x-mspublisher"},
+		{FileExtension: ".qt", MimeType: "video/quicktime"},
+		{FileExtension: ".qtl", MimeType: "application/x-quicktimeplayer"},
+		{FileExtension: ".qxd", MimeType: "application/octet-stream"},
+		{FileExtension: ".ra", MimeType: "audio/x-pn-realaudio"},
+		{FileExtension: ".ram", MimeType: "audio/x-pn-realaudio"},
+		{FileExtension: ".rar", MimeType: "application/octet-stream"},
+		{FileExtension: ".ras", MimeType: "image/x-cmu-raster"},
+		{FileExtension: ".rf", MimeType: "image/vnd.rn-realflash"},
+		{FileExtension: ".rgb", MimeType: "image/x-rgb"},
+		{FileExtension: ".rm", MimeType: "application/vnd.rn-realmedia"},
+		{FileExtension: ".rmi", MimeType: "audio/mid"},
+		{FileExtension: ".roff", MimeType: "application/x-troff"},
+		{FileExtension: ".rpm", MimeType: "audio/x-pn-realaudio-plugin"},
+		{FileExtension: ".rtf", MimeType: "application/rtf"},
+		{FileExtension: ".rtx", MimeType: "text/richtext"},
+		{FileExtension: ".scd", MimeType: "application/x-msschedule"},
+		{FileExtension: ".sct", MimeType: "text/scriptlet"},
+		{FileExtension: ".sea", MimeType: "application/octet-stream"},
+		{FileExtension: ".setpay", MimeType: "application/set-payment-initiation"},
+		{FileExtension: ".setreg", MimeType: "application/set-registration-initiation"},
+		{FileExtension: ".sgml", MimeType: "text/sgml"},
+		{FileExtension: ".sh", MimeType: "application/x-sh"},
+		{FileExtension: ".shar", MimeType: "application/x-shar"},
+		{FileExtension: ".sit", MimeType: "application/x-stuffit"},
+		{FileExtension: ".sldm", MimeType: "application/vnd.ms-powerpoint.slide.macroEnabled.12"},
+		{FileExtension: ".sldx", MimeType: "application/vnd.openxmlformats-officedocument.presentationml.slide"},
+		{FileExtension: ".smd", MimeType: "audio/x-smd"},
+		{FileExtension: ".smi", MimeType: "application/octet-stream"},
+		{FileExtension: ".smx", MimeType: "audio/x-smd"},
+		{FileExtension: ".smz", MimeType: "audio/x-smd"},
+		{FileExtension: ".snd", MimeType: "audio/basic"},
+		{FileExtension: ".snp", MimeType: "application/octet-stream"},
+		{FileExtension: ".spc", MimeType: "application/x-pkcs7-certificates"},
+		{FileExtension: ".spl", MimeType: "application/futuresplash"},
+		{FileExtension: ".spx", MimeType: "audio/ogg"},
+		{FileExtension: ".src", MimeType: "application/x-wais-source"},
+		{FileExtension: ".ssm", MimeType: "application/streamingmedia"},
+		{FileExtension: ".sst", MimeType: "application/vnd.ms-pki.certstore"},
+		{FileExtension: ".stl", MimeType: "application/vnd.ms-pki.stl"},
+		{FileExtension: ".sv4cpio", MimeType: "application/x-sv4cpio"},
+		{FileExtension: ".sv4crc", MimeType: "application/x-sv4crc"},
+		{FileExtension: ".svg", MimeType: "image/svg+xml"},
+		{FileExtension: ".svgz", MimeType: "image/svg+xml"},
+		{FileExtension: ".swf", MimeType: "application/x-shockwave-flash"},
+		{FileExtension: ".t", MimeType: "application/x-troff"},
+		{FileExtension: ".tar", MimeType: "application/x-tar"},
+		{FileExtension: ".tcl", MimeType: "application/x-tcl"},
+		{FileExtension: ".tex", MimeType: "application/x-tex"},
+		{FileExtension: ".texi", MimeType: "application/x-texinfo"},
+		{FileExtension: ".texinfo", MimeType: "application/x-texinfo"},
+		{FileExtension: ".tgz", MimeType: "application/x-compressed"},
+		{FileExtension: ".thmx", MimeType: "application/vnd.ms-officetheme"},
+		{FileExtension: ".thn", MimeType: "application/octet-stream"},
+		{FileExtension: ".tif", MimeType: "image/tiff"},
+		{FileExtension: ".tiff", MimeType: "image/tiff"},
+		{FileExtension: ".toc", MimeType: "application/octet-stream"},
+		{FileExtension: ".tr", MimeType: "application/x-troff"},
+		{FileExtension: ".trm", MimeType: "application/x-msterminal"},
+		{FileExtension: ".ts", MimeType: "video/vnd.dlna.mpeg-tts"},
+		{FileExtension: ".tsv", MimeType: "text/tab-separated-values"},
+		{FileExtension: ".ttf", MimeType: "application/octet-stream"},
+		{FileExtension: ".tts", MimeType: "video/vnd.dlna.mpeg-tts"},
+		{FileExtension: ".txt", MimeType: "text/plain"},
+		{FileExtension: ".u32", MimeType: "application/octet-stream"},
+		{FileExtension: ".uls", MimeType: "text/iuls"},
+		{FileExtension: ".ustar", MimeType: "application/x-ustar"},
+		{FileExtension: ".vbs", MimeType: "text/vbscript"},
+		{FileExtension: ".vcf", MimeType: "text/x-vcard"},
+		{FileExtension: ".vcs", MimeType: "text/plain"},
+		{FileExtension: ".vdx", MimeType: "application/vnd.ms-visio.viewer"},
+		{FileExtension: ".vml", MimeType: "text/xml"},
+		{FileExtension: ".vsd", MimeType: "application/vnd.visio"},
+		{FileExtension: ".vss", MimeType: "application/vnd.visio"},
+		{FileExtension: ".vst", MimeType: "application/vnd.visio"},
+		{FileExtension: ".vsto", MimeType: "application/x-ms-vsto"},
+		{FileExtension: ".vsw", MimeType: "application/vnd.visio"},
+		{FileExtension: ".vsx", MimeType: "application/vnd.visio"},
+		{FileExtension: ".vtx", MimeType: "application/vnd.visio"},
+		{FileExtension: ".wav", MimeType: "audio/wav"},
+		{FileExtension: ".wax", MimeType: "audio/x-ms-wax"},
+		{FileExtension: ".wbmp", MimeType: "image/vnd.wap.wbmp"},
+		{FileExtension: ".wcm", MimeType: "application/vnd.ms-works"},
+		{FileExtension: ".wdb", MimeType: "application/vnd.ms-works"},
+		{FileExtension: ".webm", MimeType: "video/webm"},
+		{FileExtension: ".wks", MimeType: "application/vnd.ms-works"},
+		{FileExtension: ".wm", MimeType: "video/x-ms-wm"},
+		{FileExtension: ".wma", MimeType: "audio/x-ms-wma"},
+		{FileExtension: ".wmd", MimeType: "application/x-ms-wmd"},
+		{FileExtension: ".wmf", MimeType: "application/x-msmetafile"},
+		{FileExtension: ".wml", MimeType: "text/vnd.wap.wml"},
+		{FileExtension: ".wmlc", MimeType: "application/vnd.wap.wmlc"},
+		{FileExtension: ".wmls", MimeType: "text/vnd.wap.wmlscript"},
+		{FileExtension: ".wmlsc", MimeType: "application/vnd.wap.wmlscriptc"},
+		{FileExtension: ".wmp", MimeType: "video/x-ms-wmp"},
+		{FileExtension: ".wmv", MimeType: "video/x-ms-wmv"},
+		{FileExtension: ".wmx", MimeType: "video/x-ms-wmx"},
+		{FileExtension: ".wmz", MimeType: "application/x-ms-wmz"},
+		{FileExtension: ".woff", MimeType: "font/x-woff"},
+		{FileExtension: ".wps", MimeType: "application/vnd.ms-works"},
+		{FileExtension: ".wri", MimeType: "application/x-mswrite"},
+		{FileExtension: ".wrl", MimeType: "x-world/x-vrml"},
+		{FileExtension: ".wrz", MimeType: "x-world/x-vrml"},
+		{FileExtension: ".wsdl", MimeType: "text/xml"},
+		{FileExtension: ".wtv", MimeType: "video/x-ms-wtv"},
+		{FileExtension: ".wvx", MimeType: "video/x-ms-wvx"},
+		{FileExtension: ".x", MimeType: "application/directx"},
+		{FileExtension: ".xaf", MimeType: "x-world/x-vrml"},
+		{FileExtension: ".xaml", MimeType: "application/xaml+xml"},
+		{FileExtension: ".xap", MimeType: "application/x-silverlight-app"},
+		{FileExtension: ".xbap", MimeType: "application/x-ms-xbap"},
+		{FileExtension: ".xbm", MimeType: "image/x-xbitmap"},
+		{FileExtension: ".xdr", MimeType: "text/plain"},
+		{FileExtension: ".xht", MimeType: "application/xhtml+xml"},
+		{FileExtension: ".xhtml", MimeType: "application/xhtml+xml"},
+		{FileExtension: ".xla", MimeType: "application/vnd.ms-excel"},
+		{FileExtension: ".xlam", MimeType: "application/vnd.ms-excel.addin.macroEnabled.12"},
+		{FileExtension: ".xlc", MimeType: "application/vnd.ms-excel"},
+		{FileExtension: ".xlm", MimeType: "application/vnd.ms-excel"},
+		{FileExtension: ".xls", MimeType: "application/vnd.ms-excel"},
+		{FileExtension: ".xlsb", MimeType: "application/vnd.ms-excel.sheet.binary.macroEnabled.12"},
+		{FileExtension: ".xlsm", MimeType: "application/vnd.ms-excel.sheet.macroEnabled.12"},
+		{FileExtension: ".xlsx", MimeType: "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"},
+		{FileExtension: ".xlt", MimeType: "application/vnd.ms-excel"},
+		{FileExtension: ".xltm", MimeType: "application/vnd.ms-excel.template.macroEnabled.12"},
+		{FileExtension: ".xltx", MimeType: "application/vnd.openxmlformats-officedocument.spreadsheetml.template"},
+		{FileExtension: ".xlw", MimeType: "application/vnd.ms-excel"},
+		{FileExtension: ".xml", MimeType: "text/xml"},
+		{FileExtension: ".xof", MimeType: "x-world/x-vrml"},
+		{FileExtension: ".xpm", MimeType: "image/x-xpixmap"},
+		{FileExtension: ".xps", MimeType: "application/vnd.ms-xpsdocument"},
+		{FileExtension: ".xsd", MimeType: "text/xml"},
+		{FileExtension: ".xsf", MimeType: "text/xml"},
+		{FileExtension: ".xsl", MimeType: "text/xml"},
+		{FileExtension: ".xslt", MimeType: "text/xml"},
+		{FileExtension: ".xsn", MimeType: "application/octet-stream"},
+		{FileExtension: ".xtp", MimeType: "application/octet-stream"},
+		{FileExtension: ".xwd", MimeType: "image/x-xwindowdump"},
+		{FileExtension: ".z", MimeType: "application/x-compress"},
+		{FileExtension: ".zip", MimeType: "application/x-zip-compressed"},
+	}
+}
+
+func defaultModules() []apphost.Module {
+	return []apphost.Module{
+		{Name: "HttpCacheModule", LockItem: true},
+		{Name: "StaticCompressionModule", LockItem: true},
+		{Name: "DynamicCompressionModule", LockItem: true},
+		{Name: "DefaultDocumentModule", LockItem: true},
+		{Name: "DirectoryListingModule", LockItem: true},
+		{Name: "IsapiFilterModule", LockItem: true},
+		{Name: "ProtocolSupportModule", LockItem: true},
+		{Name: "StaticFileModule", LockItem: true},
+		{Name: "AnonymousAuthenticationModule", LockItem: true},
+		{Name: "WindowsAuthenticationModule", LockItem: true},
+		{Name: "RequestFilteringModule", LockItem: true},
+		{Name: "CustomErrorModule", LockItem: true},
+		{Name: "IsapiModule", LockItem: true},
+		{Name: "HttpLoggingModule", LockItem: true},
+		{Name: "ConfigurationValidationModule", LockItem: true},
+		{Name: "OutputCache", Type: "System.Web.Caching.OutputCacheModule", PreCondition: "managedHandler"},
+		{Name: "Session", Type: "System.Web.SessionState.SessionStateModule", PreCondition: "managedHandler"},
+		{Name: "WindowsAuthentication", Type: "System.Web.Security.WindowsAuthenticationModule", PreCondition: "managedHandler"},
+		{Name: "FormsAuthentication", Type: "System.Web.Security.FormsAuthenticationModule", PreCondition: "managedHandler"},
+		{Name: "DefaultAuthentication", Type: "System.Web.Security.DefaultAuthenticationModule", PreCondition: "managedHandler"},
+		{Name: "RoleManager", Type: "System.Web.Security.RoleManagerModule", PreCondition: "managedHandler"},
+		{Name: "UrlAuthorization", Type: "System.Web.Security.UrlAuthorizationModule", PreCondition: "managedHandler"},
+		{Name: "FileAuthorization", Type: "System.Web.Security.FileAuthorizationModule", PreCondition: "managedHandler"},
+		{Name: "AnonymousIdentification", Type: "System.Web.Security.AnonymousIdentificationModule", PreCondition: "managedHandler"},
+		{Name: "Profile", Type: "System.Web.Profile.ProfileModule", PreCondition: "managedHandler"},
+		{Name: "UrlMappingsModule", Type: "System.Web.UrlMappingsModule", PreCondition: "managedHandler"},
+		{Name: "ServiceModel", Type: "System.ServiceModel.Activation.HttpModule, System.ServiceModel, Version=3.0.0.0, Culture=neutral, PublicKeyToken=b77a5c561934e089", PreCondition: "managedHandler,runtimeVersionv2.0"},
+		{Name: "ServiceModel-4.0", Type: "System.ServiceModel.Activation.ServiceHttpModule, System.ServiceModel.Activation, Version=4.0.0.0, Culture=neutral, PublicKeyToken=31bf3856ad364e35", PreCondition: "managedHandler,runtimeVersionv4.0"},
+		{Name: "UrlRoutingModule-4.0", Type: "System.Web.Routing.UrlRoutingModule", PreCondition: "managedHandler,runtimeVersionv4.0"},
+		{Name: "ScriptModule-4.0", Type: "System.Web.Handlers.ScriptModule, System.Web.Extensions, Version=4.0.0.0, Culture=neutral, PublicKeyToken=31bf3856ad364e35", PreCondition: "managedHandler,runtimeVersionv4.0"},
+		{Name: "CustomLoggingModule", LockItem: true},
+		{Name: "FailedRequestsTracingModule", LockItem: true},
+		{Name: "WebSocketModule", LockItem: true},
+		{Name: "HttpRedirectionModule", LockItem: true},
+		{Name: "CertificateMappingAuthenticationModule", LockItem: true},
+		{Name: "UrlAuthorizationModule", LockItem: true},
+		{Name: "DigestAuthenticationModule", LockItem: true},
+		{Name: "IISCertificateMappingAuthenticationModule", LockItem: true},
+		{Name: "IpRestrictionModule", LockItem: true},
+	}
+}
+
+func defaultHandlers() []apphost.Handler {
+	return []apphost.Handler{
+		{Name: "ASP Classic", Path: "*.asp", Verb: "GET,HEAD,POST", Modules: "IsapiModule", ScriptProcessor: `%windir%\system32\inetsrv\asp.dll`, ResourceType: "File"},
+		{Name: "ISAPI-dll", Path: "*.dll", Verb: "*", Modules: "IsapiModule", ResourceType: "File", RequireAccess: "Execute", AllowPathInfo: true},
+		{Name: "AXD-ISAPI-4.0_32bit", Path: "*.axd", Verb: "GET,HEAD,POST,DEBUG", Modules: "IsapiModule", ScriptProcessor: `%windir%\Microsoft.NET\Framework\v4.0.30319\aspnet_isapi.dll`, PreCondition: "classicMode,runtimeVersionv4.0,bitness32", ResponseBufferLimit: "0"},
+		{Name: "PageHandlerFactory-ISAPI-4.0_32bit", Path: "*.aspx", Verb: "GET,HEAD,POST,DEBUG", Modules: "IsapiModule", ScriptProcessor: `%windir%\Microsoft.NET\Framework\v4.0.30319\aspnet_isapi.dll`, PreCondition: "classicMode,runtimeVersionv4.0,bitness32", ResponseBufferLimit: "0"},
+		{Name: "SimpleHandlerFactory-ISAPI-4.0_32bit", Path: "*.ashx", Verb: "GET,HEAD,POST,DEBUG", Modules: "IsapiModule", ScriptProcessor: `%windir%\Microsoft.NET\Framework\v4.0.30319\aspnet_isapi.dll`, PreCondition: "classicMode,runtimeVersionv4.0,bitness32", ResponseBufferLimit: "0"},
+		{Name: "WebServiceHandlerFactory-ISAPI-4.0_32bit", Path: "*.asmx", Verb: "GET,HEAD,POST,DEBUG", Modules: "IsapiModule", ScriptProcessor: `%windir%\Microsoft.NET\Framework\v4.0.30319\aspnet_isapi.dll`, PreCondition: "classicMode,runtimeVersionv4.0,bitness32", ResponseBufferLimit: "0"},
+		{Name: "HttpRemotingHandlerFactory-rem-ISAPI-4.0_32bit", Path: "*.rem", Verb: "GET,HEAD,POST,DEBUG", Modules: "IsapiModule", ScriptProcessor: `%windir%\Microsoft.NET\Framework\v4.0.30319\aspnet_isapi.dll`, PreCondition: "classicMode,runtimeVersionv4.0,bitness32", ResponseBufferLimit: "0"},
+		{Name: "HttpRemotingHandlerFactory-soap-ISAPI-4.0_32bit", Path: "*.soap", Verb: "GET,HEAD,POST,DEBUG", Modules: "IsapiModule", ScriptProcessor: `%windir%\Microsoft.NET\Framework\v4.0.30319\aspnet_isapi.dll`, PreCondition: "classicMode,runtimeVersionv4.0,bitness32", ResponseBufferLimit: "0"},
+		{Name: "svc-ISAPI-4.0_32bit", Path: "*.svc", Verb: "*", Modules: "IsapiModule", ScriptProcessor: `%windir%\Microsoft.NET\Framework\v4.0.30319\aspnet_isapi.dll`, PreCondition: "classicMode,runtimeVersionv4.0,bitness32", ResponseBufferLimit: "0"},
+		{Name: "rules-ISAPI-4.0_32bit", Path: "*.rules", Verb: "*", Modules: "IsapiModule", ScriptProcessor: `%windir%\Microsoft.NET\Framework\v4.0.30319\aspnet_isapi.dll`, PreCondition: "classicMode,runtimeVersionv4.0,bitness32", ResponseBufferLimit: "0"},
+		{Name: "xoml-ISAPI-4.0_32bit", Path: "*.xoml", Verb: "*", Modules: "IsapiModule", ScriptProcessor: `%windir%\Microsoft.NET\Framework\v4.0.30319\aspnet_isapi.dll`, PreCondition: "classicMode,runtimeVersionv4.0,bitness32", ResponseBufferLimit: "0"},
+		{Name: "xamlx-ISAPI-4.0_32bit", Path: "*.xamlx", Verb: "GET,HEAD,POST,DEBUG", Modules: "IsapiModule", ScriptProcessor: `%windir%\Microsoft.NET\Framework\v4.0.30319\aspnet_isapi.dll`, PreCondition: "classicMode,runtimeVersionv4.0,bitness32", ResponseBufferLimit: "0"},
+		{Name: "aspq-ISAPI-4.0_32bit", Path: "*.aspq", Verb: "GET,HEAD,POST,DEBUG", Modules: "IsapiModule", ScriptProcessor: `%windir%\Microsoft.NET\Framework\v4.0.30319\aspnet_isapi.dll`, PreCondition: "classicMode,runtimeVersionv4.0,bitness32", ResponseBufferLimit: "0"},
+		{Name: "cshtm-ISAPI-4.0_32bit", Path: "*.cshtm", Verb: "GET,HEAD,POST,DEBUG", Modules: "IsapiModule", ScriptProcessor: `%windir%\Microsoft.NET\Framework\v4.0.30319\aspnet_isapi.dll`, PreCondition: "classicMode,runtimeVersionv4.0,bitness32", ResponseBufferLimit: "0"},
+		{Name: "cshtml-ISAPI-4.0_32bit", Path: "*.cshtml", Verb: "GET,HEAD,POST,DEBUG", Modules: "IsapiModule", ScriptProcessor: `%windir%\Microsoft.NET\Framework\v4.0.30319\aspnet_isapi.dll`, PreCondition: "classicMode,runtimeVersionv4.0,bitness32", ResponseBufferLimit: "0"},
+		{Name: "vbhtm-ISAPI-4.0_32bit", Path: "*.vbhtm", Verb: "GET,HEAD,POST,DEBUG", Modules: "IsapiModule", ScriptProcessor: `%windir%\Microsoft.NET\Framework\v4.0.30319\aspnet_isapi.dll`, PreCondition: "classicMode,runtimeVersionv4.0,bitness32", ResponseBufferLimit: "0"},
+		{Name: "vbhtml-ISAPI-4.0_32bit", Path: "*.vbhtml", Verb: "GET,HEAD,POST,DEBUG", Modules: "IsapiModule", ScriptProcessor: `%windir%\Microsoft.NET\Framework\v4.0.30319\aspnet_isapi.dll`, PreCondition: "classicMode,runtimeVersionv4.0,bitness32", ResponseBufferLimit: "0"},
+		{Name: "AXD-ISAPI-4.0_64bit", Path: "*.axd", Verb: "GET,HEAD,POST,DEBUG", Modules: "IsapiModule", ScriptProcessor: `%windir%\Microsoft.NET\Framework64\v4.0.30319\aspnet_isapi.dll`, PreCondition: "classicMode,runtimeVersionv4.0,bitness64", ResponseBufferLimit: "0"},
+		{Name: "PageHandlerFactory-ISAPI-4.0_64bit", Path: "*.aspx", Verb: "GET,HEAD,POST,DEBUG", Modules: "IsapiModule", ScriptProcessor: `%windir%\Microsoft.NET\Framework64\v4.0.30319\aspnet_isapi.dll`, PreCondition: "classicMode,runtimeVersionv4.0,bitness64", ResponseBufferLimit: "0"},
+		{Name: "SimpleHandlerFactory-ISAPI-4.0_64bit", Path: "*.ashx", Verb: "GET,HEAD,POST,DEBUG", Modules: "IsapiModule", ScriptProcessor: `%windir%\Microsoft.NET\Framework64\v4.0.30319\aspnet_isapi.dll`, PreCondition: "classicMode,runtimeVersionv4.0,bitness64", ResponseBufferLimit: "0"},
+		{Name: "WebServiceHandlerFactory-ISAPI-4.0_64bit", Path: "*.asmx", Verb: "GET,HEAD,POST,DEBUG", Modules: "IsapiModule", ScriptProcessor: `%windir%\Microsoft.NET\Framework64\v4.0.30319\aspnet_isapi.dll`, PreCondition: "classicMode,runtimeVersionv4.0,bitness64", ResponseBufferLimit: "0"},
+		{Name: "HttpRemotingHandlerFactory-rem-ISAPI-4.0_64bit", Path: "*.rem", Verb: "GET,HEAD,POST,DEBUG", Modules: "IsapiModule", ScriptProcessor: `%windir%\Microsoft.NET\Framework64\v4.0.30319\aspnet_isapi.dll`, PreCondition: "classicMode,runtimeVersionv4.0,bitness64", ResponseBufferLimit: "0"},
+		{Name: "HttpRemotingHandlerFactory-soap-ISAPI-4.0_64bit", Path: "*.soap", Verb: "GET,HEAD,POST,DEBUG", Modules: "IsapiModule", ScriptProcessor: `%windir%\Microsoft.NET\Framework64\v4.0.30319\aspnet_isapi.dll`, PreCondition: "classicMode,runtimeVersionv4.0,bitness64", ResponseBufferLimit: "0"},
+		{Name: "svc-ISAPI-4.0_64bit", Path: "*.svc", Verb: "*", Modules: "IsapiModule", ScriptProcessor: `%windir%\Microsoft.NET\Framework64\v4.0.30319\aspnet_isapi.dll`, PreCondition: "classicMode,runtimeVersionv4.0,bitness64", ResponseBufferLimit: "0"},
+		{Name: "rules-ISAPI-4.0_64bit", Path: "*.rules", Verb: "*", Modules: "IsapiModule", ScriptProcessor: `%windir%\Microsoft.NET\Framework64\v4.0.30319\aspnet_isapi.dll`, PreCondition: "classicMode,runtimeVersionv4.0,bitness64", ResponseBufferLimit: "0"},
+		{Name: "xoml-ISAPI-4.0_64bit", Path: "*.xoml", Verb: "*", Modules: "IsapiModule", ScriptProcessor: `%windir%\Microsoft.NET\Framework64\v4.0.30319\aspnet_isapi.dll`, PreCondition: "classicMode,runtimeVersionv4.0,bitness64", ResponseBufferLimit: "0"},
+		{Name: "xamlx-ISAPI-4.0_64bit", Path: "*.xamlx", Verb: "GET,HEAD,POST,DEBUG", Modules: "IsapiModule", ScriptProcessor: `%windir%\Microsoft.NET\Framework64\v4.0.30319\aspnet_isapi.dll`, PreCondition: "classicMode,runtimeVersionv4.0,bitness64", ResponseBufferLimit: "0"},
+		{Name: "aspq-ISAPI-4.0_64bit", Path: "*.aspq", Verb: "GET,HEAD,POST,DEBUG", Modules: "IsapiModule", ScriptProcessor: `%windir%\Microsoft.NET\Framework64\v4.0.30319\aspnet_isapi.dll`, PreCondition: "classicMode,runtimeVersionv4.0,bitness64", ResponseBufferLimit: "0"},
+		{Name: "cshtm-ISAPI-4.0_64bit", Path: "*.cshtm", Verb: "GET,HEAD,POST,DEBUG", Modules: "IsapiModule", ScriptProcessor: `%windir%\Microsoft.NET\Framework64\v4.0.30319\aspnet_isapi.dll`, PreCondition: "classicMode,runtimeVersionv4.0,bitness64", ResponseBufferLimit: "0"},
+		{Name: "cshtml-ISAPI-4.0_64bit", Path: "*.cshtml", Verb: "GET,HEAD,POST,DEBUG", Modules: "IsapiModule", ScriptProcessor: `%windir%\Microsoft.NET\Framework64\v4.0.30319\aspnet_isapi.dll`, PreCondition: "classicMode,runtimeVersionv4.0,bitness64", ResponseBufferLimit: "0"},
+		{Name: "vbhtm-ISAPI-4.0_64bit", Path: "*.vbhtm", Verb: "GET,HEAD,POST,DEBUG", Modules: "IsapiModule", ScriptProcessor: `%windir%\Microsoft.NET\Framework64\v4.0.30319\aspnet_isapi.dll`, PreCondition: "classicMode,runtimeVersionv4.0,bitness64", ResponseBufferLimit: "0"},
+		{Name: "vbhtml-ISAPI-4.0_64bit", Path: "*.vbhtml", Verb: "GET,HEAD,POST,DEBUG", Modules: "IsapiModule", ScriptProcessor: `%windir%\Microsoft.NET\Framework64\v4.0.30319\aspnet_isapi.dll`, PreCondition: "classicMode,runtimeVersionv4.0,bitness64", ResponseBufferLimit: "0"},
+		{Name: "TraceHandler-Integrated-4.0", Path: "trace.axd", Verb: "GET,HEAD,POST,DEBUG", Type: "System.Web.Handlers.TraceHandler", PreCondition: "integratedMode,runtimeVersionv4.0"},
+		{Name: "WebAdminHandler-Integrated-4.0", Path: "WebAdmin.axd", Verb: "GET,DEBUG", Type: "System.Web.Handlers.WebAdminHandler", PreCondition: "integratedMode,runtimeVersionv4.0"},
+		{Name: "AssemblyResourceLoader-Integrated-4.0", Path: "WebResource.axd", Verb: "GET,DEBUG", Type: "System.Web.Handlers.AssemblyResourceLoader", PreCondition: "integratedMode,runtimeVersionv4.0"},
+		{Name: "PageHandlerFactory-Integrated-4.0", Path: "*.aspx", Verb: "GET,HEAD,POST,DEBUG", Type: "System.Web.UI.PageHandlerFactory", PreCondition: "integratedMode,runtimeVersionv4.0"},
+		{Name: "SimpleHandlerFactory-Integrated-4.0", Path: "*.ashx", Verb: "GET,HEAD,POST,DEBUG", Type: "System.Web.UI.SimpleHandlerFactory", PreCondition: "integratedMode,runtimeVersionv4.0"},
+		{Name: "WebServiceHandlerFactory-Integrated-4.0", Path: "*.asmx", Verb: "GET,HEAD,POST,DEBUG", Type: "System.Web.Script.Services.ScriptHandlerFactory, System.Web.Extensions, Version=4.0.0.0, Culture=neutral, PublicKeyToken=31bf3856ad364e35", PreCondition: "integratedMode,runtimeVersionv4.0"},
+		{Name: "HttpRemotingHandlerFactory-rem-Integrated-4.0", Path: "*.rem", Verb: "GET,HEAD,POST,DEBUG", Type: "System.Runtime.Remoting.Channels.Http.HttpRemotingHandlerFactory, System.Runtime.Remoting, Version=4.0.0.0, Culture=neutral, PublicKeyToken=b77a5c561934e089", PreCondition: "integratedMode,runtimeVersionv4.0"},
+		{Name: "HttpRemotingHandlerFactory-soap-Integrated-4.0", Path: "*.soap", Verb: "GET,HEAD,POST,DEBUG", Type: "System.Runtime.Remoting.Channels.Http.HttpRemotingHandlerFactory, System.Runtime.Remoting, Version=4.0.0.0, Culture=neutral, PublicKeyToken=b77a5c561934e089", PreCondition: "integratedMode,runtimeVersionv4.0"},
+		{Name: "svc-Integrated-4.0", Path: "*.svc", Verb: "*", Type: "System.ServiceModel.Activation.ServiceHttpHandlerFactory, System.ServiceModel.Activation, Version=4.0.0.0, Culture=neutral, PublicKeyToken=31bf3856ad364e35", PreCondition: "integratedMode,runtimeVersionv4.0"},
+		{Name: "rules-Integrated-4.0", Path: "*.rules", Verb: "*", Type: "System.ServiceModel.Activation.ServiceHttpHandlerFactory, System.ServiceModel.Activation, Version=4.0.0.0, Culture=neutral, PublicKeyToken=31bf3856ad364e35", PreCondition: "integratedMode,runtimeVersionv4.0"},
+		{Name: "xoml-Integrated-4.0", Path: "*.xoml", Verb: "*", Type: "System.ServiceModel.Activation.ServiceHttpHandlerFactory, System.ServiceModel.Activation, Version=4.0.0.0, Culture=neutral, PublicKeyToken=31bf3856ad364e35", PreCondition: "integratedMode,runtimeVersionv4.0"},
+		{Name: "xamlx-Integrated-4.0", Path: "*.xamlx", Verb: "GET,HEAD,POST,DEBUG", Type: "System.Xaml.Hosting.XamlHttpHandlerFactory, System.Xaml.Hosting, Version=4.0.0.0, Culture=neutral, PublicKeyToken=31bf3856ad364e35", PreCondition: "integratedMode,runtimeVersionv4.0"},
+		{Name: "aspq-Integrated-4.0", Path: "*.aspq", Verb: "GET,HEAD,POST,DEBUG", Type: "System.Web.HttpForbiddenHandler", PreCondition: "integratedMode,runtimeVersionv4.0"},
+		{Name: "cshtm-Integrated-4.0", Path: "*.cshtm", Verb: "GET,HEAD,POST,DEBUG", Type: "System.Web.HttpForbiddenHandler", PreCondition: "integratedMode,runtimeVersionv4.0"},
+		{Name: "cshtml-Integrated-4.0", Path: "*.cshtml", Verb: "GET,HEAD,POST,DEBUG", Type: "System.Web.HttpForbiddenHandler", PreCondition: "integratedMode,runtimeVersionv4.0"},
+		{Name: "vbhtm-Integrated-4.0", Path: "*.vbhtm", Verb: "GET,HEAD,POST,DEBUG", Type: "System.Web.HttpForbiddenHandler", PreCondition: "integratedMode,runtimeVersionv4.0"},
+		{Name: "vbhtml-Integrated-4.0", Path: "*.vbhtml", Verb: "GET,HEAD,POST,DEBUG", Type: "System.Web.HttpForbiddenHandler", PreCondition: "integratedMode,runtimeVersionv4.0"},
+		{Name: "ScriptHandlerFactoryAppServices-Integrated-4.0", Path: "*_AppService.axd", Verb: "*", Type: "System.Web.Script.Services.ScriptHandlerFactory, System.Web.Extensions, Version=4.0.0.0, Culture=neutral, PublicKeyToken=31BF3856AD364E35", PreCondition: "integratedMode,runtimeVersionv4.0"},
+		{Name: "ScriptResourceIntegrated-4.0", Path: "ScriptResource.axd", Verb: "GET,HEAD", Type: "System.Web.Handlers.ScriptResourceHandler, System.Web.Extensions, Version=4.0.0.0, Culture=neutral, PublicKeyToken=31BF3856AD364E35", PreCondition: "integratedMode,runtimeVersionv4.0"},
+		{Name: "TraceHandler-Integrated", Path: "trace.axd", Verb: "GET,HEAD,POST,DEBUG", Type: "System.Web.Handlers.TraceHandler", PreCondition: "integratedMode"},
+		{Name: "WebAdminHandler-Integrated", Path: "WebAdmin.axd", Verb: "GET,DEBUG", Type: "System.Web.Handlers.WebAdminHandler", PreCondition: "integratedMode"},
+		{Name: "AssemblyResourceLoader-Integrated", Path: "WebResource.axd", Verb: "GET,DEBUG", Type: "System.Web.Handlers.AssemblyResourceLoader", PreCondition: "integratedMode"},
+		{Name: "PageHandlerFactory-Integrated", Path: "*.aspx", Verb: "GET,HEAD,POST,DEBUG", Type: "System.Web.UI.PageHandlerFactory", PreCondition: "integratedMode"},
+		{Name: "SimpleHandlerFactory-Integrated", Path: "*.ashx", Verb: "GET,HEAD,POST,DEBUG", Type: "System.Web.UI.SimpleHandlerFactory", PreCondition: "integratedMode"},
+		{Name: "WebServiceHandlerFactory-Integrated", Path: "*.asmx", Verb: "GET,HEAD,POST,DEBUG", Type: "System.Web.Services.Protocols.WebServiceHandlerFactory, System.Web.Services, Version=2.0.0.0, Culture=neutral, PublicKeyToken=b03f5f7f11d50a3a", PreCondition: "integratedMode,runtimeVersionv2.0"},
+		{Name: "HttpRemotingHandlerFactory-rem-Integrated", Path: "*.rem", Verb: "GET,HEAD,POST,DEBUG", Type: "System.Runtime.Remoting.Channels.Http.HttpRemotingHandlerFactory, System.Runtime.Remoting, Version=2.0.0.0, Culture=neutral, PublicKeyToken=b77a5c561934e089", PreCondition: "integratedMode,runtimeVersionv2.0"},
+		{Name: "HttpRemotingHandlerFactory-soap-Integrated", Path: "*.soap", Verb: "GET,HEAD,POST,DEBUG", Type: "System.Runtime.Remoting.Channels.Http.HttpRemotingHandlerFactory, System.Runtime.Remoting, Version=2.0.0.0, Culture=neutral, PublicKeyToken=b77a5c561934e089", PreCondition: "integratedMode,runtimeVersionv2.0"},
+		{Name: "AXD-ISAPI-2.0", Path: "*.axd", Verb: "GET,HEAD,POST,DEBUG", Modules: "IsapiModule", ScriptProcessor: `%windir%\Microsoft.NET\Framework\v2.0.50727\aspnet_isapi.dll`, PreCondition: "classicMode,runtimeVersionv2.0,bitness32", ResponseBufferLimit: "0"},
+		{Name: "PageHandlerFactory-ISAPI-2.0", Path: "*.aspx", Verb: "GET,HEAD,POST,DEBUG", Modules: "IsapiModule", ScriptProcessor: `%windir%\Microsoft.NET\Framework\v2.0.50727\aspnet_isapi.dll`, PreCondition: "classicMode,runtimeVersionv2.0,bitness32", ResponseBufferLimit: "0"},
+		{Name: "SimpleHandlerFactory-ISAPI-2.0", Path: "*.ashx", Verb: "GET,HEAD,POST,DEBUG", Modules: "IsapiModule", ScriptProcessor: `%windir%\Microsoft.NET\Framework\v2.0.50727\aspnet_isapi.dll`, PreCondition: "classicMode,runtimeVersionv2.0,bitness32", ResponseBufferLimit: "0"},
+		{Name: "WebServiceHandlerFactory-ISAPI-2.0", Path: "*.asmx", Verb: "GET,HEAD,POST,DEBUG", Modules: "IsapiModule", ScriptProcessor: `%windir%\Microsoft.NET\Framework\v2.0.50727\aspnet_isapi.dll`, PreCondition: "classicMode,runtimeVersionv2.0,bitness32", ResponseBufferLimit: "0"},
+		{Name: "HttpRemotingHandlerFactory-rem-ISAPI-2.0", Path: "*.rem", Verb: "GET,HEAD,POST,DEBUG", Modules: "IsapiModule", ScriptProcessor: `%windir%\Microsoft.NET\Framework\v2.0.50727\aspnet_isapi.dll`, PreCondition: "classicMode,runtimeVersionv2.0,bitness32", ResponseBufferLimit: "0"},
+		{Name: "HttpRemotingHandlerFactory-soap-ISAPI-2.0", Path: "*.soap", Verb: "GET,HEAD,POST,DEBUG", Modules: "IsapiModule", ScriptProcessor: `%windir%\Microsoft.NET\Framework\v2.0.50727\aspnet_isapi.dll`, PreCondition: "classicMode,runtimeVersionv2.0,bitness32", ResponseBufferLimit: "0"},
+		{Name: "AXD-ISAPI-2.0-64", Path: "*.axd", Verb: "GET,HEAD,POST,DEBUG", Modules: "IsapiModule", ScriptProcessor: `%windir%\Microsoft.NET\Framework64\v2.0.50727\aspnet_isapi.dll`, PreCondition: "classicMode,runtimeVersionv2.0,bitness64", ResponseBufferLimit: "0"},
+		{Name: "PageHandlerFactory-ISAPI-2.0-64", Path: "*.aspx", Verb: "GET,HEAD,POST,DEBUG", Modules: "IsapiModule", ScriptProcessor: `%windir%\Microsoft.NET\Framework64\v2.0.50727\aspnet_isapi.dll`, PreCondition: "classicMode,runtimeVersionv2.0,bitness64", ResponseBufferLimit: "0"},
+		{Name: "SimpleHandlerFactory-ISAPI-2.0-64", Path: "*.ashx", Verb: "GET,HEAD,POST,DEBUG", Modules: "IsapiModule", ScriptProcessor: `%windir%\Microsoft.NET\Framework64\v2.0.50727\aspnet_isapi.dll`, PreCondition: "classicMode,runtimeVersionv2.0,bitness64", ResponseBufferLimit: "0"},
+		{Name: "WebServiceHandlerFactory-ISAPI-2.0-64", Path: "*.asmx", Verb: "GET,HEAD,POST,DEBUG", Modules: "IsapiModule", ScriptProcessor: `%windir%\Microsoft.NET\Framework64\v2.0.50727\aspnet_isapi.dll`, PreCondition: "classicMode,runtimeVersionv2.0,bitness64", ResponseBufferLimit: "0"},
+		{Name: "HttpRemotingHandlerFactory-rem-ISAPI-2.0-64", Path: "*.rem", Verb: "GET,HEAD,POST,DEBUG", Modules: "IsapiModule", ScriptProcessor: `%windir%\Microsoft.NET\Framework64\v2.0.50727\aspnet_isapi.dll`, PreCondition: "classicMode,runtimeVersionv2.0,bitness64", ResponseBufferLimit: "0"},
+		{Name: "HttpRemotingHandlerFactory-soap-ISAPI-2.0-64", Path: "*.soap", Verb: "GET,HEAD,POST,DEBUG", Modules: "IsapiModule", ScriptProcessor: `%windir%\Microsoft.NET\Framework64\v2.0.50727\aspnet_isapi.dll`, PreCondition: "classicMode,runtimeVersionv2.0,bitness64", ResponseBufferLimit: "0"},
+		{Name: "TRACEVerbHandler", Path: "*", Verb: "TRACE", Modules: "ProtocolSupportModule", RequireAccess: "None"},
+		{Name: "OPTIONSVerbHandler", Path: "*", Verb: "OPTIONS", Modules: "ProtocolSupportModule", RequireAccess: "None"},
+		{Name: "ExtensionlessUrlHandler-ISAPI-4.0_32bit", Path: "*.", Verb: "GET,HEAD,POST,DEBUG", Modules: "IsapiModule", ScriptProcessor: `%windir%\Microsoft.NET\Framework\v4.0.30319\aspnet_isapi.dll`, PreCondition: "classicMode,runtimeVersionv4.0,bitness32", ResponseBufferLimit: "0"},
+		{Name: "ExtensionlessUrlHandler-ISAPI-4.0_64bit", Path: "*.", Verb: "GET,HEAD,POST,DEBUG", Modules: "IsapiModule", ScriptProcessor: `%windir%\Microsoft.NET\Framework64\v4.0.30319\aspnet_isapi.dll`, PreCondition: "classicMode,runtimeVersionv4.0,bitness64", ResponseBufferLimit: "0"},
+		{Name: "ExtensionlessUrlHandler-Integrated-4.0", Path: "*.", Verb: "GET,HEAD,POST,DEBUG", Type: "System.Web.Handlers.TransferRequestHandler", PreCondition: "integratedMode,runtimeVersionv4.0"},
+		{Name: "StaticFile", Path: "*", Verb: "*", Modules: "StaticFileModule,DefaultDocumentModule,DirectoryListingModule", ResourceType: "Either", RequireAccess: "Read"},
+	}
+}
+
+func defaultISAPIFilters() []apphost.ISAPIFilter {
+	return []apphost.ISAPIFilter{
+		{Name: "ASP.Net_2.0.50727-64", Path: `%windir%\Microsoft.NET\Framework64\v2.0.50727\aspnet_filter.dll`, EnableCache: true, PreCondition: "bitness64,runtimeVersionv2.0"},
+		{Name: "ASP.Net_2.0.50727.0", Path: `%windir%\Microsoft.NET\Framework\v2.0.50727\aspnet_filter.dll`, EnableCache: true, PreCondition: "bitness32,runtimeVersionv2.0"},
+		{Name: "ASP.Net_2.0_for_v1.1", Path: `%windir%\Microsoft.NET\Framework\v2.0.50727\aspnet_filter.dll`, EnableCache: true, PreCondition: "runtimeVersionv1.1"},
+		{Name: "ASP.Net_4.0_32bit", Path: `%windir%\Microsoft.NET\Framework\v4.0.30319\aspnet_filter.dll`, EnableCache: true, PreCondition: "bitness32,runtimeVersionv4.0"},
+		{Name: "ASP.Net_4.0_64bit", Path: `%windir%\Microsoft.NET\Framework64\v4.0.30319\aspnet_filter.dll`, EnableCache: true, PreCondition: "bitness64,runtimeVersionv4.0"},
+	}
+}
+
+func defaultISAPICgiRestrictions() []apphost.ISAPICgiRestriction {
+	return []apphost.ISAPICgiRestriction{
+		{Path: `%windir%\system32\inetsrv\asp.dll`, Allowed: true, GroupID: "ASP", Description: "Active Server Pages"},
+		{Path: `%windir%\Microsoft.NET\Framework64\v2.0.50727\aspnet_isapi.dll`, Allowed: true, GroupID: "ASP.NET v2.0.50727", Description: "ASP.NET v2.0.50727"},
+		{Path: `%windir%\Microsoft.NET\Framework\v2.0.50727\aspnet_isapi.dll`, Allowed: true, GroupID: "ASP.NET v2.0.50727", Description: "ASP.NET v2.0.50727"},
+		{Path: `%windir%\Microsoft.NET\Framework\v4.0.30319\aspnet_isapi.dll`, Allowed: false, GroupID: "ASP.NET v4.0.30319", Description: "ASP.NET v4.0.30319"},
+		{Path: `%windir%\Microsoft.NET\Framework64\v4.0.30319\aspnet_isapi.dll`, Allowed: true, GroupID: "ASP.NET v4.0.30319", Description: "ASP.NET v4.0.30319"},
+	}
+}
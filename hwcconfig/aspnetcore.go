@@ -0,0 +1,77 @@
+package hwcconfig
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"code.cloudfoundry.org/hwc/apphost"
+)
+
+const (
+	HostingModelInProcess    = "inprocess"
+	HostingModelOutOfProcess = "outofprocess"
+)
+
+// aspNetCoreImage is where the ASP.NET Core Hosting Bundle installs the
+// ASP.NET Core Module.
+const aspNetCoreImage = `%ProgramFiles%\IIS\Asp.Net Core Module\V2\aspnetcorev2.dll`
+
+// AspNetCore configures the ASP.NET Core Module, which hwc loads when it is
+// installed. Apps published for IIS configure the module in their Web.config.
+// ProcessPath configures it for apps that don't have an <aspNetCore> section,
+// such as a self-contained executable.
+type AspNetCore struct {
+	ProcessPath  string `json:"processPath"`
+	Arguments    string `json:"arguments"`
+	HostingModel string `json:"hostingModel"`
+
+	// appConfigured is set when the app's Web.config has an <aspNetCore>
+	// section.
+	appConfigured bool
+}
+
+func DefaultAspNetCore() AspNetCore {
+	return AspNetCore{HostingModel: HostingModelInProcess}
+}
+
+// Enabled reports whether hwc configures the module for the app.
+func (a *AspNetCore) Enabled() bool {
+	return a.ProcessPath != ""
+}
+
+func (a *AspNetCore) loadEnv() error {
+	if s, ok := os.LookupEnv("HWC_ASPNETCORE_PROCESS_PATH"); ok {
+		a.ProcessPath = s
+	}
+	if s, ok := os.LookupEnv("HWC_ASPNETCORE_ARGUMENTS"); ok {
+		a.Arguments = s
+	}
+	if s := os.Getenv("HWC_ASPNETCORE_HOSTING_MODEL"); s != "" {
+		a.HostingModel = s
+	}
+	a.HostingModel = strings.ToLower(a.HostingModel)
+	return oneOf("ASP.NET Core hosting model", a.HostingModel, HostingModelInProcess, HostingModelOutOfProcess)
+}
+
+// resolve looks for an <aspNetCore> section in the Web.config of the app in
+// root. The module has to be installed for either that section or
+// ProcessPath to work, and only one of them may configure it.
+func (a *AspNetCore) resolve(root string) error {
+	var err error
+	if a.appConfigured, err = appConfigures(root, "aspNetCore"); err != nil {
+		return err
+	}
+	if a.appConfigured && a.Enabled() {
+		return fmt.Errorf("HWC_ASPNETCORE_PROCESS_PATH can't be used with an app whose Web.config has an <aspNetCore> section")
+	}
+	return nil
+}
+
+func (a *AspNetCore) applicationHost() *apphost.AspNetCore {
+	return &apphost.AspNetCore{
+		ProcessPath:  a.ProcessPath,
+		Arguments:    a.Arguments,
+		HostingModel: a.HostingModel,
+	}
+}
@@ -0,0 +1,120 @@
+package hwcconfig_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"code.cloudfoundry.org/hwc/apphost"
+)
+
+var _ = Describe("AspNetCore", func() {
+	app := newTestApp()
+
+	var programFiles string
+
+	var installModule = func() {
+		modulePath := filepath.Join(programFiles, "IIS", "Asp.Net Core Module", "V2")
+		Expect(os.MkdirAll(modulePath, 0700)).To(Succeed())
+		Expect(ioutil.WriteFile(filepath.Join(modulePath, "aspnetcorev2.dll"), nil, 0600)).To(Succeed())
+	}
+
+	BeforeEach(func() {
+		programFiles = filepath.Join(app.workingDirectoryPath, "Program Files")
+		app.env["ProgramFiles"] = programFiles
+	})
+
+	Context("when the module isn't installed", func() {
+		It("doesn't load it", func() {
+			config := app.render()
+			Expect(globalModuleNames(config)).ToNot(ContainElement("AspNetCoreModuleV2"))
+			Expect(config.ConfigSections.Group("system.webServer").Entries).ToNot(ContainElement(apphost.Section("aspNetCore", "Allow", "")))
+		})
+
+		It("fails when the app's Web.config configures it", func() {
+			app.writeWebConfig(`<configuration><location path="." inheritInChildApplications="false"><system.webServer><handlers /><aspNetCore processPath="dotnet" arguments=".\app.dll" /></system.webServer></location></configuration>`)
+			err, _ := app.newConfig()
+			Expect(err).To(MatchError(HavePrefix("The app's Web.config configures the ASP.NET Core Module, which isn't installed")))
+		})
+
+		Context("when HWC_ASPNETCORE_PROCESS_PATH is set", func() {
+			BeforeEach(func() {
+				app.env["HWC_ASPNETCORE_PROCESS_PATH"] = `.\app.exe`
+			})
+
+			It("fails", func() {
+				err, _ := app.newConfig()
+				Expect(err).To(MatchError(HavePrefix("HWC_ASPNETCORE_PROCESS_PATH requires the ASP.NET Core Module")))
+			})
+		})
+	})
+
+	Context("when the module is installed", func() {
+		BeforeEach(installModule)
+
+		It("loads it and registers its section for the app's Web.config", func() {
+			config := app.render()
+			Expect(config.SystemWebServer.GlobalModules).To(ContainElement(apphost.GlobalModule{Name: "AspNetCoreModuleV2", Image: `%ProgramFiles%\IIS\Asp.Net Core Module\V2\aspnetcorev2.dll`}))
+			Expect(config.SystemWebServer.Modules).To(ContainElement(apphost.Module{Name: "AspNetCoreModuleV2"}))
+			Expect(config.ConfigSections.Group("system.webServer").Entries).To(ContainElement(apphost.Section("aspNetCore", "Allow", "")))
+			Expect(config.SystemWebServer.AspNetCore).To(BeNil())
+			Expect(handlerNames(config)).ToNot(ContainElement("aspNetCore"))
+		})
+
+		Context("when HWC_PROFILE is static", func() {
+			BeforeEach(func() {
+				app.env["HWC_PROFILE"] = "static"
+			})
+
+			It("still loads it", func() {
+				config := app.render()
+				Expect(globalModuleNames(config)).To(ContainElement("AspNetCoreModuleV2"))
+				Expect(globalModuleNames(config)).ToNot(ContainElement("IsapiModule"))
+			})
+		})
+
+		Context("when HWC_ASPNETCORE_PROCESS_PATH is set", func() {
+			BeforeEach(func() {
+				app.env["HWC_ASPNETCORE_PROCESS_PATH"] = "dotnet"
+				app.env["HWC_ASPNETCORE_ARGUMENTS"] = `.\app.dll`
+			})
+
+			It("hosts the app in-process ahead of the other handlers", func() {
+				config := app.render()
+				Expect(config.SystemWebServer.AspNetCore).To(Equal(&apphost.AspNetCore{ProcessPath: "dotnet", Arguments: `.\app.dll`, HostingModel: "inprocess"}))
+				Expect(config.SystemWebServer.Handlers.Handlers[0]).To(Equal(apphost.Handler{Name: "aspNetCore", Path: "*", Verb: "*", Modules: "AspNetCoreModuleV2", ResourceType: "Unspecified"}))
+			})
+
+			Context("when HWC_ASPNETCORE_HOSTING_MODEL is OutOfProcess", func() {
+				BeforeEach(func() {
+					app.env["HWC_ASPNETCORE_HOSTING_MODEL"] = "OutOfProcess"
+				})
+
+				It("hosts the app out-of-process", func() {
+					config := app.render()
+					Expect(config.SystemWebServer.AspNetCore.HostingModel).To(Equal("outofprocess"))
+				})
+			})
+
+			It("fails when the app's Web.config configures the module too", func() {
+				app.writeWebConfig(`<configuration><system.webServer><aspNetCore processPath="dotnet" /></system.webServer></configuration>`)
+				err, _ := app.newConfig()
+				Expect(err).To(MatchError("HWC_ASPNETCORE_PROCESS_PATH can't be used with an app whose Web.config has an <aspNetCore> section"))
+			})
+		})
+	})
+
+	Context("when HWC_ASPNETCORE_HOSTING_MODEL is unknown", func() {
+		BeforeEach(func() {
+			app.env["HWC_ASPNETCORE_HOSTING_MODEL"] = "sideways"
+		})
+
+		It("fails", func() {
+			err, _ := app.newConfig()
+			Expect(err).To(MatchError("Invalid ASP.NET Core hosting model: sideways (expected one of inprocess, outofprocess)"))
+		})
+	})
+})
@@ -0,0 +1,201 @@
+package hwcconfig
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"code.cloudfoundry.org/hwc/apphost"
+)
+
+// compressionScheme is a scheme hwc can render, and where its DLL is found.
+// The DLLs IIS ships are found through windir, like the required ones; the
+// others under env.
+type compressionScheme struct {
+	dll  string
+	env  string
+	path []string
+}
+
+// installedPath is the DLL on this machine.
+func (s compressionScheme) installedPath() string {
+	if s.env == "" {
+		return imagePath(s.dll)
+	}
+	return filepath.Join(append([]string{os.Getenv(s.env)}, s.path...)...)
+}
+
+// compressionSchemes are the schemes hwc knows. IIS's gzip.dll implements
+// both gzip and deflate; Brotli comes from the IIS Compression package.
+var compressionSchemes = map[string]compressionScheme{
+	"gzip":    {dll: `%Windir%\system32\inetsrv\gzip.dll`},
+	"deflate": {dll: `%Windir%\system32\inetsrv\gzip.dll`},
+	"br":      {dll: `%ProgramFiles%\IIS\IIS Compression\iisbrotli.dll`, env: "ProgramFiles", path: []string{"IIS", "IIS Compression", "iisbrotli.dll"}},
+}
+
+const (
+	defaultMinFileSizeForComp               = 2700
+	defaultStaticCompressionDisableCpuUsage = 100
+)
+
+// Compression configures <httpCompression>, which apps can't change from
+// their Web.config beyond the MIME types.
+type Compression struct {
+	// Schemes are offered in order, so the first one the client accepts
+	// wins. gzip is always rendered when listed; the others only when their
+	// DLL is installed. If none of them is, gzip is offered instead.
+	Schemes      []string `json:"schemes"`
+	StaticLevel  uint64   `json:"staticLevel"`
+	DynamicLevel uint64   `json:"dynamicLevel"`
+	// MinFileSizeForComp is the smallest static file, in bytes, IIS
+	// compresses.
+	MinFileSizeForComp uint64 `json:"minFileSizeForComp"`
+	// StaticCompressionDisableCpuUsage is the CPU percentage above which IIS
+	// stops compressing static files.
+	StaticCompressionDisableCpuUsage uint64 `json:"staticCompressionDisableCpuUsage"`
+	// StaticTypes and DynamicTypes are the MIME types IIS compresses. Every
+	// other type is left alone.
+	StaticTypes  []string `json:"staticTypes"`
+	DynamicTypes []string `json:"dynamicTypes"`
+}
+
+func DefaultCompression() Compression {
+	return Compression{
+		Schemes:                          []string{"gzip"},
+		StaticLevel:                      9,
+		DynamicLevel:                     4,
+		MinFileSizeForComp:               defaultMinFileSizeForComp,
+		StaticCompressionDisableCpuUsage: defaultStaticCompressionDisableCpuUsage,
+		StaticTypes: []string{
+			"text/*",
+			"message/*",
+			"application/x-javascript",
+			"application/javascript",
+			"application/atom+xml",
+			"application/xaml+xml",
+			"application/json",
+			"image/svg+xml",
+			"application/wasm",
+		},
+		DynamicTypes: []string{
+			"text/*",
+			"message/*",
+			"application/x-javascript",
+			"application/javascript",
+			"application/json",
+		},
+	}
+}
+
+func (c *Compression) loadEnv() error {
+	envList("HWC_COMPRESSION_SCHEMES", &c.Schemes)
+	if err := envUint("HWC_COMPRESSION_STATIC_LEVEL", &c.StaticLevel); err != nil {
+		return err
+	}
+	if err := envUint("HWC_COMPRESSION_DYNAMIC_LEVEL", &c.DynamicLevel); err != nil {
+		return err
+	}
+	if err := envUint("HWC_COMPRESSION_MIN_FILE_SIZE", &c.MinFileSizeForComp); err != nil {
+		return err
+	}
+	if err := envUint("HWC_COMPRESSION_STATIC_DISABLE_CPU_USAGE", &c.StaticCompressionDisableCpuUsage); err != nil {
+		return err
+	}
+	envList("HWC_COMPRESSION_STATIC_TYPES", &c.StaticTypes)
+	envList("HWC_COMPRESSION_DYNAMIC_TYPES", &c.DynamicTypes)
+	return c.validate()
+}
+
+func (c *Compression) validate() error {
+	if len(c.Schemes) == 0 {
+		return fmt.Errorf("Invalid compression schemes: at least one is required")
+	}
+	seen := map[string]bool{}
+	for i, scheme := range c.Schemes {
+		scheme = strings.ToLower(scheme)
+		if err := oneOf("compression scheme", scheme, "gzip", "deflate", "br"); err != nil {
+			return err
+		}
+		if seen[scheme] {
+			return fmt.Errorf("Invalid compression scheme: %s is listed twice", scheme)
+		}
+		seen[scheme] = true
+		c.Schemes[i] = scheme
+	}
+	if c.StaticLevel > 10 {
+		return fmt.Errorf("Invalid static compression level: %d (expected 0 to 10)", c.StaticLevel)
+	}
+	if c.DynamicLevel > 10 {
+		return fmt.Errorf("Invalid dynamic compression level: %d (expected 0 to 10)", c.DynamicLevel)
+	}
+	if c.StaticCompressionDisableCpuUsage == 0 || c.StaticCompressionDisableCpuUsage > 100 {
+		return fmt.Errorf("Invalid static compression CPU usage limit: %d (expected 1 to 100)", c.StaticCompressionDisableCpuUsage)
+	}
+	for _, types := range [][]string{c.StaticTypes, c.DynamicTypes} {
+		listed := map[string]bool{}
+		for _, mimeType := range types {
+			// */* is always rendered last, disabled, so nothing else is
+			// compressed.
+			if !strings.Contains(mimeType, "/") || mimeType == "*/*" {
+				return fmt.Errorf("Invalid compression MIME type: %s", mimeType)
+			}
+			key := strings.ToLower(mimeType)
+			if listed[key] {
+				return fmt.Errorf("Invalid compression MIME type: %s is listed twice", mimeType)
+			}
+			listed[key] = true
+		}
+	}
+	return nil
+}
+
+// offered returns the schemes to render, in order, leaving out those that
+// aren't installed, and whether it fell back to gzip because none of them
+// is. IIS ships gzip.dll, so it's always there.
+func (c *Compression) offered(installed map[string]bool) ([]string, bool) {
+	var schemes []string
+	for _, name := range c.Schemes {
+		if name == "gzip" || installed[name] {
+			schemes = append(schemes, name)
+		}
+	}
+	if len(schemes) == 0 {
+		return []string{"gzip"}, true
+	}
+	return schemes, false
+}
+
+// applicationHost renders the offered schemes. The attributes IIS defaults
+// are left out unless they were changed.
+func (c *Compression) applicationHost(directory string, installed map[string]bool) apphost.HTTPCompression {
+	compression := apphost.HTTPCompression{Directory: directory}
+	if c.MinFileSizeForComp != defaultMinFileSizeForComp {
+		size := c.MinFileSizeForComp
+		compression.MinFileSizeForComp = &size
+	}
+	if c.StaticCompressionDisableCpuUsage != defaultStaticCompressionDisableCpuUsage {
+		usage := c.StaticCompressionDisableCpuUsage
+		compression.StaticCompressionDisableCpuUsage = &usage
+	}
+	schemes, _ := c.offered(installed)
+	for _, name := range schemes {
+		compression.Schemes = append(compression.Schemes, apphost.CompressionScheme{
+			Name:                    name,
+			DLL:                     compressionSchemes[name].dll,
+			DynamicCompressionLevel: int(c.DynamicLevel),
+			StaticCompressionLevel:  int(c.StaticLevel),
+		})
+	}
+	compression.StaticTypes = compressionTypes(c.StaticTypes)
+	compression.DynamicTypes = compressionTypes(c.DynamicTypes)
+	return compression
+}
+
+func compressionTypes(mimeTypes []string) []apphost.CompressionType {
+	var types []apphost.CompressionType
+	for _, mimeType := range mimeTypes {
+		types = append(types, apphost.CompressionType{MimeType: mimeType, Enabled: true})
+	}
+	return append(types, apphost.CompressionType{MimeType: "*/*", Enabled: false})
+}
@@ -0,0 +1,189 @@
+package hwcconfig_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gbytes"
+
+	"code.cloudfoundry.org/hwc/apphost"
+	"code.cloudfoundry.org/hwc/hwclog"
+)
+
+var _ = Describe("Compression", func() {
+	app := newTestApp()
+
+	var schemeNames = func(config *apphost.Configuration) []string {
+		var names []string
+		for _, scheme := range config.SystemWebServer.HTTPCompression.Schemes {
+			names = append(names, scheme.Name)
+		}
+		return names
+	}
+
+	var mimeTypes = func(types []apphost.CompressionType) []string {
+		var names []string
+		for _, t := range types {
+			if t.Enabled {
+				names = append(names, t.MimeType)
+			}
+		}
+		return names
+	}
+
+	var install = func(path ...string) {
+		dll := filepath.Join(append([]string{app.workingDirectoryPath}, path...)...)
+		Expect(os.MkdirAll(filepath.Dir(dll), 0700)).To(Succeed())
+		Expect(ioutil.WriteFile(dll, nil, 0600)).To(Succeed())
+	}
+
+	BeforeEach(func() {
+		app.env["windir"] = fakeWindir(app.workingDirectoryPath, app.render())
+		app.env["ProgramFiles"] = filepath.Join(app.workingDirectoryPath, "Program Files")
+	})
+
+	It("offers gzip with the IIS defaults and compresses JSON, SVG and WebAssembly", func() {
+		compression := app.render().SystemWebServer.HTTPCompression
+		Expect(compression.Schemes).To(Equal([]apphost.CompressionScheme{
+			{Name: "gzip", DLL: `%Windir%\system32\inetsrv\gzip.dll`, DynamicCompressionLevel: 4, StaticCompressionLevel: 9},
+		}))
+		Expect(compression.MinFileSizeForComp).To(BeNil())
+		Expect(compression.StaticCompressionDisableCpuUsage).To(BeNil())
+		Expect(mimeTypes(compression.StaticTypes)).To(ContainElement("application/json"))
+		Expect(mimeTypes(compression.StaticTypes)).To(ContainElement("image/svg+xml"))
+		Expect(mimeTypes(compression.StaticTypes)).To(ContainElement("application/wasm"))
+		Expect(mimeTypes(compression.DynamicTypes)).To(ContainElement("application/json"))
+		Expect(compression.StaticTypes[len(compression.StaticTypes)-1]).To(Equal(apphost.CompressionType{MimeType: "*/*", Enabled: false}))
+		Expect(compression.DynamicTypes[len(compression.DynamicTypes)-1]).To(Equal(apphost.CompressionType{MimeType: "*/*", Enabled: false}))
+	})
+
+	Context("when the levels and limits are changed", func() {
+		BeforeEach(func() {
+			app.env["HWC_COMPRESSION_STATIC_LEVEL"] = "10"
+			app.env["HWC_COMPRESSION_DYNAMIC_LEVEL"] = "0"
+			app.env["HWC_COMPRESSION_MIN_FILE_SIZE"] = "1024"
+			app.env["HWC_COMPRESSION_STATIC_DISABLE_CPU_USAGE"] = "80"
+		})
+
+		It("renders them", func() {
+			compression := app.render().SystemWebServer.HTTPCompression
+			Expect(compression.Schemes[0].StaticCompressionLevel).To(Equal(10))
+			Expect(compression.Schemes[0].DynamicCompressionLevel).To(Equal(0))
+			Expect(*compression.MinFileSizeForComp).To(Equal(uint64(1024)))
+			Expect(*compression.StaticCompressionDisableCpuUsage).To(Equal(uint64(80)))
+		})
+	})
+
+	Context("when HWC_COMPRESSION_SCHEMES lists Brotli and deflate", func() {
+		BeforeEach(func() {
+			app.env["HWC_COMPRESSION_SCHEMES"] = "br, gzip, deflate"
+		})
+
+		It("leaves out the schemes that aren't installed", func() {
+			Expect(schemeNames(app.render())).To(Equal([]string{"gzip"}))
+		})
+
+		Context("when their DLLs are installed", func() {
+			BeforeEach(func() {
+				install("Program Files", "IIS", "IIS Compression", "iisbrotli.dll")
+				app.env["windir"] = fakeWindir(app.workingDirectoryPath, app.render(), `%windir%\system32\inetsrv\gzip.dll`)
+			})
+
+			It("offers them in order", func() {
+				config := app.render()
+				Expect(schemeNames(config)).To(Equal([]string{"br", "gzip", "deflate"}))
+				Expect(config.SystemWebServer.HTTPCompression.Schemes[0].DLL).To(Equal(`%ProgramFiles%\IIS\IIS Compression\iisbrotli.dll`))
+				Expect(config.SystemWebServer.HTTPCompression.Schemes[2].DLL).To(Equal(`%Windir%\system32\inetsrv\gzip.dll`))
+			})
+		})
+	})
+
+	Context("when none of the schemes in HWC_COMPRESSION_SCHEMES is installed", func() {
+		var (
+			log      *gbytes.Buffer
+			previous *hwclog.Logger
+		)
+
+		BeforeEach(func() {
+			log = gbytes.NewBuffer()
+			previous = hwclog.Default()
+			logger, err := hwclog.New("text", log, log)
+			Expect(err).ToNot(HaveOccurred())
+			hwclog.SetDefault(logger)
+			app.env["HWC_COMPRESSION_SCHEMES"] = "br"
+		})
+
+		AfterEach(func() {
+			hwclog.SetDefault(previous)
+		})
+
+		It("falls back to gzip with a warning", func() {
+			config := app.render()
+			Expect(schemeNames(config)).To(Equal([]string{"gzip"}))
+			Expect(config.SystemWebServer.HTTPCompression.Schemes[0].DLL).To(Equal(`%Windir%\system32\inetsrv\gzip.dll`))
+			Expect(log).To(gbytes.Say("Compression scheme br isn't installed"))
+			Expect(log).To(gbytes.Say("None of the compression schemes is installed, so gzip is offered instead"))
+		})
+	})
+
+	Context("when HWC_CONFIG_FILE sets the MIME types", func() {
+		BeforeEach(func() {
+			configFile := filepath.Join(app.workingDirectoryPath, "hwc.json")
+			Expect(ioutil.WriteFile(configFile, []byte(`{"compression": {"staticTypes": ["text/css", "application/json"], "dynamicTypes": ["application/json"]}}`), 0666)).To(Succeed())
+			app.env["HWC_CONFIG_FILE"] = configFile
+		})
+
+		It("replaces the defaults", func() {
+			compression := app.render().SystemWebServer.HTTPCompression
+			Expect(mimeTypes(compression.StaticTypes)).To(Equal([]string{"text/css", "application/json"}))
+			Expect(mimeTypes(compression.DynamicTypes)).To(Equal([]string{"application/json"}))
+		})
+	})
+
+	Context("when a scheme is unknown", func() {
+		BeforeEach(func() {
+			app.env["HWC_COMPRESSION_SCHEMES"] = "gzip,zstd"
+		})
+
+		It("fails", func() {
+			err, _ := app.newConfig()
+			Expect(err).To(MatchError("Invalid compression scheme: zstd (expected one of gzip, deflate, br)"))
+		})
+	})
+
+	Context("when a level is out of range", func() {
+		BeforeEach(func() {
+			app.env["HWC_COMPRESSION_STATIC_LEVEL"] = "11"
+		})
+
+		It("fails", func() {
+			err, _ := app.newConfig()
+			Expect(err).To(MatchError("Invalid static compression level: 11 (expected 0 to 10)"))
+		})
+	})
+
+	Context("when the CPU usage limit is 0", func() {
+		BeforeEach(func() {
+			app.env["HWC_COMPRESSION_STATIC_DISABLE_CPU_USAGE"] = "0"
+		})
+
+		It("fails", func() {
+			err, _ := app.newConfig()
+			Expect(err).To(MatchError("Invalid static compression CPU usage limit: 0 (expected 1 to 100)"))
+		})
+	})
+
+	Context("when a MIME type would compress everything", func() {
+		BeforeEach(func() {
+			app.env["HWC_COMPRESSION_DYNAMIC_TYPES"] = "*/*"
+		})
+
+		It("fails", func() {
+			err, _ := app.newConfig()
+			Expect(err).To(MatchError("Invalid compression MIME type: */*"))
+		})
+	})
+})
@@ -0,0 +1,29 @@
+package hwcconfig
+
+import "os"
+
+// EnvSubstitution controls whether hwc expands ${VAR} placeholders in the
+// app's Web.config from the container environment before IIS loads it. In
+// strict mode a placeholder for an unset variable stops hwc from starting.
+type EnvSubstitution struct {
+	Mode string `json:"mode"`
+}
+
+func DefaultEnvSubstitution() EnvSubstitution {
+	return EnvSubstitution{Mode: "off"}
+}
+
+func (es EnvSubstitution) Enabled() bool {
+	return es.Mode != "off"
+}
+
+func (es EnvSubstitution) Strict() bool {
+	return es.Mode == "strict"
+}
+
+func (es *EnvSubstitution) loadEnv() error {
+	if s := os.Getenv("HWC_WEB_CONFIG_ENV_SUBSTITUTION"); s != "" {
+		es.Mode = s
+	}
+	return oneOf("envSubstitution mode", es.Mode, "off", "on", "strict")
+}
@@ -0,0 +1,40 @@
+package hwcconfig_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("EnvSubstitution", func() {
+	app := newTestApp()
+
+	It("is off by default", func() {
+		err, hwcConfig := app.newConfig()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(hwcConfig.EnvSubstitution.Enabled()).To(BeFalse())
+	})
+
+	Context("when HWC_WEB_CONFIG_ENV_SUBSTITUTION is strict", func() {
+		BeforeEach(func() {
+			app.env["HWC_WEB_CONFIG_ENV_SUBSTITUTION"] = "strict"
+		})
+
+		It("enables strict substitution", func() {
+			err, hwcConfig := app.newConfig()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(hwcConfig.EnvSubstitution.Enabled()).To(BeTrue())
+			Expect(hwcConfig.EnvSubstitution.Strict()).To(BeTrue())
+		})
+	})
+
+	Context("when the mode is unknown", func() {
+		BeforeEach(func() {
+			app.env["HWC_WEB_CONFIG_ENV_SUBSTITUTION"] = "true"
+		})
+
+		It("fails", func() {
+			err, _ := app.newConfig()
+			Expect(err).To(MatchError("Invalid envSubstitution mode: true (expected one of off, on, strict)"))
+		})
+	})
+})
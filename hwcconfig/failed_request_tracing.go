@@ -0,0 +1,114 @@
+package hwcconfig
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"time"
+)
+
+// FailedRequestTracing controls IIS Failed Request Tracing. When enabled, IIS
+// writes a trace file for every request matching Path that fails with one of
+// StatusCodes or runs for longer than TimeTaken. Summarize asks hwc to print a
+// one line summary of each trace to stdout.
+type FailedRequestTracing struct {
+	Enabled bool `json:"enabled"`
+	// StatusCodes is nil unless it was set. FailureStatusCodes picks the
+	// default then.
+	StatusCodes *string `json:"statusCodes"`
+	TimeTaken   string  `json:"timeTaken"`
+	Path        string  `json:"path"`
+	MaxLogFiles uint64  `json:"maxLogFiles"`
+	Summarize   bool    `json:"summarize"`
+}
+
+// defaultFailureStatusCodes are the server errors. IIS traces a request that
+// matches either the status codes or timeTaken, so anything that includes 200
+// would trace every request.
+const defaultFailureStatusCodes = "500-999"
+
+var statusCodePattern = regexp.MustCompile(`^\d{3}(\.\d+)?(-\d{3}(\.\d+)?)?$`)
+
+func DefaultFailedRequestTracing() FailedRequestTracing {
+	return FailedRequestTracing{
+		Path:        "*",
+		MaxLogFiles: 50,
+	}
+}
+
+// FailureStatusCodes is the statusCodes attribute value. Unless StatusCodes
+// was set, it's the server errors when no TimeTaken threshold is configured,
+// and empty otherwise, so only slow requests are traced.
+func (frt FailedRequestTracing) FailureStatusCodes() string {
+	if frt.StatusCodes != nil {
+		return *frt.StatusCodes
+	}
+	if frt.TimeTaken != "" {
+		return ""
+	}
+	return defaultFailureStatusCodes
+}
+
+// TimeTakenSpan is the timeTaken attribute value for TimeTaken, or an empty
+// string when no threshold is configured.
+func (frt FailedRequestTracing) TimeTakenSpan() string {
+	d, err := time.ParseDuration(frt.TimeTaken)
+	if err != nil || d <= 0 {
+		return ""
+	}
+	return timeSpan(d)
+}
+
+func (frt *FailedRequestTracing) loadEnv() error {
+	if err := envBool("HWC_FAILED_REQUEST_TRACING", &frt.Enabled); err != nil {
+		return err
+	}
+	if s, ok := os.LookupEnv("HWC_FAILED_REQUEST_TRACING_STATUS_CODES"); ok {
+		frt.StatusCodes = &s
+	}
+	if s, ok := os.LookupEnv("HWC_FAILED_REQUEST_TRACING_TIME_TAKEN"); ok {
+		frt.TimeTaken = s
+	}
+	if s := os.Getenv("HWC_FAILED_REQUEST_TRACING_PATH"); s != "" {
+		frt.Path = s
+	}
+	if err := envUint("HWC_FAILED_REQUEST_TRACING_MAX_LOG_FILES", &frt.MaxLogFiles); err != nil {
+		return err
+	}
+	if err := envBool("HWC_FAILED_REQUEST_TRACING_SUMMARY", &frt.Summarize); err != nil {
+		return err
+	}
+	return frt.validate()
+}
+
+func (frt *FailedRequestTracing) validate() error {
+	statusCodes := frt.FailureStatusCodes()
+	for _, codes := range splitList(statusCodes) {
+		if !statusCodePattern.MatchString(codes) {
+			return fmt.Errorf("Invalid failedRequestTracing statusCodes: %s", statusCodes)
+		}
+	}
+	if frt.TimeTaken != "" {
+		d, err := time.ParseDuration(frt.TimeTaken)
+		if err != nil || d < time.Second {
+			return fmt.Errorf("Invalid failedRequestTracing timeTaken: %s (expected a duration of at least 1s)", frt.TimeTaken)
+		}
+	}
+	if frt.Enabled && len(splitList(statusCodes)) == 0 && frt.TimeTaken == "" {
+		return fmt.Errorf("Invalid failedRequestTracing: statusCodes or timeTaken is required")
+	}
+	if frt.Path == "" {
+		return fmt.Errorf("Invalid failedRequestTracing path: path is required")
+	}
+	if frt.MaxLogFiles == 0 {
+		return fmt.Errorf("Invalid failedRequestTracing maxLogFiles: %d (must be at least 1)", frt.MaxLogFiles)
+	}
+	return nil
+}
+
+// SiteFailedRequestLogDirectory is where IIS writes the failed request traces
+// for the site.
+func (c *HwcConfig) SiteFailedRequestLogDirectory() string {
+	return filepath.Join(c.FailedRequestLogDirectory, fmt.Sprintf("W3SVC%d", c.Port))
+}
@@ -0,0 +1,168 @@
+package hwcconfig_test
+
+import (
+	"encoding/xml"
+	"io/ioutil"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"code.cloudfoundry.org/hwc/hwcconfig"
+)
+
+type failedRequestTracingConfiguration struct {
+	SystemApplicationHost struct {
+		Sites struct {
+			SiteDefaults struct {
+				TraceFailedRequestsLogging struct {
+					Enabled     string `xml:"enabled,attr"`
+					Directory   string `xml:"directory,attr"`
+					MaxLogFiles string `xml:"maxLogFiles,attr"`
+				} `xml:"traceFailedRequestsLogging"`
+			} `xml:"siteDefaults"`
+		} `xml:"sites"`
+	} `xml:"system.applicationHost"`
+	SystemWebServer struct {
+		TraceFailedRequests []struct {
+			Path               string `xml:"path,attr"`
+			FailureDefinitions struct {
+				StatusCodes *string `xml:"statusCodes,attr"`
+				TimeTaken   *string `xml:"timeTaken,attr"`
+			} `xml:"failureDefinitions"`
+		} `xml:"tracing>traceFailedRequests>add"`
+	} `xml:"system.webServer"`
+}
+
+var _ = Describe("FailedRequestTracing", func() {
+	app := newTestApp()
+
+	var renderFailedRequestTracing = func() (*hwcconfig.HwcConfig, failedRequestTracingConfiguration) {
+		err, hwcConfig := app.newConfig()
+		Expect(err).ToNot(HaveOccurred())
+
+		configFileContents, err := ioutil.ReadFile(hwcConfig.ApplicationHostConfigPath)
+		Expect(err).ToNot(HaveOccurred())
+
+		var config failedRequestTracingConfiguration
+		Expect(xml.Unmarshal(configFileContents, &config)).To(Succeed())
+		return hwcConfig, config
+	}
+
+	It("disables failed request tracing by default", func() {
+		hwcConfig, config := renderFailedRequestTracing()
+
+		logging := config.SystemApplicationHost.Sites.SiteDefaults.TraceFailedRequestsLogging
+		Expect(logging.Enabled).To(Equal("false"))
+		Expect(logging.Directory).To(Equal(filepath.Join(app.workingDirectoryPath, "tmpPath", "FailedReqLogFiles")))
+		Expect(logging.MaxLogFiles).To(Equal("50"))
+		Expect(hwcConfig.SiteFailedRequestLogDirectory()).To(Equal(filepath.Join(app.workingDirectoryPath, "tmpPath", "FailedReqLogFiles", "W3SVC8080")))
+
+		rules := config.SystemWebServer.TraceFailedRequests
+		Expect(rules).To(HaveLen(1))
+		Expect(rules[0].Path).To(Equal("*"))
+		Expect(*rules[0].FailureDefinitions.StatusCodes).To(Equal("500-999"))
+		Expect(rules[0].FailureDefinitions.TimeTaken).To(BeNil())
+	})
+
+	Context("when failed request tracing is enabled", func() {
+		BeforeEach(func() {
+			app.env["HWC_FAILED_REQUEST_TRACING"] = "true"
+		})
+
+		It("traces server errors, but not a fast 200", func() {
+			_, config := renderFailedRequestTracing()
+
+			definitions := config.SystemWebServer.TraceFailedRequests[0].FailureDefinitions
+			Expect(*definitions.StatusCodes).To(Equal("500-999"))
+			Expect(definitions.TimeTaken).To(BeNil())
+		})
+
+		Context("when only a time taken threshold is set", func() {
+			BeforeEach(func() {
+				app.env["HWC_FAILED_REQUEST_TRACING_TIME_TAKEN"] = "5s"
+			})
+
+			It("traces slow requests, but not a fast 200", func() {
+				_, config := renderFailedRequestTracing()
+
+				definitions := config.SystemWebServer.TraceFailedRequests[0].FailureDefinitions
+				Expect(definitions.StatusCodes).To(BeNil())
+				Expect(*definitions.TimeTaken).To(Equal("00:00:05"))
+			})
+		})
+
+		Context("when the status codes are set too", func() {
+			BeforeEach(func() {
+				app.env["HWC_FAILED_REQUEST_TRACING_STATUS_CODES"] = "404.2,500-599"
+				app.env["HWC_FAILED_REQUEST_TRACING_TIME_TAKEN"] = "1m30s"
+				app.env["HWC_FAILED_REQUEST_TRACING_PATH"] = "*.aspx"
+				app.env["HWC_FAILED_REQUEST_TRACING_MAX_LOG_FILES"] = "10"
+				app.env["HWC_FAILED_REQUEST_TRACING_SUMMARY"] = "true"
+			})
+
+			It("renders the filters from the environment", func() {
+				hwcConfig, config := renderFailedRequestTracing()
+				Expect(hwcConfig.FailedRequestTracing.Summarize).To(BeTrue())
+
+				logging := config.SystemApplicationHost.Sites.SiteDefaults.TraceFailedRequestsLogging
+				Expect(logging.Enabled).To(Equal("true"))
+				Expect(logging.MaxLogFiles).To(Equal("10"))
+
+				rules := config.SystemWebServer.TraceFailedRequests
+				Expect(rules[0].Path).To(Equal("*.aspx"))
+				Expect(*rules[0].FailureDefinitions.StatusCodes).To(Equal("404.2,500-599"))
+				Expect(*rules[0].FailureDefinitions.TimeTaken).To(Equal("00:01:30"))
+			})
+		})
+
+		Context("when HWC_CONFIG_FILE sets the status codes", func() {
+			BeforeEach(func() {
+				configFile := filepath.Join(app.workingDirectoryPath, "hwc.json")
+				Expect(ioutil.WriteFile(configFile, []byte(`{"failedRequestTracing": {"statusCodes": "404", "timeTaken": "5s"}}`), 0666)).To(Succeed())
+				app.env["HWC_CONFIG_FILE"] = configFile
+			})
+
+			It("renders them alongside the time taken threshold", func() {
+				_, config := renderFailedRequestTracing()
+
+				definitions := config.SystemWebServer.TraceFailedRequests[0].FailureDefinitions
+				Expect(*definitions.StatusCodes).To(Equal("404"))
+				Expect(*definitions.TimeTaken).To(Equal("00:00:05"))
+			})
+		})
+
+		Context("when the status codes are cleared without a time taken threshold", func() {
+			BeforeEach(func() {
+				app.env["HWC_FAILED_REQUEST_TRACING_STATUS_CODES"] = ""
+			})
+
+			It("fails", func() {
+				err, _ := app.newConfig()
+				Expect(err).To(MatchError("Invalid failedRequestTracing: statusCodes or timeTaken is required"))
+			})
+		})
+	})
+
+	Context("when the status codes are invalid", func() {
+		BeforeEach(func() {
+			app.env["HWC_FAILED_REQUEST_TRACING_STATUS_CODES"] = "5xx"
+		})
+
+		It("fails", func() {
+			err, _ := app.newConfig()
+			Expect(err).To(MatchError("Invalid failedRequestTracing statusCodes: 5xx"))
+		})
+	})
+
+	Context("when the time taken threshold is too short", func() {
+		BeforeEach(func() {
+			app.env["HWC_FAILED_REQUEST_TRACING_TIME_TAKEN"] = "500ms"
+		})
+
+		It("fails", func() {
+			err, _ := app.newConfig()
+			Expect(err).To(MatchError("Invalid failedRequestTracing timeTaken: 500ms (expected a duration of at least 1s)"))
+		})
+	})
+})
@@ -0,0 +1,208 @@
+package hwcconfig
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"code.cloudfoundry.org/hwc/apphost"
+)
+
+const fastCGIEnvPrefix = "HWC_FASTCGI_ENV_"
+
+// fastCGIImage is the FastCGI module, which is only loaded, and only
+// required, when applications are defined.
+const fastCGIImage = `%windir%\System32\inetsrv\iisfcgi.dll`
+
+// FastCGI defines the FastCGI applications IIS starts, such as php-cgi.exe,
+// and the file extensions it hands to them.
+type FastCGI struct {
+	Applications []FastCGIApplication `json:"applications"`
+}
+
+type FastCGIApplication struct {
+	// FullPath is the executable, relative to the app's root unless it is
+	// absolute.
+	FullPath             string            `json:"fullPath"`
+	Arguments            string            `json:"arguments"`
+	Extensions           []string          `json:"extensions"`
+	EnvironmentVariables map[string]string `json:"environmentVariables"`
+	MaxInstances         uint64            `json:"maxInstances"`
+	InstanceMaxRequests  uint64            `json:"instanceMaxRequests"`
+	ActivityTimeout      Duration          `json:"activityTimeout"`
+	IdleTimeout          Duration          `json:"idleTimeout"`
+	RequestTimeout       Duration          `json:"requestTimeout"`
+}
+
+func (f *FastCGI) Enabled() bool {
+	return len(f.Applications) > 0
+}
+
+// loadEnv defines a single application when HWC_FASTCGI_FULL_PATH is set,
+// replacing any from HWC_CONFIG_FILE.
+func (f *FastCGI) loadEnv() error {
+	fullPath := os.Getenv("HWC_FASTCGI_FULL_PATH")
+	if fullPath == "" {
+		return f.validate()
+	}
+
+	app := FastCGIApplication{FullPath: fullPath, Arguments: os.Getenv("HWC_FASTCGI_ARGUMENTS")}
+	envList("HWC_FASTCGI_EXTENSIONS", &app.Extensions)
+	for _, entry := range os.Environ() {
+		if parts := strings.SplitN(entry, "=", 2); strings.HasPrefix(parts[0], fastCGIEnvPrefix) {
+			if app.EnvironmentVariables == nil {
+				app.EnvironmentVariables = map[string]string{}
+			}
+			app.EnvironmentVariables[strings.TrimPrefix(parts[0], fastCGIEnvPrefix)] = parts[1]
+		}
+	}
+	if err := envUint("HWC_FASTCGI_MAX_INSTANCES", &app.MaxInstances); err != nil {
+		return err
+	}
+	if err := envUint("HWC_FASTCGI_INSTANCE_MAX_REQUESTS", &app.InstanceMaxRequests); err != nil {
+		return err
+	}
+	if err := envDuration("HWC_FASTCGI_ACTIVITY_TIMEOUT", &app.ActivityTimeout); err != nil {
+		return err
+	}
+	if err := envDuration("HWC_FASTCGI_IDLE_TIMEOUT", &app.IdleTimeout); err != nil {
+		return err
+	}
+	if err := envDuration("HWC_FASTCGI_REQUEST_TIMEOUT", &app.RequestTimeout); err != nil {
+		return err
+	}
+	f.Applications = []FastCGIApplication{app}
+	return f.validate()
+}
+
+// fastCGITimeouts are the ranges IIS accepts for each timeout, in seconds.
+var fastCGITimeouts = map[string][2]uint64{
+	"activityTimeout": {10, 3600},
+	"idleTimeout":     {10, 604800},
+	"requestTimeout":  {10, 604800},
+}
+
+func (f *FastCGI) validate() error {
+	mapped := map[string]string{}
+	// IIS identifies an application by its full path and arguments.
+	defined := map[string]bool{}
+	for _, app := range f.Applications {
+		if app.FullPath == "" {
+			return fmt.Errorf("Invalid FastCGI application: fullPath is required")
+		}
+		id := strings.ToLower(filepath.Clean(app.FullPath)) + "|" + app.Arguments
+		if defined[id] {
+			return fmt.Errorf("Invalid FastCGI application %s: it is defined more than once with the same arguments", app.FullPath)
+		}
+		defined[id] = true
+		if app.MaxInstances > 10000 {
+			return fmt.Errorf("Invalid FastCGI maxInstances for %s: %d (expected 0 to 10000)", app.FullPath, app.MaxInstances)
+		}
+		if app.InstanceMaxRequests > 10000000 {
+			return fmt.Errorf("Invalid FastCGI instanceMaxRequests for %s: %d (expected 1 to 10000000)", app.FullPath, app.InstanceMaxRequests)
+		}
+		if len(app.Extensions) == 0 {
+			return fmt.Errorf("Invalid FastCGI application %s: extensions are required", app.FullPath)
+		}
+		for _, extension := range app.Extensions {
+			if !strings.HasPrefix(extension, ".") || strings.ContainsAny(extension, `*/\`) {
+				return fmt.Errorf("Invalid FastCGI extension for %s: %q (expected an extension such as .php)", app.FullPath, extension)
+			}
+			key := strings.ToLower(extension)
+			if other, ok := mapped[key]; ok {
+				return fmt.Errorf("Invalid FastCGI extension for %s: %s is already handled by %s", app.FullPath, extension, other)
+			}
+			mapped[key] = app.FullPath
+		}
+		timeouts := []struct {
+			name  string
+			value Duration
+		}{
+			{"activityTimeout", app.ActivityTimeout},
+			{"idleTimeout", app.IdleTimeout},
+			{"requestTimeout", app.RequestTimeout},
+		}
+		for _, timeout := range timeouts {
+			if timeout.value < 0 || time.Duration(timeout.value)%time.Second != 0 {
+				return fmt.Errorf("Invalid FastCGI %s for %s: %s (expected whole seconds)", timeout.name, app.FullPath, timeout.value)
+			}
+			// Zero leaves the IIS default.
+			limits := fastCGITimeouts[timeout.name]
+			if s := seconds(timeout.value); s != 0 && (s < limits[0] || s > limits[1]) {
+				return fmt.Errorf("Invalid FastCGI %s for %s: %s (expected %d to %d seconds)", timeout.name, app.FullPath, timeout.value, limits[0], limits[1])
+			}
+		}
+	}
+	return nil
+}
+
+// resolve makes relative executable paths absolute against root, where the
+// app was pushed from, and checks the executables exist.
+func (f *FastCGI) resolve(root string) error {
+	for i := range f.Applications {
+		app := &f.Applications[i]
+		if !filepath.IsAbs(app.FullPath) {
+			app.FullPath = filepath.Join(root, app.FullPath)
+		}
+		if _, err := os.Stat(app.FullPath); err != nil {
+			return fmt.Errorf("Invalid FastCGI application: %v", err)
+		}
+	}
+	return nil
+}
+
+func (f *FastCGI) applicationHost() apphost.FastCGI {
+	var fastCGI apphost.FastCGI
+	for _, app := range f.Applications {
+		a := apphost.FastCGIApplication{
+			FullPath:            app.FullPath,
+			Arguments:           app.Arguments,
+			MaxInstances:        app.MaxInstances,
+			InstanceMaxRequests: app.InstanceMaxRequests,
+			ActivityTimeout:     seconds(app.ActivityTimeout),
+			IdleTimeout:         seconds(app.IdleTimeout),
+			RequestTimeout:      seconds(app.RequestTimeout),
+		}
+		var names []string
+		for name := range app.EnvironmentVariables {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			a.EnvironmentVariables = append(a.EnvironmentVariables, apphost.EnvironmentVariable{Name: name, Value: app.EnvironmentVariables[name]})
+		}
+		fastCGI.Applications = append(fastCGI.Applications, a)
+	}
+	return fastCGI
+}
+
+// handlers map each application's extensions to it. IIS finds the
+// application by the script processor, which is the full path followed by
+// the arguments, if any, after a "|".
+func (f *FastCGI) handlers() []apphost.Handler {
+	var handlers []apphost.Handler
+	for _, app := range f.Applications {
+		scriptProcessor := app.FullPath
+		if app.Arguments != "" {
+			scriptProcessor += "|" + app.Arguments
+		}
+		for _, extension := range app.Extensions {
+			handlers = append(handlers, apphost.Handler{
+				Name:            "FastCGI-" + strings.TrimPrefix(extension, "."),
+				Path:            "*" + extension,
+				Verb:            "*",
+				Modules:         "FastCgiModule",
+				ScriptProcessor: scriptProcessor,
+				ResourceType:    "Either",
+			})
+		}
+	}
+	return handlers
+}
+
+func seconds(d Duration) uint64 {
+	return uint64(time.Duration(d) / time.Second)
+}
@@ -0,0 +1,170 @@
+package hwcconfig_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"code.cloudfoundry.org/hwc/apphost"
+)
+
+var _ = Describe("FastCGI", func() {
+	app := newTestApp()
+
+	BeforeEach(func() {
+		Expect(os.MkdirAll(filepath.Join(app.rootPath, "php"), 0700)).To(Succeed())
+		Expect(ioutil.WriteFile(filepath.Join(app.rootPath, "php", "php-cgi.exe"), nil, 0700)).To(Succeed())
+		app.env["windir"] = fakeWindir(app.workingDirectoryPath, app.render(), `%windir%\System32\inetsrv\iisfcgi.dll`)
+	})
+
+	It("leaves FastCGI out by default", func() {
+		config := app.render()
+		Expect(config.SystemWebServer.FastCGI.Applications).To(BeEmpty())
+		Expect(globalModuleNames(config)).ToNot(ContainElement("FastCgiModule"))
+		Expect(moduleNames(config)).ToNot(ContainElement("FastCgiModule"))
+	})
+
+	Context("when HWC_FASTCGI_FULL_PATH is set", func() {
+		BeforeEach(func() {
+			app.env["HWC_FASTCGI_FULL_PATH"] = filepath.Join("php", "php-cgi.exe")
+			app.env["HWC_FASTCGI_EXTENSIONS"] = ".php"
+			app.env["HWC_FASTCGI_MAX_INSTANCES"] = "8"
+			app.env["HWC_FASTCGI_INSTANCE_MAX_REQUESTS"] = "10000"
+			app.env["HWC_FASTCGI_ACTIVITY_TIMEOUT"] = "1m"
+			app.env["HWC_FASTCGI_ENV_PHP_FCGI_MAX_REQUESTS"] = "10000"
+			app.env["HWC_FASTCGI_ENV_PHPRC"] = `C:\php`
+		})
+
+		It("defines the application relative to the app and maps the extensions to it", func() {
+			fullPath := filepath.Join(app.rootPath, "php", "php-cgi.exe")
+
+			config := app.render()
+			Expect(config.SystemWebServer.FastCGI.Applications).To(Equal([]apphost.FastCGIApplication{{
+				FullPath:            fullPath,
+				MaxInstances:        8,
+				InstanceMaxRequests: 10000,
+				ActivityTimeout:     60,
+				EnvironmentVariables: []apphost.EnvironmentVariable{
+					{Name: "PHPRC", Value: `C:\php`},
+					{Name: "PHP_FCGI_MAX_REQUESTS", Value: "10000"},
+				},
+			}}))
+			Expect(globalModuleNames(config)).To(ContainElement("FastCgiModule"))
+			Expect(config.SystemWebServer.Modules).To(ContainElement(apphost.Module{Name: "FastCgiModule", LockItem: true}))
+			Expect(config.SystemWebServer.Handlers.Handlers[0]).To(Equal(apphost.Handler{
+				Name:            "FastCGI-php",
+				Path:            "*.php",
+				Verb:            "*",
+				Modules:         "FastCgiModule",
+				ScriptProcessor: fullPath,
+				ResourceType:    "Either",
+			}))
+		})
+
+		Context("when the executable doesn't exist", func() {
+			BeforeEach(func() {
+				app.env["HWC_FASTCGI_FULL_PATH"] = filepath.Join("php", "missing.exe")
+			})
+
+			It("fails", func() {
+				err, _ := app.newConfig()
+				Expect(err).To(MatchError(HavePrefix("Invalid FastCGI application")))
+			})
+		})
+
+		Context("when no extensions are given", func() {
+			BeforeEach(func() {
+				delete(app.env, "HWC_FASTCGI_EXTENSIONS")
+			})
+
+			It("fails", func() {
+				err, _ := app.newConfig()
+				Expect(err).To(MatchError(HaveSuffix("extensions are required")))
+			})
+		})
+
+		Context("when a timeout isn't whole seconds", func() {
+			BeforeEach(func() {
+				app.env["HWC_FASTCGI_ACTIVITY_TIMEOUT"] = "1500ms"
+			})
+
+			It("fails", func() {
+				err, _ := app.newConfig()
+				Expect(err).To(MatchError("Invalid FastCGI activityTimeout for " + filepath.Join("php", "php-cgi.exe") + ": 1.5s (expected whole seconds)"))
+			})
+		})
+	})
+
+	Context("when HWC_CONFIG_FILE defines applications", func() {
+		BeforeEach(func() {
+			Expect(ioutil.WriteFile(filepath.Join(app.rootPath, "php", "php7.exe"), nil, 0700)).To(Succeed())
+			configFile := filepath.Join(app.workingDirectoryPath, "hwc.json")
+			Expect(ioutil.WriteFile(configFile, []byte(`{"fastCgi": {"applications": [
+				{"fullPath": "php/php-cgi.exe", "arguments": "-d display_errors=Off", "extensions": [".php"], "requestTimeout": "5m"},
+				{"fullPath": "php/php7.exe", "extensions": [".php7", ".phtml"]}
+			]}}`), 0666)).To(Succeed())
+			app.env["HWC_CONFIG_FILE"] = configFile
+		})
+
+		It("renders each one with its handlers", func() {
+			config := app.render()
+			Expect(config.SystemWebServer.FastCGI.Applications).To(HaveLen(2))
+			Expect(config.SystemWebServer.FastCGI.Applications[0].Arguments).To(Equal("-d display_errors=Off"))
+			Expect(config.SystemWebServer.FastCGI.Applications[0].RequestTimeout).To(Equal(uint64(300)))
+
+			handlers := config.SystemWebServer.Handlers.Handlers
+			Expect(handlerNames(config)[:3]).To(Equal([]string{"FastCGI-php", "FastCGI-php7", "FastCGI-phtml"}))
+			Expect(handlers[0].ScriptProcessor).To(Equal(filepath.Join(app.rootPath, "php", "php-cgi.exe") + "|-d display_errors=Off"))
+			Expect(handlers[2].ScriptProcessor).To(Equal(filepath.Join(app.rootPath, "php", "php7.exe")))
+		})
+	})
+
+	Context("when an application is defined twice", func() {
+		BeforeEach(func() {
+			configFile := filepath.Join(app.workingDirectoryPath, "hwc.json")
+			Expect(ioutil.WriteFile(configFile, []byte(`{"fastCgi": {"applications": [
+				{"fullPath": "php/php-cgi.exe", "extensions": [".php"]},
+				{"fullPath": "PHP/php-cgi.exe", "extensions": [".phtml"]},
+				{"fullPath": "php/php-cgi.exe", "arguments": "-c php-dev.ini", "extensions": [".php5"]}
+			]}}`), 0666)).To(Succeed())
+			app.env["HWC_CONFIG_FILE"] = configFile
+		})
+
+		It("fails unless the arguments differ", func() {
+			err, _ := app.newConfig()
+			Expect(err).To(MatchError("Invalid FastCGI application PHP/php-cgi.exe: it is defined more than once with the same arguments"))
+		})
+	})
+
+	Context("when a timeout is outside the range IIS allows", func() {
+		BeforeEach(func() {
+			app.env["HWC_FASTCGI_FULL_PATH"] = "php-cgi.exe"
+			app.env["HWC_FASTCGI_EXTENSIONS"] = ".php"
+			app.env["HWC_FASTCGI_ACTIVITY_TIMEOUT"] = "2h"
+		})
+
+		It("fails", func() {
+			err, _ := app.newConfig()
+			Expect(err).To(MatchError("Invalid FastCGI activityTimeout for php-cgi.exe: 2h0m0s (expected 10 to 3600 seconds)"))
+		})
+	})
+
+	Context("when two applications handle the same extension", func() {
+		BeforeEach(func() {
+			configFile := filepath.Join(app.workingDirectoryPath, "hwc.json")
+			Expect(ioutil.WriteFile(configFile, []byte(`{"fastCgi": {"applications": [
+				{"fullPath": "a.exe", "extensions": [".php"]},
+				{"fullPath": "b.exe", "extensions": [".PHP"]}
+			]}}`), 0666)).To(Succeed())
+			app.env["HWC_CONFIG_FILE"] = configFile
+		})
+
+		It("fails", func() {
+			err, _ := app.newConfig()
+			Expect(err).To(MatchError("Invalid FastCGI extension for b.exe: .PHP is already handled by a.exe"))
+		})
+	})
+})
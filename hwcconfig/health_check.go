@@ -0,0 +1,68 @@
+package hwcconfig
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+// HealthCheck configures the readiness probe hwc makes against the app once
+// IIS has started. The probe is disabled when Path is empty. Path is relative
+// to the app's context path.
+type HealthCheck struct {
+	Path           string   `json:"path"`
+	ExpectedStatus uint64   `json:"expectedStatus"`
+	Timeout        Duration `json:"timeout"`
+	Deadline       Duration `json:"deadline"`
+}
+
+func DefaultHealthCheck() HealthCheck {
+	return HealthCheck{
+		ExpectedStatus: 200,
+		Timeout:        Duration(5 * time.Second),
+		Deadline:       Duration(60 * time.Second),
+	}
+}
+
+func (hc HealthCheck) Enabled() bool {
+	return hc.Path != ""
+}
+
+func (hc *HealthCheck) loadEnv() error {
+	if s, ok := os.LookupEnv("HWC_HEALTH_CHECK_PATH"); ok {
+		hc.Path = s
+	}
+	if err := envUint("HWC_HEALTH_CHECK_STATUS", &hc.ExpectedStatus); err != nil {
+		return err
+	}
+	if err := envDuration("HWC_HEALTH_CHECK_TIMEOUT", &hc.Timeout); err != nil {
+		return err
+	}
+	if err := envDuration("HWC_HEALTH_CHECK_DEADLINE", &hc.Deadline); err != nil {
+		return err
+	}
+	return hc.validate()
+}
+
+func (hc *HealthCheck) validate() error {
+	if hc.Path != "" && !strings.HasPrefix(hc.Path, "/") {
+		return fmt.Errorf("Invalid healthCheck path: %s (must start with /)", hc.Path)
+	}
+	if hc.ExpectedStatus < 100 || hc.ExpectedStatus > 599 {
+		return fmt.Errorf("Invalid healthCheck expectedStatus: %d", hc.ExpectedStatus)
+	}
+	if hc.Timeout <= 0 || hc.Deadline <= 0 {
+		return fmt.Errorf("Invalid healthCheck: timeout and deadline must be positive")
+	}
+	return nil
+}
+
+// LocalURL is the URL of path within the app, as served on this instance's
+// port. The context path is the literal IIS application path, so it is
+// percent-encoded here; path is used as given and may carry a query string.
+func (c *HwcConfig) LocalURL(path string) string {
+	contextPath := (&url.URL{Path: strings.TrimSuffix(c.Applications[0].Path, "/")}).EscapedPath()
+	return fmt.Sprintf("http://localhost:%d%s/%s", c.Port, contextPath, strings.TrimPrefix(path, "/"))
+}
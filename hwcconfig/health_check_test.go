@@ -0,0 +1,103 @@
+package hwcconfig_test
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("HealthCheck", func() {
+	app := newTestApp()
+
+	It("is disabled by default", func() {
+		err, hwcConfig := app.newConfig()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(hwcConfig.HealthCheck.Enabled()).To(BeFalse())
+		Expect(hwcConfig.HealthCheck.ExpectedStatus).To(Equal(uint64(200)))
+		Expect(time.Duration(hwcConfig.HealthCheck.Timeout)).To(Equal(5 * time.Second))
+		Expect(time.Duration(hwcConfig.HealthCheck.Deadline)).To(Equal(time.Minute))
+	})
+
+	Context("when configured from the environment", func() {
+		BeforeEach(func() {
+			app.env["HWC_HEALTH_CHECK_PATH"] = "/health?deep=true"
+			app.env["HWC_HEALTH_CHECK_STATUS"] = "204"
+			app.env["HWC_HEALTH_CHECK_TIMEOUT"] = "2s"
+			app.env["HWC_HEALTH_CHECK_DEADLINE"] = "3m"
+		})
+
+		It("probes the path with the given limits", func() {
+			err, hwcConfig := app.newConfig()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(hwcConfig.HealthCheck.Enabled()).To(BeTrue())
+			Expect(hwcConfig.HealthCheck.ExpectedStatus).To(Equal(uint64(204)))
+			Expect(time.Duration(hwcConfig.HealthCheck.Timeout)).To(Equal(2 * time.Second))
+			Expect(time.Duration(hwcConfig.HealthCheck.Deadline)).To(Equal(3 * time.Minute))
+			Expect(hwcConfig.LocalURL(hwcConfig.HealthCheck.Path)).To(Equal("http://localhost:8080/health?deep=true"))
+		})
+
+		Context("when the app has a context path", func() {
+			BeforeEach(func() {
+				app.contextPath = "/vdir1/vdir2"
+			})
+
+			It("probes the path within the app", func() {
+				err, hwcConfig := app.newConfig()
+				Expect(err).ToNot(HaveOccurred())
+				Expect(hwcConfig.LocalURL(hwcConfig.HealthCheck.Path)).To(Equal("http://localhost:8080/vdir1/vdir2/health?deep=true"))
+			})
+		})
+
+		Context("when the context path needs percent-encoding", func() {
+			BeforeEach(func() {
+				app.contextPath = "/R&D/my app#1"
+			})
+
+			It("encodes it in the URL", func() {
+				err, hwcConfig := app.newConfig()
+				Expect(err).ToNot(HaveOccurred())
+				Expect(hwcConfig.LocalURL(hwcConfig.HealthCheck.Path)).To(Equal("http://localhost:8080/R&D/my%20app%231/health?deep=true"))
+			})
+		})
+	})
+
+	Context("when HWC_CONFIG_FILE sets the limits", func() {
+		BeforeEach(func() {
+			configFile := filepath.Join(app.workingDirectoryPath, "hwc.json")
+			Expect(ioutil.WriteFile(configFile, []byte(`{"healthCheck": {"path": "/ready", "deadline": "90s"}}`), 0666)).To(Succeed())
+			app.env["HWC_CONFIG_FILE"] = configFile
+		})
+
+		It("reads durations as strings", func() {
+			err, hwcConfig := app.newConfig()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(hwcConfig.HealthCheck.Path).To(Equal("/ready"))
+			Expect(time.Duration(hwcConfig.HealthCheck.Deadline)).To(Equal(90 * time.Second))
+		})
+	})
+
+	Context("when a duration has no unit", func() {
+		BeforeEach(func() {
+			app.env["HWC_HEALTH_CHECK_TIMEOUT"] = "5"
+		})
+
+		It("fails", func() {
+			err, _ := app.newConfig()
+			Expect(err).To(MatchError("Invalid value for HWC_HEALTH_CHECK_TIMEOUT: 5"))
+		})
+	})
+
+	Context("when the path is relative", func() {
+		BeforeEach(func() {
+			app.env["HWC_HEALTH_CHECK_PATH"] = "health"
+		})
+
+		It("fails", func() {
+			err, _ := app.newConfig()
+			Expect(err).To(MatchError("Invalid healthCheck path: health (must start with /)"))
+		})
+	})
+})
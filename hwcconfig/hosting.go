@@ -0,0 +1,266 @@
+package hwcconfig
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"code.cloudfoundry.org/hwc/apphost"
+	"code.cloudfoundry.org/hwc/hwclog"
+)
+
+const (
+	ProfileFull       = "full"
+	ProfileStatic     = "static"
+	ProfileASPNet45   = "aspnet45"
+	ProfileASPClassic = "asp-classic"
+	ProfileAuto       = "auto"
+)
+
+// Hosting selects the profile that decides which parts of IIS the site loads.
+// The full profile loads everything hwc has always loaded. The others only
+// load the native modules, handlers and ISAPI extensions their kind of app
+// needs, and only require those DLLs to be installed. The auto profile picks
+// one from the files in the app.
+type Hosting struct {
+	Profile string `json:"profile"`
+}
+
+func DefaultHosting() Hosting {
+	return Hosting{Profile: ProfileFull}
+}
+
+func (h *Hosting) loadEnv() error {
+	if s, ok := os.LookupEnv("HWC_PROFILE"); ok && s != "" {
+		h.Profile = s
+	}
+	return h.validate()
+}
+
+func (h *Hosting) validate() error {
+	return oneOf("hosting profile", h.Profile, ProfileFull, ProfileStatic, ProfileASPNet45, ProfileASPClassic, ProfileAuto)
+}
+
+// resolve replaces the auto profile with the one detected for the app in
+// root.
+func (h *Hosting) resolve(root string) error {
+	if h.Profile != ProfileAuto {
+		return nil
+	}
+	profile, err := detectProfile(root)
+	if err != nil {
+		return fmt.Errorf("Detecting hosting profile: %v", err)
+	}
+	h.Profile = profile
+	hwclog.Default().Info("hosting-profile", fmt.Sprintf("Detected hosting profile %s", profile), hwclog.Fields{"profile": profile})
+	return nil
+}
+
+var errDetected = errors.New("detected")
+
+// detectProfile picks asp-classic for apps with .asp pages, aspnet45 for apps
+// with a bin directory or ASP.NET pages, full for apps with both and static
+// for everything else. A Web.config alone says nothing, since static and
+// classic ASP apps use one to configure IIS too.
+func detectProfile(root string) (string, error) {
+	classic, aspnet := false, false
+	err := filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		name := strings.ToLower(info.Name())
+		if info.IsDir() {
+			if path != root && name == "bin" && filepath.Dir(path) == root {
+				aspnet = true
+			}
+			return nil
+		}
+		switch filepath.Ext(name) {
+		case ".asp":
+			classic = true
+		case ".aspx", ".asmx", ".ashx", ".svc", ".asax", ".cshtml", ".vbhtml":
+			aspnet = true
+		}
+		if classic && aspnet {
+			return errDetected
+		}
+		return nil
+	})
+	if err != nil && err != errDetected {
+		return "", err
+	}
+
+	switch {
+	case classic && aspnet:
+		return ProfileFull, nil
+	case classic:
+		return ProfileASPClassic, nil
+	case aspnet:
+		return ProfileASPNet45, nil
+	default:
+		return ProfileStatic, nil
+	}
+}
+
+// staticModules are the native modules every profile loads. Besides serving
+// files they cover the logging, tracing, error pages and request filtering
+// hwc configures.
+var staticModules = []string{
+	"UriCacheModule",
+	"FileCacheModule",
+	"TokenCacheModule",
+	"HttpCacheModule",
+	"StaticCompressionModule",
+	"DynamicCompressionModule",
+	"DefaultDocumentModule",
+	"DirectoryListingModule",
+	"ProtocolSupportModule",
+	"StaticFileModule",
+	"AnonymousAuthenticationModule",
+	"RequestFilteringModule",
+	"CustomErrorModule",
+	"HttpLoggingModule",
+	"CustomLoggingModule",
+	"RequestMonitorModule",
+	"TracingModule",
+	"FailedRequestsTracingModule",
+	"ConfigurationValidationModule",
+	"HttpRedirectionModule",
+	"IpRestrictionModule",
+	"DynamicIpRestrictionModule",
+}
+
+type hostingProfile struct {
+	// globalModules are the native modules loaded on top of staticModules.
+	globalModules []string
+	// managed loads the ASP.NET 4 managed modules and handlers.
+	managed bool
+	// isapiExtensions are the script processors handlers may use and
+	// isapiCgiRestriction allows.
+	isapiExtensions []string
+}
+
+var hostingProfiles = map[string]hostingProfile{
+	ProfileStatic: {},
+	ProfileASPNet45: {
+		globalModules: []string{"ManagedEngineV4.0_32bit", "ManagedEngineV4.0_64bit", "WindowsAuthenticationModule", "UrlAuthorizationModule", "WebSocketModule"},
+		managed:       true,
+	},
+	ProfileASPClassic: {
+		globalModules:   []string{"IsapiModule", "WindowsAuthenticationModule", "UrlAuthorizationModule"},
+		isapiExtensions: []string{`%windir%\system32\inetsrv\asp.dll`},
+	},
+}
+
+// profile returns the profile's filters, or false for the full profile,
+// which loads everything.
+func (h Hosting) profile() (hostingProfile, bool) {
+	p, ok := hostingProfiles[h.Profile]
+	return p, ok
+}
+
+func (p hostingProfile) loads(module string) bool {
+	return contains(staticModules, module) || contains(p.globalModules, module)
+}
+
+// globalModules are the native modules the profile loads, which are also the
+// DLLs it requires.
+func (h Hosting) globalModules(all []apphost.GlobalModule) []apphost.GlobalModule {
+	p, ok := h.profile()
+	if !ok {
+		return all
+	}
+	var kept []apphost.GlobalModule
+	for _, module := range all {
+		if p.loads(module.Name) {
+			kept = append(kept, module)
+		}
+	}
+	return kept
+}
+
+func (h Hosting) modules(all []apphost.Module) []apphost.Module {
+	p, ok := h.profile()
+	if !ok {
+		return all
+	}
+	var kept []apphost.Module
+	for _, module := range all {
+		if module.Type == "" && p.loads(module.Name) || module.Type != "" && p.managed && !forRuntimeV2(module.PreCondition) {
+			kept = append(kept, module)
+		}
+	}
+	return kept
+}
+
+// handlers keeps the handlers whose modules the profile loads, and of those
+// the managed ones only with ASP.NET and the ISAPI ones only for the
+// profile's extensions.
+func (h Hosting) handlers(all []apphost.Handler) []apphost.Handler {
+	p, ok := h.profile()
+	if !ok {
+		return all
+	}
+	var kept []apphost.Handler
+	for _, handler := range all {
+		if p.allows(handler) {
+			kept = append(kept, handler)
+		}
+	}
+	return kept
+}
+
+func (p hostingProfile) allows(handler apphost.Handler) bool {
+	if handler.Type != "" {
+		return p.managed && !forRuntimeV2(handler.PreCondition)
+	}
+	for _, module := range strings.Split(handler.Modules, ",") {
+		if !p.loads(module) {
+			return false
+		}
+	}
+	return handler.ScriptProcessor == "" || contains(p.isapiExtensions, handler.ScriptProcessor)
+}
+
+func (h Hosting) isapiFilters(all []apphost.ISAPIFilter) []apphost.ISAPIFilter {
+	if p, ok := h.profile(); ok && !p.loads("IsapiFilterModule") {
+		return nil
+	}
+	return all
+}
+
+func (h Hosting) isapiCgiRestrictions(all []apphost.ISAPICgiRestriction) []apphost.ISAPICgiRestriction {
+	p, ok := h.profile()
+	if !ok {
+		return all
+	}
+	var kept []apphost.ISAPICgiRestriction
+	for _, restriction := range all {
+		if contains(p.isapiExtensions, restriction.Path) {
+			kept = append(kept, restriction)
+		}
+	}
+	return kept
+}
+
+// isapiExtensions are the ISAPI DLLs the profile requires on top of its
+// global modules. The full profile doesn't require any.
+func (h Hosting) isapiExtensions() []string {
+	p, _ := h.profile()
+	return append([]string(nil), p.isapiExtensions...)
+}
+
+func forRuntimeV2(preCondition string) bool {
+	return strings.Contains(preCondition, "runtimeVersionv2.0")
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if strings.EqualFold(v, value) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,164 @@
+package hwcconfig_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"code.cloudfoundry.org/hwc/hwcconfig"
+)
+
+var _ = Describe("Hosting", func() {
+	app := newTestApp()
+
+	It("loads everything by default", func() {
+		err, hwcConfig := app.newConfig()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(hwcConfig.Hosting.Profile).To(Equal(hwcconfig.ProfileFull))
+
+		config := app.render()
+		Expect(globalModuleNames(config)).To(ContainElement("IsapiModule"))
+		Expect(handlerNames(config)).To(ContainElement("ASP Classic"))
+		Expect(handlerNames(config)).To(ContainElement("PageHandlerFactory-ISAPI-4.0_64bit"))
+		Expect(moduleNames(config)).To(ContainElement("ServiceModel"))
+	})
+
+	Context("when HWC_PROFILE is static", func() {
+		BeforeEach(func() {
+			app.env["HWC_PROFILE"] = "static"
+		})
+
+		It("only loads what serving files needs", func() {
+			config := app.render()
+			Expect(globalModuleNames(config)).To(ContainElement("StaticFileModule"))
+			Expect(globalModuleNames(config)).ToNot(ContainElement("IsapiModule"))
+			Expect(globalModuleNames(config)).ToNot(ContainElement("ManagedEngineV4.0_64bit"))
+			Expect(handlerNames(config)).To(Equal([]string{"TRACEVerbHandler", "OPTIONSVerbHandler", "StaticFile"}))
+			for _, m := range config.SystemWebServer.Modules {
+				Expect(m.Type).To(BeEmpty())
+			}
+			Expect(config.SystemWebServer.ISAPIFilters).To(BeEmpty())
+			Expect(config.SystemWebServer.Security.ISAPICgiRestriction).To(BeEmpty())
+		})
+	})
+
+	Context("when HWC_PROFILE is aspnet45", func() {
+		BeforeEach(func() {
+			app.env["HWC_PROFILE"] = "aspnet45"
+		})
+
+		It("loads the integrated ASP.NET 4 pipeline without ISAPI", func() {
+			config := app.render()
+			Expect(globalModuleNames(config)).To(ContainElement("ManagedEngineV4.0_64bit"))
+			Expect(globalModuleNames(config)).ToNot(ContainElement("IsapiModule"))
+			Expect(handlerNames(config)).To(ContainElement("PageHandlerFactory-Integrated-4.0"))
+			Expect(handlerNames(config)).ToNot(ContainElement("PageHandlerFactory-ISAPI-4.0_64bit"))
+			Expect(handlerNames(config)).ToNot(ContainElement("ASP Classic"))
+			Expect(moduleNames(config)).To(ContainElement("ServiceModel-4.0"))
+			Expect(moduleNames(config)).ToNot(ContainElement("ServiceModel"))
+			Expect(config.SystemWebServer.Security.ISAPICgiRestriction).To(BeEmpty())
+		})
+	})
+
+	Context("when HWC_PROFILE is asp-classic", func() {
+		BeforeEach(func() {
+			app.env["windir"] = fakeWindir(app.workingDirectoryPath, app.render())
+			app.env["HWC_PROFILE"] = "asp-classic"
+		})
+
+		It("requires asp.dll", func() {
+			err, _ := app.newConfig()
+			Expect(err).To(MatchError(ContainSubstring("asp.dll")))
+		})
+
+		Context("when asp.dll is installed", func() {
+			BeforeEach(func() {
+				app.env["windir"] = fakeWindir(app.workingDirectoryPath, app.render(), `%windir%\system32\inetsrv\asp.dll`)
+			})
+
+			It("maps .asp pages to it and only allows it", func() {
+				config := app.render()
+				Expect(globalModuleNames(config)).To(ContainElement("IsapiModule"))
+				Expect(globalModuleNames(config)).ToNot(ContainElement("ManagedEngineV4.0_64bit"))
+				Expect(handlerNames(config)).To(ContainElement("ASP Classic"))
+				Expect(handlerNames(config)).ToNot(ContainElement("PageHandlerFactory-ISAPI-4.0_64bit"))
+				Expect(config.SystemWebServer.Security.ISAPICgiRestriction).To(HaveLen(1))
+				Expect(config.SystemWebServer.Security.ISAPICgiRestriction[0].Path).To(Equal(`%windir%\system32\inetsrv\asp.dll`))
+			})
+		})
+	})
+
+	Context("when HWC_PROFILE is auto", func() {
+		BeforeEach(func() {
+			app.env["HWC_PROFILE"] = "auto"
+		})
+
+		It("picks static for an app of plain files", func() {
+			Expect(ioutil.WriteFile(filepath.Join(app.rootPath, "index.html"), nil, 0600)).To(Succeed())
+			Expect(ioutil.WriteFile(filepath.Join(app.rootPath, "Web.config"), []byte("<configuration />"), 0600)).To(Succeed())
+
+			err, hwcConfig := app.newConfig()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(hwcConfig.Hosting.Profile).To(Equal(hwcconfig.ProfileStatic))
+		})
+
+		It("picks aspnet45 for an ASP.NET app", func() {
+			var err error
+			app.rootPath, err = filepath.Abs(filepath.Join("..", "fixtures", "nora"))
+			Expect(err).ToNot(HaveOccurred())
+
+			err, hwcConfig := app.newConfig()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(hwcConfig.Hosting.Profile).To(Equal(hwcconfig.ProfileASPNet45))
+		})
+
+		It("picks full for an app with both .asp and ASP.NET pages", func() {
+			Expect(os.MkdirAll(filepath.Join(app.rootPath, "legacy"), 0700)).To(Succeed())
+			Expect(ioutil.WriteFile(filepath.Join(app.rootPath, "legacy", "default.asp"), nil, 0600)).To(Succeed())
+			Expect(ioutil.WriteFile(filepath.Join(app.rootPath, "default.aspx"), nil, 0600)).To(Succeed())
+
+			err, hwcConfig := app.newConfig()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(hwcConfig.Hosting.Profile).To(Equal(hwcconfig.ProfileFull))
+		})
+	})
+
+	Context("when HWC_PROFILE is unknown", func() {
+		BeforeEach(func() {
+			app.env["HWC_PROFILE"] = "x"
+		})
+
+		It("fails", func() {
+			err, _ := app.newConfig()
+			Expect(err).To(MatchError("Invalid hosting profile: x (expected one of full, static, aspnet45, asp-classic, auto)"))
+		})
+	})
+
+	Context("when the -profile flag is set", func() {
+		BeforeEach(func() {
+			app.env["HWC_PROFILE"] = "aspnet45"
+			app.profile = "static"
+		})
+
+		It("takes precedence over HWC_PROFILE without changing the environment", func() {
+			err, hwcConfig := app.newConfig()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(hwcConfig.Hosting.Profile).To(Equal(hwcconfig.ProfileStatic))
+			Expect(os.Getenv("HWC_PROFILE")).To(Equal("aspnet45"))
+		})
+
+		Context("when it's unknown", func() {
+			BeforeEach(func() {
+				app.profile = "x"
+			})
+
+			It("fails", func() {
+				err, _ := app.newConfig()
+				Expect(err).To(MatchError("Invalid hosting profile: x (expected one of full, static, aspnet45, asp-classic, auto)"))
+			})
+		})
+	})
+})
@@ -0,0 +1,139 @@
+package hwcconfig
+
+import (
+	"embed"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+//go:embed errorpages/*.htm
+var defaultErrorPages embed.FS
+
+// errorPagesLanguage is the language directory IIS falls back to when it looks
+// up <error> pages underneath prefixLanguageFilePath.
+const errorPagesLanguage = "en-US"
+
+// HTTPErrors configures the host level <httpErrors> section. The custom error
+// pages default to the set embedded in hwc. Files named <statusCode>.htm in
+// PagesDirectory replace or add to them.
+type HTTPErrors struct {
+	ErrorMode        string `json:"errorMode"`
+	ExistingResponse string `json:"existingResponse"`
+	PagesDirectory   string `json:"pagesDirectory"`
+
+	Pages []ErrorPage `json:"-"`
+}
+
+type ErrorPage struct {
+	StatusCode int
+	Path       string
+}
+
+func DefaultHTTPErrors() HTTPErrors {
+	return HTTPErrors{
+		ErrorMode:        "DetailedLocalOnly",
+		ExistingResponse: "Auto",
+	}
+}
+
+func (he *HTTPErrors) loadEnv() error {
+	if s := os.Getenv("HWC_ERROR_MODE"); s != "" {
+		he.ErrorMode = s
+	}
+	if s := os.Getenv("HWC_ERROR_EXISTING_RESPONSE"); s != "" {
+		he.ExistingResponse = s
+	}
+	if s := os.Getenv("HWC_ERROR_PAGES_DIR"); s != "" {
+		he.PagesDirectory = s
+	}
+	return he.validate()
+}
+
+func (he *HTTPErrors) validate() error {
+	if err := oneOf("httpErrors errorMode", he.ErrorMode, "Custom", "Detailed", "DetailedLocalOnly"); err != nil {
+		return err
+	}
+	return oneOf("httpErrors existingResponse", he.ExistingResponse, "Auto", "Replace", "PassThrough")
+}
+
+// writeErrorPages copies the embedded pages, followed by any operator supplied
+// pages, into ErrorPagesDirectory.
+func (c *HwcConfig) writeErrorPages() error {
+	languageDirectory := filepath.Join(c.ErrorPagesDirectory, errorPagesLanguage)
+	err := os.MkdirAll(languageDirectory, 0700)
+	if err != nil {
+		return err
+	}
+
+	pages := map[int]string{}
+
+	embedded, err := defaultErrorPages.ReadDir("errorpages")
+	if err != nil {
+		return err
+	}
+	for _, entry := range embedded {
+		data, err := defaultErrorPages.ReadFile("errorpages/" + entry.Name())
+		if err != nil {
+			return err
+		}
+		if err := writeErrorPage(languageDirectory, entry.Name(), data, pages); err != nil {
+			return err
+		}
+	}
+
+	if c.HTTPErrors.PagesDirectory != "" {
+		supplied, err := ioutil.ReadDir(c.HTTPErrors.PagesDirectory)
+		if err != nil {
+			return fmt.Errorf("Reading error pages directory: %v", err)
+		}
+		for _, entry := range supplied {
+			if entry.IsDir() || !strings.EqualFold(filepath.Ext(entry.Name()), ".htm") {
+				continue
+			}
+			data, err := ioutil.ReadFile(filepath.Join(c.HTTPErrors.PagesDirectory, entry.Name()))
+			if err != nil {
+				return err
+			}
+			if err := writeErrorPage(languageDirectory, entry.Name(), data, pages); err != nil {
+				return err
+			}
+		}
+	}
+
+	c.HTTPErrors.Pages = nil
+	for statusCode, path := range pages {
+		c.HTTPErrors.Pages = append(c.HTTPErrors.Pages, ErrorPage{StatusCode: statusCode, Path: path})
+	}
+	sort.Slice(c.HTTPErrors.Pages, func(i, j int) bool {
+		return c.HTTPErrors.Pages[i].StatusCode < c.HTTPErrors.Pages[j].StatusCode
+	})
+	return nil
+}
+
+func writeErrorPage(directory, name string, data []byte, pages map[int]string) error {
+	statusCode, err := strconv.Atoi(strings.TrimSuffix(name, filepath.Ext(name)))
+	if err != nil || statusCode < 400 || statusCode > 999 {
+		return nil
+	}
+
+	path := fmt.Sprintf("%d.htm", statusCode)
+	if err := ioutil.WriteFile(filepath.Join(directory, path), data, 0600); err != nil {
+		return err
+	}
+	pages[statusCode] = path
+	return nil
+}
+
+func oneOf(name, value string, allowed ...string) error {
+	for _, a := range allowed {
+		if value == a {
+			return nil
+		}
+	}
+	return fmt.Errorf("Invalid %s: %s (expected one of %s)", name, value, strings.Join(allowed, ", "))
+}
@@ -0,0 +1,105 @@
+package hwcconfig_test
+
+import (
+	"encoding/xml"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"code.cloudfoundry.org/hwc/hwcconfig"
+)
+
+type httpErrorsConfiguration struct {
+	SystemWebServer struct {
+		HTTPErrors struct {
+			ErrorMode        string `xml:"errorMode,attr"`
+			ExistingResponse string `xml:"existingResponse,attr"`
+			Errors           []struct {
+				StatusCode             string `xml:"statusCode,attr"`
+				PrefixLanguageFilePath string `xml:"prefixLanguageFilePath,attr"`
+				Path                   string `xml:"path,attr"`
+			} `xml:"error"`
+		} `xml:"httpErrors"`
+	} `xml:"system.webServer"`
+}
+
+var _ = Describe("HTTPErrors", func() {
+	app := newTestApp()
+
+	var renderHTTPErrors = func() (*hwcconfig.HwcConfig, httpErrorsConfiguration) {
+		err, hwcConfig := app.newConfig()
+		Expect(err).ToNot(HaveOccurred())
+
+		configFileContents, err := ioutil.ReadFile(hwcConfig.ApplicationHostConfigPath)
+		Expect(err).ToNot(HaveOccurred())
+
+		var config httpErrorsConfiguration
+		Expect(xml.Unmarshal(configFileContents, &config)).To(Succeed())
+		return hwcConfig, config
+	}
+
+	Context("with no overrides", func() {
+		It("writes the embedded error pages into the config directory", func() {
+			hwcConfig, config := renderHTTPErrors()
+
+			Expect(hwcConfig.ErrorPagesDirectory).To(Equal(filepath.Join(app.workingDirectoryPath, "tmpPath", "config", "custerr")))
+
+			httpErrors := config.SystemWebServer.HTTPErrors
+			Expect(httpErrors.ErrorMode).To(Equal("DetailedLocalOnly"))
+			Expect(httpErrors.ExistingResponse).To(Equal("Auto"))
+			Expect(httpErrors.Errors).To(HaveLen(9))
+
+			for _, e := range httpErrors.Errors {
+				Expect(e.PrefixLanguageFilePath).To(Equal(hwcConfig.ErrorPagesDirectory))
+				Expect(filepath.Join(e.PrefixLanguageFilePath, "en-US", e.Path)).To(BeAnExistingFile())
+			}
+
+			page, err := ioutil.ReadFile(filepath.Join(hwcConfig.ErrorPagesDirectory, "en-US", "404.htm"))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(string(page)).To(ContainSubstring("404 - Not Found"))
+		})
+	})
+
+	Context("when an error pages directory is supplied", func() {
+		BeforeEach(func() {
+			pagesDirectory := filepath.Join(app.workingDirectoryPath, "branded")
+			Expect(os.MkdirAll(pagesDirectory, 0777)).To(Succeed())
+			Expect(ioutil.WriteFile(filepath.Join(pagesDirectory, "404.htm"), []byte("branded 404"), 0666)).To(Succeed())
+			Expect(ioutil.WriteFile(filepath.Join(pagesDirectory, "503.htm"), []byte("branded 503"), 0666)).To(Succeed())
+			Expect(ioutil.WriteFile(filepath.Join(pagesDirectory, "logo.png"), []byte{}, 0666)).To(Succeed())
+
+			app.env["HWC_ERROR_PAGES_DIR"] = pagesDirectory
+			app.env["HWC_ERROR_MODE"] = "Custom"
+			app.env["HWC_ERROR_EXISTING_RESPONSE"] = "Replace"
+		})
+
+		It("uses the supplied pages alongside the embedded ones", func() {
+			hwcConfig, config := renderHTTPErrors()
+
+			httpErrors := config.SystemWebServer.HTTPErrors
+			Expect(httpErrors.ErrorMode).To(Equal("Custom"))
+			Expect(httpErrors.ExistingResponse).To(Equal("Replace"))
+			Expect(httpErrors.Errors).To(HaveLen(10))
+			Expect(httpErrors.Errors[9].StatusCode).To(Equal("503"))
+			Expect(httpErrors.Errors[9].Path).To(Equal("503.htm"))
+
+			page, err := ioutil.ReadFile(filepath.Join(hwcConfig.ErrorPagesDirectory, "en-US", "404.htm"))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(string(page)).To(Equal("branded 404"))
+		})
+	})
+
+	Context("when the error mode is invalid", func() {
+		BeforeEach(func() {
+			app.env["HWC_ERROR_MODE"] = "Verbose"
+		})
+
+		It("returns an error", func() {
+			err, _ := app.newConfig()
+			Expect(err).To(MatchError("Invalid httpErrors errorMode: Verbose (expected one of Custom, Detailed, DetailedLocalOnly)"))
+		})
+	})
+})
@@ -0,0 +1,128 @@
+package hwcconfig
+
+import (
+	"encoding/xml"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"code.cloudfoundry.org/hwc/apphost"
+	"code.cloudfoundry.org/hwc/hwclog"
+)
+
+// httpPlatformImage is where the HttpPlatformHandler installer puts the
+// module.
+const httpPlatformImage = `%windir%\system32\inetsrv\httpPlatformHandler.dll`
+
+// HTTPPlatform configures the HttpPlatformHandler module, which hwc loads when
+// it is installed. The module starts a backend process, such as a Node.js or
+// Java server, tells it which port to listen on in HTTP_PLATFORM_PORT and
+// proxies requests to it. Apps can configure the module in their Web.config.
+// ProcessPath configures it for apps that don't have an <httpPlatform>
+// section.
+type HTTPPlatform struct {
+	ProcessPath string `json:"processPath"`
+	Arguments   string `json:"arguments"`
+
+	// appConfigured is set when the app's Web.config has an <httpPlatform>
+	// section, appLogsStdout when that section sets where stdout goes, and
+	// appSetsPort when it sets PORT itself.
+	appConfigured bool
+	appLogsStdout bool
+	appSetsPort   bool
+	loaded        bool
+}
+
+// appHTTPPlatform is what hwc needs to know about an <httpPlatform> section in
+// the app's Web.config.
+type appHTTPPlatform struct {
+	StdoutLogEnabled     *string `xml:"stdoutLogEnabled,attr"`
+	StdoutLogFile        *string `xml:"stdoutLogFile,attr"`
+	EnvironmentVariables []struct {
+		Name string `xml:"name,attr"`
+	} `xml:"environmentVariables>environmentVariable"`
+}
+
+// Enabled reports whether hwc configures the module for the app.
+func (hp *HTTPPlatform) Enabled() bool {
+	return hp.ProcessPath != ""
+}
+
+// Loaded reports whether the module is installed and was loaded.
+func (hp *HTTPPlatform) Loaded() bool {
+	return hp.loaded
+}
+
+// CapturesOutput reports whether the backend's stdout is written to
+// HTTPPlatformLogDirectory, which it isn't when the app's Web.config sends it
+// elsewhere.
+func (hp *HTTPPlatform) CapturesOutput() bool {
+	return hp.loaded && !hp.appLogsStdout
+}
+
+func (hp *HTTPPlatform) loadEnv() error {
+	if s, ok := os.LookupEnv("HWC_HTTP_PLATFORM_PROCESS_PATH"); ok {
+		hp.ProcessPath = s
+	}
+	if s, ok := os.LookupEnv("HWC_HTTP_PLATFORM_ARGUMENTS"); ok {
+		hp.Arguments = s
+	}
+	return nil
+}
+
+// resolve looks for an <httpPlatform> section in the Web.config of the app in
+// root, which ProcessPath can't be used with, and for the settings in it that
+// take over from hwc's.
+func (hp *HTTPPlatform) resolve(root string) error {
+	err := decodeAppSections(root, "httpPlatform", func(d *xml.Decoder, start xml.StartElement) error {
+		hp.appConfigured = true
+		var section appHTTPPlatform
+		if err := d.DecodeElement(&section, &start); err != nil {
+			return err
+		}
+		if section.StdoutLogEnabled != nil || section.StdoutLogFile != nil {
+			hp.appLogsStdout = true
+		}
+		for _, variable := range section.EnvironmentVariables {
+			if strings.EqualFold(variable.Name, "PORT") {
+				hp.appSetsPort = true
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if hp.appConfigured && hp.Enabled() {
+		return fmt.Errorf("HWC_HTTP_PLATFORM_PROCESS_PATH can't be used with an app whose Web.config has an <httpPlatform> section")
+	}
+	if hp.appLogsStdout {
+		hwclog.Default().Warn("http-platform-stdout", "The app's Web.config sets stdoutLogEnabled or stdoutLogFile on <httpPlatform>, so hwc won't print the backend's output",
+			hwclog.Fields{"element": "httpPlatform"})
+	}
+	return nil
+}
+
+// HTTPPlatformLogDirectory is where the backend process's stdout is written.
+func (c *HwcConfig) HTTPPlatformLogDirectory() string {
+	return filepath.Join(c.LogDirectory, "HttpPlatform")
+}
+
+// applicationHost is the server wide <httpPlatform> section. An <httpPlatform>
+// in the app's Web.config inherits its attributes, so unless it sets its own,
+// the backend writes its stdout where hwc follows it. The backend also gets
+// PORT set to the port the module proxies to, instead of the port hwc listens
+// on, unless the app sets PORT itself; IIS rejects the app's entry if both do.
+func (hp *HTTPPlatform) applicationHost(logDirectory string) *apphost.HTTPPlatform {
+	section := &apphost.HTTPPlatform{
+		ProcessPath:      hp.ProcessPath,
+		Arguments:        hp.Arguments,
+		StdoutLogEnabled: true,
+		StdoutLogFile:    filepath.Join(logDirectory, "stdout"),
+	}
+	if !hp.appSetsPort {
+		section.EnvironmentVariables = []apphost.EnvironmentVariable{{Name: "PORT", Value: "%HTTP_PLATFORM_PORT%"}}
+	}
+	return section
+}
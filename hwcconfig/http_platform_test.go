@@ -0,0 +1,108 @@
+package hwcconfig_test
+
+import (
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"code.cloudfoundry.org/hwc/apphost"
+)
+
+var _ = Describe("HTTPPlatform", func() {
+	app := newTestApp()
+
+	Context("when the module isn't installed", func() {
+		BeforeEach(func() {
+			app.env["windir"] = fakeWindir(app.workingDirectoryPath, app.render())
+		})
+
+		It("doesn't load it", func() {
+			err, hwcConfig := app.newConfig()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(hwcConfig.HTTPPlatform.Loaded()).To(BeFalse())
+			Expect(hwcConfig.ApplicationHost.SystemWebServer.HTTPPlatform).To(BeNil())
+		})
+
+		It("fails when the app's Web.config configures it", func() {
+			app.writeWebConfig(`<configuration><system.webServer><httpPlatform processPath="node.exe" arguments="server.js" /></system.webServer></configuration>`)
+			err, _ := app.newConfig()
+			Expect(err).To(MatchError(HavePrefix("The app's Web.config configures the HttpPlatformHandler module, which isn't installed")))
+		})
+	})
+
+	Context("when the module is installed", func() {
+		BeforeEach(func() {
+			app.env["windir"] = fakeWindir(app.workingDirectoryPath, app.render(), `%windir%\system32\inetsrv\httpPlatformHandler.dll`)
+		})
+
+		It("loads it and sends the backend's stdout to the log directory", func() {
+			err, hwcConfig := app.newConfig()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(hwcConfig.HTTPPlatform.Loaded()).To(BeTrue())
+			Expect(hwcConfig.HTTPPlatformLogDirectory()).To(BeADirectory())
+
+			config := app.render()
+			Expect(config.SystemWebServer.GlobalModules).To(ContainElement(apphost.GlobalModule{Name: "httpPlatformHandler", Image: `%windir%\system32\inetsrv\httpPlatformHandler.dll`}))
+			Expect(config.SystemWebServer.Modules).To(ContainElement(apphost.Module{Name: "httpPlatformHandler"}))
+			Expect(config.ConfigSections.Group("system.webServer").Entries).To(ContainElement(apphost.Section("httpPlatform", "Allow", "")))
+			Expect(config.SystemWebServer.HTTPPlatform).To(Equal(&apphost.HTTPPlatform{
+				StdoutLogEnabled:     true,
+				StdoutLogFile:        filepath.Join(hwcConfig.HTTPPlatformLogDirectory(), "stdout"),
+				EnvironmentVariables: []apphost.EnvironmentVariable{{Name: "PORT", Value: "%HTTP_PLATFORM_PORT%"}},
+			}))
+			Expect(handlerNames(config)).ToNot(ContainElement("httpPlatformHandler"))
+		})
+
+		It("lets the app's Web.config configure it", func() {
+			app.writeWebConfig(`<configuration><system.webServer><httpPlatform processPath="node.exe" arguments="server.js" /></system.webServer></configuration>`)
+			err, hwcConfig := app.newConfig()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(hwcConfig.HTTPPlatform.CapturesOutput()).To(BeTrue())
+		})
+
+		It("doesn't capture the output when the app's Web.config sends stdout elsewhere", func() {
+			app.writeWebConfig(`<configuration><location path="."><system.webServer><httpPlatform processPath="node.exe" stdoutLogEnabled="true" stdoutLogFile=".\logs\node" /></system.webServer></location></configuration>`)
+			err, hwcConfig := app.newConfig()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(hwcConfig.HTTPPlatform.Loaded()).To(BeTrue())
+			Expect(hwcConfig.HTTPPlatform.CapturesOutput()).To(BeFalse())
+		})
+
+		It("leaves PORT to the app's Web.config when it sets it", func() {
+			app.writeWebConfig(`<configuration><system.webServer><httpPlatform processPath="node.exe"><environmentVariables><environmentVariable name="PORT" value="%HTTP_PLATFORM_PORT%" /></environmentVariables></httpPlatform></system.webServer></configuration>`)
+			Expect(app.render().SystemWebServer.HTTPPlatform.EnvironmentVariables).To(BeEmpty())
+		})
+
+		Context("when HWC_HTTP_PLATFORM_PROCESS_PATH is set", func() {
+			BeforeEach(func() {
+				app.env["HWC_HTTP_PLATFORM_PROCESS_PATH"] = `C:\java\bin\java.exe`
+				app.env["HWC_HTTP_PLATFORM_ARGUMENTS"] = `-Dserver.port=%HTTP_PLATFORM_PORT% -jar app.jar`
+			})
+
+			It("starts the process and sends every request to it", func() {
+				config := app.render()
+				Expect(config.SystemWebServer.HTTPPlatform.ProcessPath).To(Equal(`C:\java\bin\java.exe`))
+				Expect(config.SystemWebServer.HTTPPlatform.Arguments).To(Equal(`-Dserver.port=%HTTP_PLATFORM_PORT% -jar app.jar`))
+				Expect(config.SystemWebServer.Handlers.Handlers[0]).To(Equal(apphost.Handler{Name: "httpPlatformHandler", Path: "*", Verb: "*", Modules: "httpPlatformHandler", ResourceType: "Unspecified"}))
+			})
+
+			It("fails when the app's Web.config configures the module too", func() {
+				app.writeWebConfig(`<configuration><location path="."><system.webServer><httpPlatform processPath="node.exe" /></system.webServer></location></configuration>`)
+				err, _ := app.newConfig()
+				Expect(err).To(MatchError("HWC_HTTP_PLATFORM_PROCESS_PATH can't be used with an app whose Web.config has an <httpPlatform> section"))
+			})
+		})
+	})
+
+	Context("when HWC_HTTP_PLATFORM_PROCESS_PATH is set without the module", func() {
+		BeforeEach(func() {
+			app.env["HWC_HTTP_PLATFORM_PROCESS_PATH"] = "node.exe"
+		})
+
+		It("fails", func() {
+			err, _ := app.newConfig()
+			Expect(err).To(MatchError(HavePrefix("HWC_HTTP_PLATFORM_PROCESS_PATH requires the HttpPlatformHandler module")))
+		})
+	})
+})
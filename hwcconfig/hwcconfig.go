@@ -4,6 +4,10 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+
+	"github.com/cloudfoundry-community/go-cfenv"
+
+	"code.cloudfoundry.org/hwc/apphost"
 )
 
 type HwcConfig struct {
@@ -12,24 +16,87 @@ type HwcConfig struct {
 	TempDirectory                 string
 	IISCompressedFilesDirectory   string
 	ASPCompiledTemplatesDirectory string
+	ErrorPagesDirectory           string
+	LogDirectory                  string
+	FailedRequestLogDirectory     string
+
+	RequestFiltering     RequestFiltering
+	HTTPErrors           HTTPErrors
+	AccessLog            AccessLog
+	FailedRequestTracing FailedRequestTracing
+	ServiceBindings      ServiceBindings
+	EnvSubstitution      EnvSubstitution
+	InstanceMetadata     InstanceMetadata
+	SecurityHeaders      SecurityHeaders
+	HealthCheck          HealthCheck
+	Warmup               Warmup
+	Watchdog             Watchdog
+	Metrics              Metrics
+	Overlays             Overlays
+	Hosting              Hosting
+	AspNetCore           AspNetCore
+	HTTPPlatform         HTTPPlatform
+	FastCGI              FastCGI
+	WebSocket            WebSocket
+	Compression          Compression
+
+	// NativeModules are the names of the modules loaded from HWC_NATIVE_MODULES.
+	NativeModules []string
 
 	Applications              []*HwcApplication
 	AspnetConfigPath          string
 	WebConfigPath             string
 	ApplicationHostConfigPath string
+
+	// ApplicationHost is the model ApplicationHostConfigPath was written from,
+	// before any Overlays were applied.
+	ApplicationHost *apphost.Configuration
 }
 
-func New(port int, rootPath, tmpPath, contextPath, uuid string) (error, *HwcConfig) {
+// New generates the configs for the app in rootPath. appEnv is the app's CF
+// environment, or nil when hwc isn't running on CF. profile, unless empty,
+// is the hosting profile to use whatever HWC_PROFILE says.
+func New(port int, rootPath, tmpPath, contextPath, uuid string, appEnv *cfenv.App, profile string) (error, *HwcConfig) {
 	config := &HwcConfig{
 		Instance:                      uuid,
 		Port:                          port,
 		TempDirectory:                 tmpPath,
 		IISCompressedFilesDirectory:   filepath.Join(tmpPath, "IIS Temporary Compressed Files"),
 		ASPCompiledTemplatesDirectory: filepath.Join(tmpPath, "ASP Compiled Templates"),
+		LogDirectory:                  filepath.Join(tmpPath, "LogFiles"),
+		FailedRequestLogDirectory:     filepath.Join(tmpPath, "FailedReqLogFiles"),
+		RequestFiltering:              DefaultRequestFiltering(),
+		HTTPErrors:                    DefaultHTTPErrors(),
+		AccessLog:                     DefaultAccessLog(),
+		FailedRequestTracing:          DefaultFailedRequestTracing(),
+		EnvSubstitution:               DefaultEnvSubstitution(),
+		HealthCheck:                   DefaultHealthCheck(),
+		Warmup:                        DefaultWarmup(),
+		Watchdog:                      DefaultWatchdog(),
+		Hosting:                       DefaultHosting(),
+		AspNetCore:                    DefaultAspNetCore(),
+		WebSocket:                     DefaultWebSocket(),
+		Compression:                   DefaultCompression(),
+	}
+
+	err := config.loadOverrides()
+	if err != nil {
+		return err, nil
+	}
+	if profile != "" {
+		config.Hosting.Profile = profile
+		err = config.Hosting.validate()
+		if err != nil {
+			return err, nil
+		}
 	}
+	if config.Metrics.Port == uint64(port) {
+		return fmt.Errorf("Invalid metrics port: %d (must differ from PORT)", config.Metrics.Port), nil
+	}
+	config.InstanceMetadata.resolve(appEnv)
 
 	defaultRootPath := filepath.Join(config.TempDirectory, "wwwroot")
-	err := os.MkdirAll(defaultRootPath, 0700)
+	err = os.MkdirAll(defaultRootPath, 0700)
 	if err != nil {
 		return err, nil
 	}
@@ -40,6 +107,12 @@ func New(port int, rootPath, tmpPath, contextPath, uuid string) (error, *HwcConf
 		return err, nil
 	}
 
+	config.ErrorPagesDirectory = filepath.Join(configPath, "custerr")
+	err = config.writeErrorPages()
+	if err != nil {
+		return err, nil
+	}
+
 	err = os.MkdirAll(config.IISCompressedFilesDirectory, 0700)
 	if err != nil {
 		return err, nil
@@ -63,6 +136,26 @@ func New(port int, rootPath, tmpPath, contextPath, uuid string) (error, *HwcConf
 	config.AspnetConfigPath = filepath.Join(configPath, "Aspnet.config")
 	config.WebConfigPath = filepath.Join(configPath, "Web.config")
 
+	err = config.Hosting.resolve(rootPath)
+	if err != nil {
+		return err, nil
+	}
+
+	err = config.AspNetCore.resolve(rootPath)
+	if err != nil {
+		return err, nil
+	}
+
+	err = config.HTTPPlatform.resolve(rootPath)
+	if err != nil {
+		return err, nil
+	}
+
+	err = config.FastCGI.resolve(rootPath)
+	if err != nil {
+		return err, nil
+	}
+
 	err = config.generateApplicationHostConfig()
 	if err != nil {
 		return err, nil
@@ -1,13 +1,141 @@
 package hwcconfig_test
 
 import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
+
+	"github.com/cloudfoundry-community/go-cfenv"
+
+	"code.cloudfoundry.org/hwc/apphost"
+	"code.cloudfoundry.org/hwc/hwcconfig"
 )
 
 func TestHwcconfig(t *testing.T) {
 	RegisterFailHandler(Fail)
 	RunSpecs(t, "Hwcconfig Suite")
 }
+
+// testApp is an app pushed to rootPath, under a fresh working directory for
+// every spec, and the environment hwc runs it with.
+type testApp struct {
+	workingDirectoryPath string
+	rootPath             string
+	contextPath          string
+	// appEnv is the CF environment hwc is given, nil unless a spec sets it.
+	appEnv *cfenv.App
+	// profile is what hwc's -profile flag was set to.
+	profile string
+
+	// env is set just before each spec runs, so nested BeforeEach blocks can
+	// add to it, and restored after.
+	env      map[string]string
+	previous map[string]*string
+}
+
+// newTestApp sets up a testApp for each spec in the enclosing container.
+func newTestApp() *testApp {
+	app := &testApp{}
+
+	BeforeEach(func() {
+		var err error
+		app.workingDirectoryPath, err = ioutil.TempDir("", "hwcconfig_test")
+		Expect(err).ToNot(HaveOccurred())
+		app.rootPath = filepath.Join(app.workingDirectoryPath, "rootPath")
+		Expect(os.MkdirAll(app.rootPath, 0700)).To(Succeed())
+		app.contextPath = "/"
+		app.appEnv = nil
+		app.profile = ""
+		app.env = map[string]string{}
+		app.previous = map[string]*string{}
+	})
+
+	JustBeforeEach(func() {
+		for name, value := range app.env {
+			if previous, ok := os.LookupEnv(name); ok {
+				app.previous[name] = &previous
+			} else {
+				app.previous[name] = nil
+			}
+			Expect(os.Setenv(name, value)).To(Succeed())
+		}
+	})
+
+	AfterEach(func() {
+		for name, previous := range app.previous {
+			if previous != nil {
+				Expect(os.Setenv(name, *previous)).To(Succeed())
+			} else {
+				Expect(os.Unsetenv(name)).To(Succeed())
+			}
+		}
+		_ = os.RemoveAll(app.workingDirectoryPath)
+	})
+
+	return app
+}
+
+func (app *testApp) newConfig() (error, *hwcconfig.HwcConfig) {
+	return hwcconfig.New(8080, app.rootPath, filepath.Join(app.workingDirectoryPath, "tmpPath"), app.contextPath, "someuid12345", app.appEnv, app.profile)
+}
+
+// render generates the app's config and reads back the ApplicationHost.config.
+func (app *testApp) render() *apphost.Configuration {
+	err, hwcConfig := app.newConfig()
+	Expect(err).ToNot(HaveOccurred())
+	configFileContents, err := ioutil.ReadFile(hwcConfig.ApplicationHostConfigPath)
+	Expect(err).ToNot(HaveOccurred())
+	config, err := apphost.Unmarshal(configFileContents)
+	Expect(err).ToNot(HaveOccurred())
+	return config
+}
+
+func (app *testApp) writeWebConfig(contents string) {
+	Expect(ioutil.WriteFile(filepath.Join(app.rootPath, "Web.config"), []byte(contents), 0600)).To(Succeed())
+}
+
+func globalModuleNames(config *apphost.Configuration) []string {
+	var names []string
+	for _, m := range config.SystemWebServer.GlobalModules {
+		names = append(names, m.Name)
+	}
+	return names
+}
+
+func handlerNames(config *apphost.Configuration) []string {
+	var names []string
+	for _, h := range config.SystemWebServer.Handlers.Handlers {
+		names = append(names, h.Name)
+	}
+	return names
+}
+
+func moduleNames(config *apphost.Configuration) []string {
+	var names []string
+	for _, m := range config.SystemWebServer.Modules {
+		names = append(names, m.Name)
+	}
+	return names
+}
+
+// fakeWindir installs empty copies of the DLLs config loads, plus the extra
+// ones given, in a new windir under dir.
+func fakeWindir(dir string, config *apphost.Configuration, extra ...string) string {
+	images := append([]string(nil), extra...)
+	for _, m := range config.SystemWebServer.GlobalModules {
+		images = append(images, m.Image)
+	}
+
+	windir := filepath.Join(dir, "Windows")
+	for _, image := range images {
+		path := strings.Replace(image, `%windir%`, windir, -1)
+		Expect(os.MkdirAll(filepath.Dir(path), 0700)).To(Succeed())
+		Expect(ioutil.WriteFile(path, nil, 0600)).To(Succeed())
+	}
+	return windir
+}
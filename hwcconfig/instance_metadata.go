@@ -0,0 +1,71 @@
+package hwcconfig
+
+import (
+	"strconv"
+
+	"github.com/cloudfoundry-community/go-cfenv"
+)
+
+// rewriteImage is the URL Rewrite module, which sets the server variables.
+const rewriteImage = `%windir%\system32\inetsrv\rewrite.dll`
+
+// InstanceMetadata controls whether IIS tells clients and apps which Cloud
+// Foundry app instance served a request, as response headers and as server
+// variables. Server variables are set by a URL Rewrite global rule and need
+// rewrite.dll to be installed.
+type InstanceMetadata struct {
+	Headers         bool `json:"headers"`
+	ServerVariables bool `json:"serverVariables"`
+
+	Values []InstanceMetadataValue `json:"-"`
+}
+
+type InstanceMetadataValue struct {
+	Header         string
+	ServerVariable string
+	Value          string
+	// PerInstance is set for values that differ between instances of the
+	// same app.
+	PerInstance bool
+}
+
+// InstanceMetadataValues is the metadata exposed for the app instance
+// described by appEnv.
+func InstanceMetadataValues(appEnv *cfenv.App) []InstanceMetadataValue {
+	return []InstanceMetadataValue{
+		{Header: "X-CF-Instance-Index", ServerVariable: "CF_INSTANCE_INDEX", Value: strconv.Itoa(appEnv.Index), PerInstance: true},
+		{Header: "X-CF-Instance-GUID", ServerVariable: "CF_INSTANCE_GUID", Value: appEnv.InstanceID, PerInstance: true},
+		{Header: "X-CF-App-Name", ServerVariable: "CF_APP_NAME", Value: appEnv.Name},
+	}
+}
+
+// PerInstanceNames are the headers and server variables whose values differ
+// between instances of the same app.
+func (im *InstanceMetadata) PerInstanceNames() []string {
+	var names []string
+	for _, value := range im.Values {
+		if value.PerInstance {
+			names = append(names, value.Header, value.ServerVariable)
+		}
+	}
+	return names
+}
+
+func (im *InstanceMetadata) Enabled() bool {
+	return im.Headers || im.ServerVariables
+}
+
+func (im *InstanceMetadata) loadEnv() error {
+	if err := envBool("HWC_INSTANCE_HEADERS", &im.Headers); err != nil {
+		return err
+	}
+	return envBool("HWC_INSTANCE_SERVER_VARIABLES", &im.ServerVariables)
+}
+
+// resolve fills in Values from appEnv, which is nil when hwc isn't running on
+// CF, so there's nothing to expose.
+func (im *InstanceMetadata) resolve(appEnv *cfenv.App) {
+	if im.Enabled() && appEnv != nil {
+		im.Values = InstanceMetadataValues(appEnv)
+	}
+}
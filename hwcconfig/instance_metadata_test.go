@@ -0,0 +1,134 @@
+package hwcconfig_test
+
+import (
+	"encoding/xml"
+	"io/ioutil"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/cloudfoundry-community/go-cfenv"
+
+	"code.cloudfoundry.org/hwc/hwcconfig"
+)
+
+type instanceMetadataConfiguration struct {
+	SystemWebServer []struct {
+		CustomHeaders []struct {
+			Name  string `xml:"name,attr"`
+			Value string `xml:"value,attr"`
+		} `xml:"httpProtocol>customHeaders>add"`
+		GlobalRules []struct {
+			Name            string `xml:"name,attr"`
+			ServerVariables []struct {
+				Name  string `xml:"name,attr"`
+				Value string `xml:"value,attr"`
+			} `xml:"serverVariables>set"`
+		} `xml:"rewrite>globalRules>rule"`
+	} `xml:"system.webServer"`
+}
+
+var _ = Describe("InstanceMetadata", func() {
+	app := newTestApp()
+
+	var renderInstanceMetadata = func() instanceMetadataConfiguration {
+		err, hwcConfig := app.newConfig()
+		Expect(err).ToNot(HaveOccurred())
+
+		configFileContents, err := ioutil.ReadFile(hwcConfig.ApplicationHostConfigPath)
+		Expect(err).ToNot(HaveOccurred())
+
+		var config instanceMetadataConfiguration
+		Expect(xml.Unmarshal(configFileContents, &config)).To(Succeed())
+		return config
+	}
+
+	BeforeEach(func() {
+		app.appEnv = &cfenv.App{InstanceID: "b3d1a6a5-0c3f-4f4e-7d5a-1c2b", Index: 3, Name: "orders & more"}
+	})
+
+	Describe("InstanceMetadataValues", func() {
+		It("derives the metadata from the CF environment", func() {
+			values := hwcconfig.InstanceMetadataValues(&cfenv.App{InstanceID: "guid", Index: 2, Name: "orders"})
+			Expect(values).To(Equal([]hwcconfig.InstanceMetadataValue{
+				{Header: "X-CF-Instance-Index", ServerVariable: "CF_INSTANCE_INDEX", Value: "2", PerInstance: true},
+				{Header: "X-CF-Instance-GUID", ServerVariable: "CF_INSTANCE_GUID", Value: "guid", PerInstance: true},
+				{Header: "X-CF-App-Name", ServerVariable: "CF_APP_NAME", Value: "orders"},
+			}))
+		})
+
+		It("names the values that differ between instances", func() {
+			metadata := hwcconfig.InstanceMetadata{Values: hwcconfig.InstanceMetadataValues(&cfenv.App{InstanceID: "guid", Index: 2, Name: "orders"})}
+			Expect(metadata.PerInstanceNames()).To(Equal([]string{"X-CF-Instance-Index", "CF_INSTANCE_INDEX", "X-CF-Instance-GUID", "CF_INSTANCE_GUID"}))
+		})
+	})
+
+	It("exposes no metadata by default", func() {
+		config := renderInstanceMetadata()
+		Expect(config.SystemWebServer[0].CustomHeaders).To(BeEmpty())
+		Expect(config.SystemWebServer[0].GlobalRules).To(BeEmpty())
+	})
+
+	Context("when HWC_INSTANCE_HEADERS is set", func() {
+		BeforeEach(func() {
+			app.env["HWC_INSTANCE_HEADERS"] = "true"
+		})
+
+		It("adds the metadata as response headers", func() {
+			config := renderInstanceMetadata()
+			headers := config.SystemWebServer[0].CustomHeaders
+			Expect(headers).To(HaveLen(3))
+			Expect(headers[0].Name).To(Equal("X-CF-Instance-Index"))
+			Expect(headers[0].Value).To(Equal("3"))
+			Expect(headers[1].Value).To(Equal("b3d1a6a5-0c3f-4f4e-7d5a-1c2b"))
+			Expect(headers[2].Value).To(Equal("orders & more"))
+		})
+
+		Context("when hwc isn't running on CF", func() {
+			BeforeEach(func() {
+				app.appEnv = nil
+			})
+
+			It("adds no headers", func() {
+				config := renderInstanceMetadata()
+				Expect(config.SystemWebServer[0].CustomHeaders).To(BeEmpty())
+			})
+		})
+	})
+
+	Context("when HWC_INSTANCE_SERVER_VARIABLES is set", func() {
+		BeforeEach(func() {
+			app.env["HWC_INSTANCE_SERVER_VARIABLES"] = "true"
+		})
+
+		Context("when the URL Rewrite module isn't installed", func() {
+			BeforeEach(func() {
+				app.env["windir"] = fakeWindir(app.workingDirectoryPath, app.render())
+			})
+
+			It("fails", func() {
+				err, _ := app.newConfig()
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(HavePrefix("Instance metadata server variables require the URL Rewrite module"))
+			})
+		})
+
+		Context("when the URL Rewrite module is installed", func() {
+			BeforeEach(func() {
+				app.env["windir"] = fakeWindir(app.workingDirectoryPath, app.render(), `%windir%\system32\inetsrv\rewrite.dll`)
+			})
+
+			It("sets the metadata as server variables", func() {
+				config := renderInstanceMetadata()
+				Expect(config.SystemWebServer[0].CustomHeaders).To(BeEmpty())
+
+				rules := config.SystemWebServer[0].GlobalRules
+				Expect(rules).To(HaveLen(1))
+				Expect(rules[0].ServerVariables).To(HaveLen(3))
+				Expect(rules[0].ServerVariables[0].Name).To(Equal("CF_INSTANCE_INDEX"))
+				Expect(rules[0].ServerVariables[0].Value).To(Equal("3"))
+				Expect(rules[0].ServerVariables[2].Name).To(Equal("CF_APP_NAME"))
+			})
+		})
+	})
+})
@@ -0,0 +1,40 @@
+package hwcconfig
+
+import "fmt"
+
+// Metrics configures the sidecar HTTP listener that serves hwc's metrics in
+// the Prometheus text format on /metrics. The listener is off when Port is 0.
+// Request metrics are read from the site's W3C log, so enabling metrics
+// turns IIS logging on even when AccessLog is disabled.
+type Metrics struct {
+	Port uint64 `json:"port"`
+}
+
+func (m Metrics) Enabled() bool {
+	return m.Port != 0
+}
+
+// Address is the address the metrics listener binds to.
+func (m Metrics) Address() string {
+	return fmt.Sprintf(":%d", m.Port)
+}
+
+func (m *Metrics) loadEnv() error {
+	if err := envUint("HWC_METRICS_PORT", &m.Port); err != nil {
+		return err
+	}
+	return m.validate()
+}
+
+func (m *Metrics) validate() error {
+	if m.Port > 65535 {
+		return fmt.Errorf("Invalid metrics port: %d (must be at most 65535)", m.Port)
+	}
+	return nil
+}
+
+// HTTPLoggingEnabled reports whether IIS writes the site's W3C log, which
+// both the access log and the request metrics are read from.
+func (c *HwcConfig) HTTPLoggingEnabled() bool {
+	return c.AccessLog.Enabled() || c.Metrics.Enabled()
+}
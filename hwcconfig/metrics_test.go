@@ -0,0 +1,62 @@
+package hwcconfig_test
+
+import (
+	"encoding/xml"
+	"io/ioutil"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Metrics", func() {
+	app := newTestApp()
+
+	It("is disabled by default", func() {
+		err, hwcConfig := app.newConfig()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(hwcConfig.Metrics.Enabled()).To(BeFalse())
+		Expect(hwcConfig.HTTPLoggingEnabled()).To(BeFalse())
+	})
+
+	Context("when HWC_METRICS_PORT is set", func() {
+		BeforeEach(func() {
+			app.env["HWC_METRICS_PORT"] = "9100"
+		})
+
+		It("serves metrics on that port and turns on HTTP logging", func() {
+			err, hwcConfig := app.newConfig()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(hwcConfig.Metrics.Enabled()).To(BeTrue())
+			Expect(hwcConfig.Metrics.Address()).To(Equal(":9100"))
+			Expect(hwcConfig.AccessLog.Enabled()).To(BeFalse())
+
+			configFileContents, err := ioutil.ReadFile(hwcConfig.ApplicationHostConfigPath)
+			Expect(err).ToNot(HaveOccurred())
+			var config accessLogConfiguration
+			Expect(xml.Unmarshal(configFileContents, &config)).To(Succeed())
+			Expect(config.SystemWebServer.HTTPLogging.DontLog).To(Equal("false"))
+		})
+	})
+
+	Context("when the port is the app's PORT", func() {
+		BeforeEach(func() {
+			app.env["HWC_METRICS_PORT"] = "8080"
+		})
+
+		It("fails", func() {
+			err, _ := app.newConfig()
+			Expect(err).To(MatchError("Invalid metrics port: 8080 (must differ from PORT)"))
+		})
+	})
+
+	Context("when the port is out of range", func() {
+		BeforeEach(func() {
+			app.env["HWC_METRICS_PORT"] = "70000"
+		})
+
+		It("fails", func() {
+			err, _ := app.newConfig()
+			Expect(err).To(MatchError("Invalid metrics port: 70000 (must be at most 65535)"))
+		})
+	})
+})
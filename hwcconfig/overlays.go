@@ -0,0 +1,56 @@
+package hwcconfig
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	"code.cloudfoundry.org/hwc/overlay"
+)
+
+// Overlays names a directory of overlay files that patch the generated
+// ApplicationHost.config and Web.config, for settings hwc has no option
+// for. See the overlay package for their format.
+type Overlays struct {
+	Directory string `json:"directory"`
+
+	overlays []overlay.Overlay
+}
+
+func (o Overlays) Enabled() bool {
+	return o.Directory != ""
+}
+
+func (o *Overlays) loadEnv() error {
+	if s, ok := os.LookupEnv("HWC_CONFIG_OVERLAYS"); ok {
+		o.Directory = s
+	}
+	return o.load()
+}
+
+// load reads the overlays up front so a broken one fails before any
+// configuration is written.
+func (o *Overlays) load() error {
+	if !o.Enabled() {
+		return nil
+	}
+	info, err := os.Stat(o.Directory)
+	if err != nil {
+		return fmt.Errorf("Invalid config overlay directory: %v", err)
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("Invalid config overlay directory: %s is not a directory", o.Directory)
+	}
+	o.overlays, err = overlay.Load(o.Directory)
+	return err
+}
+
+// writeFile applies the overlays for target to data and writes the result to
+// path.
+func (o Overlays) writeFile(path, target string, data []byte) error {
+	data, err := overlay.Apply(o.overlays, target, data)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, data, 0644)
+}
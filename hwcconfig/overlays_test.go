@@ -0,0 +1,69 @@
+package hwcconfig_test
+
+import (
+	"io/ioutil"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Overlays", func() {
+	app := newTestApp()
+
+	var fixture = func(name string) string {
+		path, err := filepath.Abs(filepath.Join("..", "fixtures", "overlays", name))
+		Expect(err).ToNot(HaveOccurred())
+		return path
+	}
+
+	It("is disabled by default", func() {
+		err, hwcConfig := app.newConfig()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(hwcConfig.Overlays.Enabled()).To(BeFalse())
+	})
+
+	Context("when HWC_CONFIG_OVERLAYS names a directory of overlays", func() {
+		BeforeEach(func() {
+			app.env["HWC_CONFIG_OVERLAYS"] = fixture("valid")
+		})
+
+		It("applies them to the generated configs", func() {
+			err, hwcConfig := app.newConfig()
+			Expect(err).ToNot(HaveOccurred())
+
+			applicationHost, err := ioutil.ReadFile(hwcConfig.ApplicationHostConfigPath)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(string(applicationHost)).To(ContainSubstring(`<requestLimits maxAllowedContentLength="2097152" maxUrl="8192" maxQueryString="4096">`))
+			Expect(string(applicationHost)).To(ContainSubstring(`<mimeMap fileExtension=".avif" mimeType="image/avif" />`))
+			Expect(string(applicationHost)).ToNot(ContainSubstring("TRACEVerbHandler"))
+
+			webConfig, err := ioutil.ReadFile(hwcConfig.WebConfigPath)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(string(webConfig)).To(ContainSubstring(`<trust level="Medium" originUrl="" />`))
+		})
+	})
+
+	Context("when an overlay targets an element that doesn't exist", func() {
+		BeforeEach(func() {
+			app.env["HWC_CONFIG_OVERLAYS"] = fixture("unmatched")
+		})
+
+		It("fails naming the overlay and the path", func() {
+			err, _ := app.newConfig()
+			Expect(err).To(MatchError(ContainSubstring("missing.json: operation 1 (set system.webServer/handlers/add[@name='NoSuchHandler']) matched nothing in ApplicationHost.config")))
+		})
+	})
+
+	Context("when the directory doesn't exist", func() {
+		BeforeEach(func() {
+			app.env["HWC_CONFIG_OVERLAYS"] = filepath.Join(app.workingDirectoryPath, "missing")
+		})
+
+		It("fails before generating anything", func() {
+			err, _ := app.newConfig()
+			Expect(err).To(MatchError(ContainSubstring("Invalid config overlay directory")))
+			Expect(filepath.Join(app.workingDirectoryPath, "tmpPath", "config")).ToNot(BeADirectory())
+		})
+	})
+})
@@ -0,0 +1,181 @@
+package hwcconfig
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// loadOverrides layers operator settings on top of the defaults. Values from
+// the JSON document named by HWC_CONFIG_FILE are applied first, followed by
+// individual HWC_* environment variables.
+func (c *HwcConfig) loadOverrides() error {
+	if err := c.loadConfigFile(os.Getenv("HWC_CONFIG_FILE")); err != nil {
+		return err
+	}
+
+	if err := c.RequestFiltering.loadEnv(); err != nil {
+		return err
+	}
+	if err := c.HTTPErrors.loadEnv(); err != nil {
+		return err
+	}
+	if err := c.AccessLog.loadEnv(); err != nil {
+		return err
+	}
+	if err := c.FailedRequestTracing.loadEnv(); err != nil {
+		return err
+	}
+	if err := c.ServiceBindings.loadEnv(); err != nil {
+		return err
+	}
+	if err := c.EnvSubstitution.loadEnv(); err != nil {
+		return err
+	}
+	if err := c.InstanceMetadata.loadEnv(); err != nil {
+		return err
+	}
+	if err := c.SecurityHeaders.loadEnv(); err != nil {
+		return err
+	}
+	if err := c.HealthCheck.loadEnv(); err != nil {
+		return err
+	}
+	if err := c.Warmup.loadEnv(); err != nil {
+		return err
+	}
+	if err := c.Watchdog.loadEnv(); err != nil {
+		return err
+	}
+	if err := c.Metrics.loadEnv(); err != nil {
+		return err
+	}
+	if err := c.Overlays.loadEnv(); err != nil {
+		return err
+	}
+	if err := c.Hosting.loadEnv(); err != nil {
+		return err
+	}
+	if err := c.AspNetCore.loadEnv(); err != nil {
+		return err
+	}
+	if err := c.HTTPPlatform.loadEnv(); err != nil {
+		return err
+	}
+	if err := c.FastCGI.loadEnv(); err != nil {
+		return err
+	}
+	if err := c.WebSocket.loadEnv(); err != nil {
+		return err
+	}
+	return c.Compression.loadEnv()
+}
+
+func (c *HwcConfig) loadConfigFile(path string) error {
+	if path == "" {
+		return nil
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	overrides := struct {
+		RequestFiltering     *RequestFiltering     `json:"requestFiltering"`
+		HTTPErrors           *HTTPErrors           `json:"httpErrors"`
+		AccessLog            *AccessLog            `json:"accessLog"`
+		FailedRequestTracing *FailedRequestTracing `json:"failedRequestTracing"`
+		ServiceBindings      *ServiceBindings      `json:"serviceBindings"`
+		EnvSubstitution      *EnvSubstitution      `json:"envSubstitution"`
+		InstanceMetadata     *InstanceMetadata     `json:"instanceMetadata"`
+		SecurityHeaders      *SecurityHeaders      `json:"securityHeaders"`
+		HealthCheck          *HealthCheck          `json:"healthCheck"`
+		Warmup               *Warmup               `json:"warmup"`
+		Watchdog             *Watchdog             `json:"watchdog"`
+		Metrics              *Metrics              `json:"metrics"`
+		Overlays             *Overlays             `json:"overlays"`
+		Hosting              *Hosting              `json:"hosting"`
+		AspNetCore           *AspNetCore           `json:"aspNetCore"`
+		HTTPPlatform         *HTTPPlatform         `json:"httpPlatform"`
+		FastCGI              *FastCGI              `json:"fastCgi"`
+		WebSocket            *WebSocket            `json:"webSocket"`
+		Compression          *Compression          `json:"compression"`
+	}{
+		RequestFiltering:     &c.RequestFiltering,
+		HTTPErrors:           &c.HTTPErrors,
+		AccessLog:            &c.AccessLog,
+		FailedRequestTracing: &c.FailedRequestTracing,
+		ServiceBindings:      &c.ServiceBindings,
+		EnvSubstitution:      &c.EnvSubstitution,
+		InstanceMetadata:     &c.InstanceMetadata,
+		SecurityHeaders:      &c.SecurityHeaders,
+		HealthCheck:          &c.HealthCheck,
+		Warmup:               &c.Warmup,
+		Watchdog:             &c.Watchdog,
+		Metrics:              &c.Metrics,
+		Overlays:             &c.Overlays,
+		Hosting:              &c.Hosting,
+		AspNetCore:           &c.AspNetCore,
+		HTTPPlatform:         &c.HTTPPlatform,
+		FastCGI:              &c.FastCGI,
+		WebSocket:            &c.WebSocket,
+		Compression:          &c.Compression,
+	}
+
+	decoder := json.NewDecoder(file)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&overrides); err != nil {
+		return fmt.Errorf("Invalid HWC_CONFIG_FILE %s: %v", path, err)
+	}
+	return nil
+}
+
+func envBool(name string, value *bool) error {
+	s, ok := os.LookupEnv(name)
+	if !ok || s == "" {
+		return nil
+	}
+	b, err := strconv.ParseBool(s)
+	if err != nil {
+		return fmt.Errorf("Invalid value for %s: %s", name, s)
+	}
+	*value = b
+	return nil
+}
+
+func envUint(name string, value *uint64) error {
+	s, ok := os.LookupEnv(name)
+	if !ok || s == "" {
+		return nil
+	}
+	n, err := strconv.ParseUint(s, 10, 64)
+	if err != nil {
+		return fmt.Errorf("Invalid value for %s: %s", name, s)
+	}
+	*value = n
+	return nil
+}
+
+// envList reads a comma separated list. A variable that is set but empty
+// clears the list.
+func envList(name string, value *[]string) {
+	s, ok := os.LookupEnv(name)
+	if !ok {
+		return
+	}
+	*value = splitList(s)
+}
+
+func splitList(s string) []string {
+	var values []string
+	for _, v := range strings.Split(s, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			values = append(values, v)
+		}
+	}
+	return values
+}
@@ -0,0 +1,132 @@
+package hwcconfig
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"code.cloudfoundry.org/hwc/apphost"
+)
+
+// RequestFiltering is the host level <requestFiltering> policy. Apps may still
+// override it from their own Web.config.
+type RequestFiltering struct {
+	AllowDoubleEscaping     bool          `json:"allowDoubleEscaping"`
+	AllowHighBitCharacters  bool          `json:"allowHighBitCharacters"`
+	MaxAllowedContentLength uint64        `json:"maxAllowedContentLength"`
+	MaxURL                  uint64        `json:"maxUrl"`
+	MaxQueryString          uint64        `json:"maxQueryString"`
+	HeaderLimits            []HeaderLimit `json:"headerLimits"`
+	DenyURLSequences        []string      `json:"denyUrlSequences"`
+	DeniedFileExtensions    []string      `json:"deniedFileExtensions"`
+	DeniedVerbs             []string      `json:"deniedVerbs"`
+	HiddenSegments          []string      `json:"hiddenSegments"`
+}
+
+type HeaderLimit struct {
+	Header    string `json:"header"`
+	SizeLimit uint64 `json:"sizeLimit"`
+}
+
+func DefaultRequestFiltering() RequestFiltering {
+	return RequestFiltering{
+		AllowDoubleEscaping:     false,
+		AllowHighBitCharacters:  false,
+		MaxAllowedContentLength: 2097152,
+		MaxURL:                  260,
+		MaxQueryString:          2048,
+		DenyURLSequences:        []string{"..", "./", `\`, ":", "%", "&"},
+		DeniedFileExtensions: []string{
+			".asa", ".asax", ".ascx", ".master", ".skin", ".browser", ".sitemap", ".config",
+			".cs", ".csproj", ".vb", ".vbproj", ".webinfo", ".licx", ".resx", ".resources",
+			".mdb", ".vjsproj", ".java", ".jsl", ".ldb", ".dsdgm", ".ssdgm", ".lsad", ".ssmap",
+			".cd", ".dsprototype", ".lsaprototype", ".sdm", ".sdmDocument", ".mdf", ".ldf",
+			".ad", ".dd", ".ldd", ".sd", ".adprototype", ".lddprototype", ".exclude",
+			".refresh", ".compiled", ".msgx", ".vsdisco", ".rules",
+		},
+		HiddenSegments: []string{
+			"web.config", "bin", "App_code", "App_GlobalResources", "App_LocalResources",
+			"App_WebReferences", "App_Data", "App_Browsers", ".iishost",
+		},
+	}
+}
+
+func (rf *RequestFiltering) loadEnv() error {
+	if err := envBool("HWC_REQUEST_FILTERING_ALLOW_DOUBLE_ESCAPING", &rf.AllowDoubleEscaping); err != nil {
+		return err
+	}
+	if err := envBool("HWC_REQUEST_FILTERING_ALLOW_HIGH_BIT_CHARACTERS", &rf.AllowHighBitCharacters); err != nil {
+		return err
+	}
+	if err := envUint("HWC_REQUEST_FILTERING_MAX_ALLOWED_CONTENT_LENGTH", &rf.MaxAllowedContentLength); err != nil {
+		return err
+	}
+	if err := envUint("HWC_REQUEST_FILTERING_MAX_URL", &rf.MaxURL); err != nil {
+		return err
+	}
+	if err := envUint("HWC_REQUEST_FILTERING_MAX_QUERY_STRING", &rf.MaxQueryString); err != nil {
+		return err
+	}
+	if s, ok := os.LookupEnv("HWC_REQUEST_FILTERING_HEADER_LIMITS"); ok {
+		limits, err := parseHeaderLimits(s)
+		if err != nil {
+			return fmt.Errorf("Invalid value for HWC_REQUEST_FILTERING_HEADER_LIMITS: %v", err)
+		}
+		rf.HeaderLimits = limits
+	}
+	envList("HWC_REQUEST_FILTERING_DENY_URL_SEQUENCES", &rf.DenyURLSequences)
+	envList("HWC_REQUEST_FILTERING_DENIED_FILE_EXTENSIONS", &rf.DeniedFileExtensions)
+	envList("HWC_REQUEST_FILTERING_DENIED_VERBS", &rf.DeniedVerbs)
+	envList("HWC_REQUEST_FILTERING_HIDDEN_SEGMENTS", &rf.HiddenSegments)
+	return nil
+}
+
+// parseHeaderLimits parses a list such as "Content-Type=100,Cookie=4096"
+func parseHeaderLimits(s string) ([]HeaderLimit, error) {
+	var limits []HeaderLimit
+	for _, entry := range splitList(s) {
+		parts := strings.SplitN(entry, "=", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("%s is not of the form <header>=<sizeLimit>", entry)
+		}
+		size, err := strconv.ParseUint(strings.TrimSpace(parts[1]), 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%s is not of the form <header>=<sizeLimit>", entry)
+		}
+		limits = append(limits, HeaderLimit{Header: strings.TrimSpace(parts[0]), SizeLimit: size})
+	}
+	return limits, nil
+}
+
+// applicationHost is the <requestFiltering> element for the policy.
+func (rf RequestFiltering) applicationHost() apphost.RequestFiltering {
+	section := apphost.RequestFiltering{
+		AllowDoubleEscaping:    rf.AllowDoubleEscaping,
+		AllowHighBitCharacters: rf.AllowHighBitCharacters,
+		FileExtensions:         apphost.FileExtensions{AllowUnlisted: true, ApplyToWebDAV: true},
+		RequestLimits: apphost.RequestLimits{
+			MaxAllowedContentLength: rf.MaxAllowedContentLength,
+			MaxURL:                  rf.MaxURL,
+			MaxQueryString:          rf.MaxQueryString,
+		},
+		Verbs:          apphost.Verbs{AllowUnlisted: true, ApplyToWebDAV: true},
+		HiddenSegments: apphost.HiddenSegments{ApplyToWebDAV: true},
+	}
+	for _, sequence := range rf.DenyURLSequences {
+		section.DenyURLSequences = append(section.DenyURLSequences, apphost.DenyURLSequence{Sequence: sequence})
+	}
+	for _, extension := range rf.DeniedFileExtensions {
+		section.FileExtensions.FileExtensions = append(section.FileExtensions.FileExtensions, apphost.FileExtension{FileExtension: extension, Allowed: false})
+	}
+	for _, limit := range rf.HeaderLimits {
+		section.RequestLimits.HeaderLimits = append(section.RequestLimits.HeaderLimits, apphost.HeaderLimit(limit))
+	}
+	for _, verb := range rf.DeniedVerbs {
+		section.Verbs.Verbs = append(section.Verbs.Verbs, apphost.Verb{Verb: verb, Allowed: false})
+	}
+	for _, segment := range rf.HiddenSegments {
+		section.HiddenSegments.Segments = append(section.HiddenSegments.Segments, apphost.HiddenSegment{Segment: segment})
+	}
+	return section
+}
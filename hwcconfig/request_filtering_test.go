@@ -0,0 +1,170 @@
+package hwcconfig_test
+
+import (
+	"encoding/xml"
+	"io/ioutil"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"code.cloudfoundry.org/hwc/hwcconfig"
+)
+
+type requestFilteringConfiguration struct {
+	SystemWebServer struct {
+		Security struct {
+			RequestFiltering struct {
+				AllowDoubleEscaping    string `xml:"allowDoubleEscaping,attr"`
+				AllowHighBitCharacters string `xml:"allowHighBitCharacters,attr"`
+				DenyURLSequences       []struct {
+					Sequence string `xml:"sequence,attr"`
+				} `xml:"denyUrlSequences>add"`
+				FileExtensions []struct {
+					FileExtension string `xml:"fileExtension,attr"`
+					Allowed       string `xml:"allowed,attr"`
+				} `xml:"fileExtensions>add"`
+				RequestLimits struct {
+					MaxAllowedContentLength string `xml:"maxAllowedContentLength,attr"`
+					MaxURL                  string `xml:"maxUrl,attr"`
+					MaxQueryString          string `xml:"maxQueryString,attr"`
+					HeaderLimits            []struct {
+						Header    string `xml:"header,attr"`
+						SizeLimit string `xml:"sizeLimit,attr"`
+					} `xml:"headerLimits>add"`
+				} `xml:"requestLimits"`
+				Verbs []struct {
+					Verb    string `xml:"verb,attr"`
+					Allowed string `xml:"allowed,attr"`
+				} `xml:"verbs>add"`
+				HiddenSegments []struct {
+					Segment string `xml:"segment,attr"`
+				} `xml:"hiddenSegments>add"`
+			} `xml:"requestFiltering"`
+		} `xml:"security"`
+	} `xml:"system.webServer"`
+}
+
+var _ = Describe("RequestFiltering", func() {
+	app := newTestApp()
+
+	var renderRequestFiltering = func() (*hwcconfig.HwcConfig, requestFilteringConfiguration) {
+		err, hwcConfig := app.newConfig()
+		Expect(err).ToNot(HaveOccurred())
+
+		configFileContents, err := ioutil.ReadFile(hwcConfig.ApplicationHostConfigPath)
+		Expect(err).ToNot(HaveOccurred())
+
+		var config requestFilteringConfiguration
+		Expect(xml.Unmarshal(configFileContents, &config)).To(Succeed())
+		return hwcConfig, config
+	}
+
+	Context("with no overrides", func() {
+		It("renders the default request filtering policy", func() {
+			hwcConfig, config := renderRequestFiltering()
+			Expect(hwcConfig.RequestFiltering).To(Equal(hwcconfig.DefaultRequestFiltering()))
+
+			rf := config.SystemWebServer.Security.RequestFiltering
+			Expect(rf.AllowDoubleEscaping).To(Equal("false"))
+			Expect(rf.AllowHighBitCharacters).To(Equal("false"))
+			Expect(rf.RequestLimits.MaxAllowedContentLength).To(Equal("2097152"))
+			Expect(rf.RequestLimits.MaxURL).To(Equal("260"))
+			Expect(rf.RequestLimits.MaxQueryString).To(Equal("2048"))
+			Expect(rf.RequestLimits.HeaderLimits).To(BeEmpty())
+			Expect(rf.Verbs).To(BeEmpty())
+
+			var sequences []string
+			for _, add := range rf.DenyURLSequences {
+				sequences = append(sequences, add.Sequence)
+			}
+			Expect(sequences).To(Equal([]string{"..", "./", `\`, ":", "%", "&"}))
+
+			Expect(rf.FileExtensions).To(HaveLen(44))
+			Expect(rf.FileExtensions[0].FileExtension).To(Equal(".asa"))
+			Expect(rf.FileExtensions[0].Allowed).To(Equal("false"))
+
+			Expect(rf.HiddenSegments).To(HaveLen(9))
+			Expect(rf.HiddenSegments[0].Segment).To(Equal("web.config"))
+		})
+	})
+
+	Context("when HWC_REQUEST_FILTERING_* environment variables are set", func() {
+		BeforeEach(func() {
+			app.env["HWC_REQUEST_FILTERING_ALLOW_DOUBLE_ESCAPING"] = "true"
+			app.env["HWC_REQUEST_FILTERING_MAX_ALLOWED_CONTENT_LENGTH"] = "31457280"
+			app.env["HWC_REQUEST_FILTERING_MAX_URL"] = "4096"
+			app.env["HWC_REQUEST_FILTERING_DENY_URL_SEQUENCES"] = "..,./"
+			app.env["HWC_REQUEST_FILTERING_DENIED_VERBS"] = "TRACE, TRACK"
+			app.env["HWC_REQUEST_FILTERING_HEADER_LIMITS"] = "Cookie=4096"
+		})
+
+		It("renders the overridden values", func() {
+			_, config := renderRequestFiltering()
+
+			rf := config.SystemWebServer.Security.RequestFiltering
+			Expect(rf.AllowDoubleEscaping).To(Equal("true"))
+			Expect(rf.RequestLimits.MaxAllowedContentLength).To(Equal("31457280"))
+			Expect(rf.RequestLimits.MaxURL).To(Equal("4096"))
+			Expect(rf.RequestLimits.MaxQueryString).To(Equal("2048"))
+			Expect(rf.DenyURLSequences).To(HaveLen(2))
+			Expect(rf.Verbs).To(HaveLen(2))
+			Expect(rf.Verbs[1].Verb).To(Equal("TRACK"))
+			Expect(rf.Verbs[1].Allowed).To(Equal("false"))
+			Expect(rf.RequestLimits.HeaderLimits).To(HaveLen(1))
+			Expect(rf.RequestLimits.HeaderLimits[0].Header).To(Equal("Cookie"))
+			Expect(rf.RequestLimits.HeaderLimits[0].SizeLimit).To(Equal("4096"))
+		})
+
+		Context("and a value is invalid", func() {
+			BeforeEach(func() {
+				app.env["HWC_REQUEST_FILTERING_MAX_URL"] = "lots"
+			})
+
+			It("returns an error", func() {
+				err, _ := app.newConfig()
+				Expect(err).To(MatchError("Invalid value for HWC_REQUEST_FILTERING_MAX_URL: lots"))
+			})
+		})
+	})
+
+	Context("when HWC_CONFIG_FILE sets a request filtering policy", func() {
+		BeforeEach(func() {
+			configFile := filepath.Join(app.workingDirectoryPath, "hwc.json")
+			Expect(ioutil.WriteFile(configFile, []byte(`{
+				"requestFiltering": {
+					"maxQueryString": 8192,
+					"hiddenSegments": ["bin", "App_Data"]
+				}
+			}`), 0666)).To(Succeed())
+
+			app.env["HWC_CONFIG_FILE"] = configFile
+			app.env["HWC_REQUEST_FILTERING_MAX_URL"] = "1024"
+		})
+
+		It("layers the file and then the environment over the defaults", func() {
+			hwcConfig, config := renderRequestFiltering()
+
+			Expect(hwcConfig.RequestFiltering.MaxAllowedContentLength).To(Equal(uint64(2097152)))
+			Expect(hwcConfig.RequestFiltering.MaxQueryString).To(Equal(uint64(8192)))
+			Expect(hwcConfig.RequestFiltering.MaxURL).To(Equal(uint64(1024)))
+
+			rf := config.SystemWebServer.Security.RequestFiltering
+			Expect(rf.HiddenSegments).To(HaveLen(2))
+			Expect(rf.HiddenSegments[1].Segment).To(Equal("App_Data"))
+			Expect(rf.DenyURLSequences).To(HaveLen(6))
+		})
+
+		Context("and the file contains unknown settings", func() {
+			BeforeEach(func() {
+				Expect(ioutil.WriteFile(app.env["HWC_CONFIG_FILE"], []byte(`{"requestFiltering": {"maxUrls": 1}}`), 0666)).To(Succeed())
+			})
+
+			It("returns an error", func() {
+				err, _ := app.newConfig()
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("Invalid HWC_CONFIG_FILE"))
+			})
+		})
+	})
+})
@@ -0,0 +1,118 @@
+package hwcconfig
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// SecurityHeaders is the response header policy every app inherits. Headers
+// are added as host level custom headers. RemoveServerHeader and
+// RemoveVersionHeader stop IIS and ASP.NET from sending the Server and
+// X-AspNet-Version headers.
+type SecurityHeaders struct {
+	Headers             []ResponseHeader `json:"headers"`
+	RemoveServerHeader  bool             `json:"removeServerHeader"`
+	RemoveVersionHeader bool             `json:"removeVersionHeader"`
+}
+
+type ResponseHeader struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+const responseHeaderEnvPrefix = "HWC_RESPONSE_HEADER_"
+
+var headerNamePattern = regexp.MustCompile("^[!#$%&'*+.^_`|~0-9A-Za-z-]+$")
+
+// baselineSecurityHeaders are added by HWC_SECURITY_HEADERS.
+var baselineSecurityHeaders = []ResponseHeader{
+	{Name: "Strict-Transport-Security", Value: "max-age=31536000"},
+	{Name: "X-Content-Type-Options", Value: "nosniff"},
+	{Name: "X-Frame-Options", Value: "SAMEORIGIN"},
+	{Name: "Referrer-Policy", Value: "strict-origin-when-cross-origin"},
+}
+
+func (sh *SecurityHeaders) loadEnv() error {
+	var baseline bool
+	if err := envBool("HWC_SECURITY_HEADERS", &baseline); err != nil {
+		return err
+	}
+	if baseline {
+		for _, header := range baselineSecurityHeaders {
+			if _, ok := sh.header(header.Name); !ok {
+				sh.Headers = append(sh.Headers, header)
+			}
+		}
+		sh.RemoveServerHeader = true
+		sh.RemoveVersionHeader = true
+	}
+
+	var names []string
+	for _, entry := range os.Environ() {
+		if parts := strings.SplitN(entry, "=", 2); strings.HasPrefix(parts[0], responseHeaderEnvPrefix) {
+			names = append(names, parts[0])
+		}
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		sh.setHeader(headerName(strings.TrimPrefix(name, responseHeaderEnvPrefix)), os.Getenv(name))
+	}
+
+	if err := envBool("HWC_REMOVE_SERVER_HEADER", &sh.RemoveServerHeader); err != nil {
+		return err
+	}
+	if err := envBool("HWC_REMOVE_VERSION_HEADER", &sh.RemoveVersionHeader); err != nil {
+		return err
+	}
+	return sh.validate()
+}
+
+func (sh *SecurityHeaders) validate() error {
+	for _, header := range sh.Headers {
+		if !headerNamePattern.MatchString(header.Name) {
+			return fmt.Errorf("Invalid response header name: %q", header.Name)
+		}
+		if strings.ContainsAny(header.Value, "\r\n") {
+			return fmt.Errorf("Invalid value for response header %s: values must be a single line", header.Name)
+		}
+	}
+	return nil
+}
+
+func (sh *SecurityHeaders) header(name string) (int, bool) {
+	for i, header := range sh.Headers {
+		if strings.EqualFold(header.Name, name) {
+			return i, true
+		}
+	}
+	return 0, false
+}
+
+// setHeader replaces the value of the named header, or adds it. An empty
+// value removes the header from the policy.
+func (sh *SecurityHeaders) setHeader(name, value string) {
+	i, ok := sh.header(name)
+	switch {
+	case ok && value == "":
+		sh.Headers = append(sh.Headers[:i], sh.Headers[i+1:]...)
+	case ok:
+		sh.Headers[i].Value = value
+	case value != "":
+		sh.Headers = append(sh.Headers, ResponseHeader{Name: name, Value: value})
+	}
+}
+
+// headerName turns the suffix of a HWC_RESPONSE_HEADER_ variable, such as
+// CONTENT_SECURITY_POLICY, into a header name: Content-Security-Policy.
+func headerName(s string) string {
+	words := strings.Split(strings.ToLower(s), "_")
+	for i, word := range words {
+		if word != "" {
+			words[i] = strings.ToUpper(word[:1]) + word[1:]
+		}
+	}
+	return strings.Join(words, "-")
+}
@@ -0,0 +1,111 @@
+package hwcconfig_test
+
+import (
+	"encoding/xml"
+	"io/ioutil"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+type responseHeader struct {
+	Name  string `xml:"name,attr"`
+	Value string `xml:"value,attr"`
+}
+
+type securityHeadersConfiguration struct {
+	SystemWebServer []struct {
+		CustomHeaders    []responseHeader `xml:"httpProtocol>customHeaders>add"`
+		RequestFiltering struct {
+			RemoveServerHeader *string `xml:"removeServerHeader,attr"`
+		} `xml:"security>requestFiltering"`
+	} `xml:"system.webServer"`
+}
+
+type securityHeadersWebConfiguration struct {
+	SystemWeb struct {
+		HTTPRuntime *struct {
+			EnableVersionHeader string `xml:"enableVersionHeader,attr"`
+		} `xml:"httpRuntime"`
+	} `xml:"system.web"`
+}
+
+var _ = Describe("SecurityHeaders", func() {
+	app := newTestApp()
+
+	var renderSecurityHeaders = func() (securityHeadersConfiguration, securityHeadersWebConfiguration) {
+		err, hwcConfig := app.newConfig()
+		Expect(err).ToNot(HaveOccurred())
+
+		configFileContents, err := ioutil.ReadFile(hwcConfig.ApplicationHostConfigPath)
+		Expect(err).ToNot(HaveOccurred())
+		var config securityHeadersConfiguration
+		Expect(xml.Unmarshal(configFileContents, &config)).To(Succeed())
+
+		webConfigFileContents, err := ioutil.ReadFile(hwcConfig.WebConfigPath)
+		Expect(err).ToNot(HaveOccurred())
+		var webConfig securityHeadersWebConfiguration
+		Expect(xml.Unmarshal(webConfigFileContents, &webConfig)).To(Succeed())
+		return config, webConfig
+	}
+
+	It("adds no headers and keeps the Server and X-AspNet-Version headers by default", func() {
+		config, webConfig := renderSecurityHeaders()
+		Expect(config.SystemWebServer[0].CustomHeaders).To(BeEmpty())
+		Expect(config.SystemWebServer[0].RequestFiltering.RemoveServerHeader).To(BeNil())
+		Expect(webConfig.SystemWeb.HTTPRuntime).To(BeNil())
+	})
+
+	Context("when HWC_SECURITY_HEADERS is set", func() {
+		BeforeEach(func() {
+			app.env["HWC_SECURITY_HEADERS"] = "true"
+		})
+
+		It("applies the baseline policy", func() {
+			config, webConfig := renderSecurityHeaders()
+			Expect(config.SystemWebServer[0].CustomHeaders).To(Equal([]responseHeader{
+				{Name: "Strict-Transport-Security", Value: "max-age=31536000"},
+				{Name: "X-Content-Type-Options", Value: "nosniff"},
+				{Name: "X-Frame-Options", Value: "SAMEORIGIN"},
+				{Name: "Referrer-Policy", Value: "strict-origin-when-cross-origin"},
+			}))
+			Expect(*config.SystemWebServer[0].RequestFiltering.RemoveServerHeader).To(Equal("true"))
+			Expect(webConfig.SystemWeb.HTTPRuntime.EnableVersionHeader).To(Equal("false"))
+		})
+
+		Context("when individual headers are set", func() {
+			BeforeEach(func() {
+				app.env["HWC_RESPONSE_HEADER_STRICT_TRANSPORT_SECURITY"] = "max-age=63072000; includeSubDomains"
+				app.env["HWC_RESPONSE_HEADER_X_FRAME_OPTIONS"] = ""
+				app.env["HWC_RESPONSE_HEADER_CONTENT_SECURITY_POLICY"] = "default-src 'self'"
+				app.env["HWC_REMOVE_VERSION_HEADER"] = "false"
+			})
+
+			It("overrides the baseline", func() {
+				config, webConfig := renderSecurityHeaders()
+				Expect(config.SystemWebServer[0].CustomHeaders).To(Equal([]responseHeader{
+					{Name: "Strict-Transport-Security", Value: "max-age=63072000; includeSubDomains"},
+					{Name: "X-Content-Type-Options", Value: "nosniff"},
+					{Name: "Referrer-Policy", Value: "strict-origin-when-cross-origin"},
+					{Name: "Content-Security-Policy", Value: "default-src 'self'"},
+				}))
+				Expect(*config.SystemWebServer[0].RequestFiltering.RemoveServerHeader).To(Equal("true"))
+				Expect(webConfig.SystemWeb.HTTPRuntime).To(BeNil())
+			})
+		})
+	})
+
+	Context("when HWC_CONFIG_FILE sets a header with an invalid name", func() {
+		BeforeEach(func() {
+			configFile := filepath.Join(app.workingDirectoryPath, "hwc.json")
+			Expect(ioutil.WriteFile(configFile, []byte(`{"securityHeaders": {"headers": [{"name": "Bad Header", "value": "x"}]}}`), 0666)).To(Succeed())
+			app.env["HWC_CONFIG_FILE"] = configFile
+		})
+
+		It("fails", func() {
+			err, _ := app.newConfig()
+			Expect(err).To(MatchError(`Invalid response header name: "Bad Header"`))
+		})
+	})
+})
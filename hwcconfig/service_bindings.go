@@ -0,0 +1,44 @@
+package hwcconfig
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"code.cloudfoundry.org/hwc/servicebindings"
+)
+
+// ServiceBindings controls whether hwc merges the app's bound services into
+// the <connectionStrings> and <appSettings> of its Web.config. Rules map
+// services that don't carry a connectionString credential.
+type ServiceBindings struct {
+	Enabled bool                   `json:"enabled"`
+	Rules   []servicebindings.Rule `json:"rules"`
+}
+
+func (sb *ServiceBindings) loadEnv() error {
+	if err := envBool("HWC_SERVICE_BINDINGS", &sb.Enabled); err != nil {
+		return err
+	}
+	if path := os.Getenv("HWC_SERVICE_BINDINGS_FILE"); path != "" {
+		return sb.loadRules(path)
+	}
+	return nil
+}
+
+func (sb *ServiceBindings) loadRules(path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	var rules []servicebindings.Rule
+	decoder := json.NewDecoder(file)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&rules); err != nil {
+		return fmt.Errorf("Invalid HWC_SERVICE_BINDINGS_FILE %s: %v", path, err)
+	}
+	sb.Rules = rules
+	return nil
+}
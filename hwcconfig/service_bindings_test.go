@@ -0,0 +1,58 @@
+package hwcconfig_test
+
+import (
+	"io/ioutil"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"code.cloudfoundry.org/hwc/servicebindings"
+)
+
+var _ = Describe("ServiceBindings", func() {
+	app := newTestApp()
+
+	var rulesFile string
+
+	BeforeEach(func() {
+		rulesFile = filepath.Join(app.workingDirectoryPath, "bindings.json")
+	})
+
+	It("is disabled by default", func() {
+		err, hwcConfig := app.newConfig()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(hwcConfig.ServiceBindings.Enabled).To(BeFalse())
+		Expect(hwcConfig.ServiceBindings.Rules).To(BeEmpty())
+	})
+
+	Context("when HWC_SERVICE_BINDINGS_FILE is set", func() {
+		BeforeEach(func() {
+			Expect(ioutil.WriteFile(rulesFile, []byte(`[{"tag": "redis", "appSettings": {"RedisHost": "${host}"}}]`), 0666)).To(Succeed())
+			app.env["HWC_SERVICE_BINDINGS"] = "true"
+			app.env["HWC_SERVICE_BINDINGS_FILE"] = rulesFile
+		})
+
+		It("reads the rules from it", func() {
+			err, hwcConfig := app.newConfig()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(hwcConfig.ServiceBindings.Enabled).To(BeTrue())
+			Expect(hwcConfig.ServiceBindings.Rules).To(Equal([]servicebindings.Rule{
+				{Tag: "redis", AppSettings: map[string]string{"RedisHost": "${host}"}},
+			}))
+		})
+	})
+
+	Context("when a rule has unknown fields", func() {
+		BeforeEach(func() {
+			Expect(ioutil.WriteFile(rulesFile, []byte(`[{"tags": ["redis"]}]`), 0666)).To(Succeed())
+			app.env["HWC_SERVICE_BINDINGS_FILE"] = rulesFile
+		})
+
+		It("fails", func() {
+			err, _ := app.newConfig()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(HavePrefix("Invalid HWC_SERVICE_BINDINGS_FILE " + rulesFile))
+		})
+	})
+})
@@ -0,0 +1,62 @@
+package hwcconfig
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+// Warmup lists the app paths hwc requests once IIS has started. When
+// DelayServerStarted is set, hwc only reports that the server started once
+// the warm-up requests have completed.
+type Warmup struct {
+	Paths              []string `json:"paths"`
+	Host               string   `json:"host"`
+	Concurrency        uint64   `json:"concurrency"`
+	Timeout            Duration `json:"timeout"`
+	DelayServerStarted bool     `json:"delayServerStarted"`
+}
+
+func DefaultWarmup() Warmup {
+	return Warmup{
+		Concurrency: 1,
+		Timeout:     Duration(2 * time.Minute),
+	}
+}
+
+func (w Warmup) Enabled() bool {
+	return len(w.Paths) > 0
+}
+
+func (w *Warmup) loadEnv() error {
+	envList("HWC_WARMUP_PATHS", &w.Paths)
+	if s, ok := os.LookupEnv("HWC_WARMUP_HOST"); ok {
+		w.Host = s
+	}
+	if err := envUint("HWC_WARMUP_CONCURRENCY", &w.Concurrency); err != nil {
+		return err
+	}
+	if err := envDuration("HWC_WARMUP_TIMEOUT", &w.Timeout); err != nil {
+		return err
+	}
+	if err := envBool("HWC_WARMUP_DELAY_SERVER_STARTED", &w.DelayServerStarted); err != nil {
+		return err
+	}
+	return w.validate()
+}
+
+func (w *Warmup) validate() error {
+	for _, path := range w.Paths {
+		if !strings.HasPrefix(path, "/") {
+			return fmt.Errorf("Invalid warmup path: %s (must start with /)", path)
+		}
+	}
+	if w.Concurrency == 0 {
+		return fmt.Errorf("Invalid warmup concurrency: 0 (must be at least 1)")
+	}
+	if w.Timeout <= 0 {
+		return fmt.Errorf("Invalid warmup timeout: %s (must be positive)", w.Timeout)
+	}
+	return nil
+}
@@ -0,0 +1,55 @@
+package hwcconfig_test
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"code.cloudfoundry.org/hwc/hwcconfig"
+)
+
+var _ = Describe("Warmup", func() {
+	app := newTestApp()
+
+	It("is disabled by default", func() {
+		err, hwcConfig := app.newConfig()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(hwcConfig.Warmup.Enabled()).To(BeFalse())
+		Expect(hwcConfig.Warmup.Concurrency).To(Equal(uint64(1)))
+		Expect(time.Duration(hwcConfig.Warmup.Timeout)).To(Equal(2 * time.Minute))
+	})
+
+	Context("when configured from the environment", func() {
+		BeforeEach(func() {
+			app.env["HWC_WARMUP_PATHS"] = "/, /api/products?page=1"
+			app.env["HWC_WARMUP_HOST"] = "myapp.example.com"
+			app.env["HWC_WARMUP_CONCURRENCY"] = "4"
+			app.env["HWC_WARMUP_TIMEOUT"] = "45s"
+			app.env["HWC_WARMUP_DELAY_SERVER_STARTED"] = "true"
+		})
+
+		It("warms up the given paths", func() {
+			err, hwcConfig := app.newConfig()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(hwcConfig.Warmup).To(Equal(hwcconfig.Warmup{
+				Paths:              []string{"/", "/api/products?page=1"},
+				Host:               "myapp.example.com",
+				Concurrency:        4,
+				Timeout:            hwcconfig.Duration(45 * time.Second),
+				DelayServerStarted: true,
+			}))
+		})
+	})
+
+	Context("when the concurrency is zero", func() {
+		BeforeEach(func() {
+			app.env["HWC_WARMUP_CONCURRENCY"] = "0"
+		})
+
+		It("fails", func() {
+			err, _ := app.newConfig()
+			Expect(err).To(MatchError("Invalid warmup concurrency: 0 (must be at least 1)"))
+		})
+	})
+})
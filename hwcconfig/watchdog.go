@@ -0,0 +1,49 @@
+package hwcconfig
+
+import (
+	"fmt"
+	"time"
+)
+
+// Watchdog configures the supervisor that makes hwc exit when the app stops
+// responding. It probes the health check path, or the app's root when no
+// health check is configured, every Interval. hwc shuts down after
+// FailureThreshold failed probes in a row, or as soon as the app writes one
+// of Markers to stderr.
+type Watchdog struct {
+	Enabled          bool     `json:"enabled"`
+	Interval         Duration `json:"interval"`
+	FailureThreshold uint64   `json:"failureThreshold"`
+	Markers          []string `json:"markers"`
+}
+
+func DefaultWatchdog() Watchdog {
+	return Watchdog{
+		Interval:         Duration(10 * time.Second),
+		FailureThreshold: 3,
+	}
+}
+
+func (w *Watchdog) loadEnv() error {
+	if err := envBool("HWC_WATCHDOG", &w.Enabled); err != nil {
+		return err
+	}
+	if err := envDuration("HWC_WATCHDOG_INTERVAL", &w.Interval); err != nil {
+		return err
+	}
+	if err := envUint("HWC_WATCHDOG_FAILURE_THRESHOLD", &w.FailureThreshold); err != nil {
+		return err
+	}
+	envList("HWC_WATCHDOG_MARKERS", &w.Markers)
+	return w.validate()
+}
+
+func (w *Watchdog) validate() error {
+	if w.Interval <= 0 {
+		return fmt.Errorf("Invalid watchdog interval: %s (must be positive)", w.Interval)
+	}
+	if w.FailureThreshold == 0 {
+		return fmt.Errorf("Invalid watchdog failureThreshold: 0 (must be at least 1)")
+	}
+	return nil
+}
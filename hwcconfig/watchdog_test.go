@@ -0,0 +1,54 @@
+package hwcconfig_test
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"code.cloudfoundry.org/hwc/hwcconfig"
+)
+
+var _ = Describe("Watchdog", func() {
+	app := newTestApp()
+
+	It("is disabled by default", func() {
+		err, hwcConfig := app.newConfig()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(hwcConfig.Watchdog).To(Equal(hwcconfig.Watchdog{
+			Interval:         hwcconfig.Duration(10 * time.Second),
+			FailureThreshold: 3,
+		}))
+	})
+
+	Context("when configured from the environment", func() {
+		BeforeEach(func() {
+			app.env["HWC_WATCHDOG"] = "true"
+			app.env["HWC_WATCHDOG_INTERVAL"] = "30s"
+			app.env["HWC_WATCHDOG_FAILURE_THRESHOLD"] = "5"
+			app.env["HWC_WATCHDOG_MARKERS"] = "StackOverflowException,OutOfMemoryException"
+		})
+
+		It("sets the thresholds and markers", func() {
+			err, hwcConfig := app.newConfig()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(hwcConfig.Watchdog).To(Equal(hwcconfig.Watchdog{
+				Enabled:          true,
+				Interval:         hwcconfig.Duration(30 * time.Second),
+				FailureThreshold: 5,
+				Markers:          []string{"StackOverflowException", "OutOfMemoryException"},
+			}))
+		})
+	})
+
+	Context("when the failure threshold is zero", func() {
+		BeforeEach(func() {
+			app.env["HWC_WATCHDOG_FAILURE_THRESHOLD"] = "0"
+		})
+
+		It("fails", func() {
+			err, _ := app.newConfig()
+			Expect(err).To(MatchError("Invalid watchdog failureThreshold: 0 (must be at least 1)"))
+		})
+	})
+})
@@ -1,22 +1,30 @@
 package hwcconfig
 
 import (
-	"os"
+	"bytes"
+	"encoding/xml"
 	"text/template"
+
+	"code.cloudfoundry.org/hwc/overlay"
 )
 
 func (c *HwcConfig) generateWebConfig() error {
-	file, err := os.Create(c.WebConfigPath)
-	if err != nil {
+	var buf bytes.Buffer
+	var tmpl = template.Must(template.New("webconfig").Funcs(template.FuncMap{"xml": escapeXML}).Parse(webConfigTemplate))
+	if err := tmpl.Execute(&buf, c); err != nil {
 		return err
 	}
-	defer file.Close()
+	return c.Overlays.writeFile(c.WebConfigPath, overlay.Web, buf.Bytes())
+}
 
-	var tmpl = template.Must(template.New("webconfig").Parse(webConfigTemplate))
-	if err := tmpl.Execute(file, c); err != nil {
-		return err
+// escapeXML makes s safe to use as element text or as a quoted attribute
+// value. Every value the template interpolates must go through it.
+func escapeXML(s string) (string, error) {
+	var buf bytes.Buffer
+	if err := xml.EscapeText(&buf, []byte(s)); err != nil {
+		return "", err
 	}
-	return nil
+	return buf.String(), nil
 }
 
 const webConfigTemplate = `<?xml version="1.0" encoding="UTF-8"?>
@@ -102,7 +110,11 @@ const webConfigTemplate = `<?xml version="1.0" encoding="UTF-8"?>
             <add alias="downlevel" userAgent="Generic Downlevel" />
         </clientTarget>
 
-				<compilation tempDirectory="{{.TempDirectory}}">
+        {{if .SecurityHeaders.RemoveVersionHeader}}
+        <httpRuntime enableVersionHeader="false" />
+        {{end}}
+
+				<compilation tempDirectory="{{xml .TempDirectory}}">
             <assemblies>
                 <add assembly="mscorlib" />
                 <add assembly="Microsoft.CSharp, Version=4.0.0.0, Culture=neutral, PublicKeyToken=b03f5f7f11d50a3a" />
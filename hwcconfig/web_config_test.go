@@ -0,0 +1,46 @@
+package hwcconfig_test
+
+import (
+	"encoding/xml"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"code.cloudfoundry.org/hwc/hwcconfig"
+)
+
+var _ = Describe("WebConfig", func() {
+	var workingDirectoryPath string
+
+	BeforeEach(func() {
+		var err error
+		workingDirectoryPath, err = ioutil.TempDir("", "hwcconfig_test")
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		_ = os.RemoveAll(workingDirectoryPath)
+	})
+
+	It("escapes the temp directory so the config parses back to the same value", func() {
+		tmpPath := filepath.Join(workingDirectoryPath, "R&D's files", "tmpPath")
+
+		err, hwcConfig := hwcconfig.New(8080, filepath.Join(workingDirectoryPath, "rootPath"), tmpPath, "/", "someuid12345", nil, "")
+		Expect(err).ToNot(HaveOccurred())
+		configFileContents, err := ioutil.ReadFile(hwcConfig.WebConfigPath)
+		Expect(err).ToNot(HaveOccurred())
+
+		var config struct {
+			SystemWeb struct {
+				Compilation struct {
+					TempDirectory string `xml:"tempDirectory,attr"`
+				} `xml:"compilation"`
+			} `xml:"system.web"`
+		}
+		Expect(xml.Unmarshal(configFileContents, &config)).To(Succeed())
+		Expect(config.SystemWeb.Compilation.TempDirectory).To(Equal(tmpPath))
+	})
+})
@@ -0,0 +1,68 @@
+package hwcconfig
+
+import (
+	"fmt"
+	"time"
+
+	"code.cloudfoundry.org/hwc/apphost"
+)
+
+// WebSocket configures the IIS WebSocket module. The <webSocket> section is
+// locked to ApplicationHost.config, so apps can only change it through hwc.
+type WebSocket struct {
+	Enabled bool `json:"enabled"`
+	// PingInterval is how often IIS pings idle connections. Zero disables
+	// pings.
+	PingInterval       Duration `json:"pingInterval"`
+	ReceiveBufferLimit uint64   `json:"receiveBufferLimit"`
+}
+
+// DefaultWebSocket is the IIS default.
+func DefaultWebSocket() WebSocket {
+	return WebSocket{
+		Enabled:            true,
+		ReceiveBufferLimit: 4194304,
+	}
+}
+
+func (ws *WebSocket) loadEnv() error {
+	if err := envBool("HWC_WEBSOCKET_ENABLED", &ws.Enabled); err != nil {
+		return err
+	}
+	if err := envDuration("HWC_WEBSOCKET_PING_INTERVAL", &ws.PingInterval); err != nil {
+		return err
+	}
+	if err := envUint("HWC_WEBSOCKET_RECEIVE_BUFFER_LIMIT", &ws.ReceiveBufferLimit); err != nil {
+		return err
+	}
+	return ws.validate()
+}
+
+func (ws *WebSocket) validate() error {
+	if ws.PingInterval < 0 || time.Duration(ws.PingInterval)%time.Second != 0 {
+		return fmt.Errorf("Invalid WebSocket ping interval: %s (expected whole seconds)", ws.PingInterval)
+	}
+	if ws.ReceiveBufferLimit == 0 {
+		return fmt.Errorf("Invalid WebSocket receive buffer limit: 0")
+	}
+	return nil
+}
+
+// Customized reports whether ws changes anything the WebSocket module does.
+// Turning WebSockets off doesn't need the module.
+func (ws WebSocket) Customized() bool {
+	return ws.Enabled && ws != DefaultWebSocket()
+}
+
+// applicationHost is nil when ws is the IIS default, which leaves the section
+// out of ApplicationHost.config.
+func (ws WebSocket) applicationHost() *apphost.WebSocket {
+	if ws == DefaultWebSocket() {
+		return nil
+	}
+	return &apphost.WebSocket{
+		Enabled:            ws.Enabled,
+		ReceiveBufferLimit: ws.ReceiveBufferLimit,
+		PingInterval:       timeSpan(time.Duration(ws.PingInterval)),
+	}
+}
@@ -0,0 +1,123 @@
+package hwcconfig_test
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gbytes"
+
+	"code.cloudfoundry.org/hwc/apphost"
+	"code.cloudfoundry.org/hwc/hwcconfig"
+	"code.cloudfoundry.org/hwc/hwclog"
+)
+
+var _ = Describe("WebSocket", func() {
+	app := newTestApp()
+
+	It("leaves the IIS defaults alone", func() {
+		err, hwcConfig := app.newConfig()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(hwcConfig.WebSocket).To(Equal(hwcconfig.DefaultWebSocket()))
+		Expect(app.render().SystemWebServer.WebSocket).To(BeNil())
+	})
+
+	Context("when the settings are changed", func() {
+		BeforeEach(func() {
+			app.env["HWC_WEBSOCKET_PING_INTERVAL"] = "90s"
+			app.env["HWC_WEBSOCKET_RECEIVE_BUFFER_LIMIT"] = "65536"
+		})
+
+		It("renders them as IIS time spans and byte counts", func() {
+			Expect(app.render().SystemWebServer.WebSocket).To(Equal(&apphost.WebSocket{
+				Enabled:            true,
+				ReceiveBufferLimit: 65536,
+				PingInterval:       "00:01:30",
+			}))
+		})
+	})
+
+	Context("when the hosting profile doesn't load the WebSocket module", func() {
+		var (
+			log      *gbytes.Buffer
+			previous *hwclog.Logger
+		)
+
+		BeforeEach(func() {
+			log = gbytes.NewBuffer()
+			previous = hwclog.Default()
+			logger, err := hwclog.New("text", log, log)
+			Expect(err).ToNot(HaveOccurred())
+			hwclog.SetDefault(logger)
+			app.env["HWC_PROFILE"] = "static"
+		})
+
+		AfterEach(func() {
+			hwclog.SetDefault(previous)
+		})
+
+		It("says nothing about the defaults", func() {
+			app.render()
+			Expect(log).ToNot(gbytes.Say("WebSocket"))
+		})
+
+		Context("when the settings are changed", func() {
+			BeforeEach(func() {
+				app.env["HWC_WEBSOCKET_PING_INTERVAL"] = "30s"
+			})
+
+			It("warns that they have no effect", func() {
+				app.render()
+				Expect(log).To(gbytes.Say("Warning: The WebSocket settings have no effect, because the static hosting profile doesn't load the WebSocket module"))
+			})
+		})
+	})
+
+	Context("when HWC_WEBSOCKET_ENABLED is false", func() {
+		BeforeEach(func() {
+			app.env["HWC_WEBSOCKET_ENABLED"] = "false"
+		})
+
+		It("disables WebSockets", func() {
+			Expect(app.render().SystemWebServer.WebSocket.Enabled).To(BeFalse())
+		})
+	})
+
+	Context("when HWC_CONFIG_FILE sets them", func() {
+		BeforeEach(func() {
+			configFile := filepath.Join(app.workingDirectoryPath, "hwc.json")
+			Expect(ioutil.WriteFile(configFile, []byte(`{"webSocket": {"enabled": true, "pingInterval": "30s", "receiveBufferLimit": 4194304}}`), 0666)).To(Succeed())
+			app.env["HWC_CONFIG_FILE"] = configFile
+		})
+
+		It("reads them", func() {
+			err, hwcConfig := app.newConfig()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(time.Duration(hwcConfig.WebSocket.PingInterval)).To(Equal(30 * time.Second))
+		})
+	})
+
+	Context("when the ping interval isn't whole seconds", func() {
+		BeforeEach(func() {
+			app.env["HWC_WEBSOCKET_PING_INTERVAL"] = "500ms"
+		})
+
+		It("fails", func() {
+			err, _ := app.newConfig()
+			Expect(err).To(MatchError("Invalid WebSocket ping interval: 500ms (expected whole seconds)"))
+		})
+	})
+
+	Context("when the receive buffer limit is 0", func() {
+		BeforeEach(func() {
+			app.env["HWC_WEBSOCKET_RECEIVE_BUFFER_LIMIT"] = "0"
+		})
+
+		It("fails", func() {
+			err, _ := app.newConfig()
+			Expect(err).To(MatchError("Invalid WebSocket receive buffer limit: 0"))
+		})
+	})
+})
@@ -0,0 +1,185 @@
+// Package hwclog writes hwc's own messages, as opposed to the output of IIS
+// and the app, either as the plain lines hwc has always printed or as JSON
+// objects for log aggregation.
+package hwclog
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"sync"
+	"time"
+)
+
+type Level string
+
+const (
+	Info  Level = "info"
+	Warn  Level = "warn"
+	Error Level = "error"
+	Fatal Level = "fatal"
+)
+
+// Fields are the structured details of a message. In the text format they are
+// only carried by the message itself.
+type Fields map[string]interface{}
+
+type Format string
+
+const (
+	Text Format = "text"
+	JSON Format = "json"
+)
+
+// Logger writes info messages to Stdout and everything else to Stderr.
+type Logger struct {
+	Format Format
+	Stdout io.Writer
+	Stderr io.Writer
+	Now    func() time.Time
+
+	mu sync.Mutex
+}
+
+func New(format string, stdout, stderr io.Writer) (*Logger, error) {
+	switch Format(format) {
+	case Text, JSON:
+	default:
+		return nil, fmt.Errorf("Unknown log format %q (expected text or json)", format)
+	}
+	return &Logger{
+		Format: Format(format),
+		Stdout: stdout,
+		Stderr: stderr,
+		Now:    time.Now,
+	}, nil
+}
+
+// FromEnv returns a logger in the format named by HWC_LOG_FORMAT, which
+// defaults to text.
+func FromEnv(stdout, stderr io.Writer) (*Logger, error) {
+	format := os.Getenv("HWC_LOG_FORMAT")
+	if format == "" {
+		format = string(Text)
+	}
+	return New(format, stdout, stderr)
+}
+
+func (l *Logger) Info(event, message string, fields Fields) {
+	l.write(Info, event, message, fields)
+}
+
+// Warn writes a warning. The text format prefixes the message with
+// "Warning: ".
+func (l *Logger) Warn(event, message string, fields Fields) {
+	l.write(Warn, event, message, fields)
+}
+
+func (l *Logger) Error(event, message string, fields Fields) {
+	l.write(Error, event, message, fields)
+}
+
+// Fatal writes the error hwc is about to exit with. The text format
+// precedes it with a blank line so it stands out from the output of IIS.
+func (l *Logger) Fatal(event string, err error, fields Fields) {
+	l.write(Fatal, event, err.Error(), fields)
+}
+
+func (l *Logger) write(level Level, event, message string, fields Fields) {
+	out := l.Stderr
+	if level == Info {
+		out = l.Stdout
+	}
+
+	var line string
+	if l.Format == JSON {
+		line = l.formatJSON(level, event, message, fields)
+	} else {
+		line = formatText(level, message)
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	fmt.Fprintln(out, line)
+}
+
+func formatText(level Level, message string) string {
+	switch level {
+	case Warn:
+		return "Warning: " + message
+	case Fatal:
+		return "\n" + message
+	default:
+		return message
+	}
+}
+
+// formatJSON renders timestamp, level, event and message followed by the
+// fields in name order.
+func (l *Logger) formatJSON(level Level, event, message string, fields Fields) string {
+	var b bytes.Buffer
+	writeJSONField(&b, "timestamp", l.Now().UTC().Format(time.RFC3339Nano))
+	writeJSONField(&b, "level", level)
+	writeJSONField(&b, "event", event)
+	writeJSONField(&b, "message", message)
+
+	names := make([]string, 0, len(fields))
+	for name := range fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		writeJSONField(&b, name, fields[name])
+	}
+	b.WriteByte('}')
+	return b.String()
+}
+
+func writeJSONField(b *bytes.Buffer, name string, value interface{}) {
+	if b.Len() == 0 {
+		b.WriteByte('{')
+	} else {
+		b.WriteByte(',')
+	}
+	b.Write(marshal(name))
+	b.WriteByte(':')
+	if err, ok := value.(error); ok {
+		value = err.Error()
+	}
+	b.Write(marshal(value))
+}
+
+// marshal encodes value without escaping HTML, so the XML in messages about
+// Web.config stays readable. Values JSON can't encode are written as strings.
+func marshal(value interface{}) []byte {
+	var b bytes.Buffer
+	encoder := json.NewEncoder(&b)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(value); err != nil {
+		b.Reset()
+		encoder.Encode(fmt.Sprint(value))
+	}
+	return bytes.TrimSuffix(b.Bytes(), []byte("\n"))
+}
+
+var (
+	defaultMu     sync.RWMutex
+	defaultLogger = &Logger{Format: Text, Stdout: os.Stdout, Stderr: os.Stderr, Now: time.Now}
+)
+
+// Default is the logger used by packages that aren't handed one. It writes
+// text to os.Stdout and os.Stderr until SetDefault replaces it.
+func Default() *Logger {
+	defaultMu.RLock()
+	defer defaultMu.RUnlock()
+	return defaultLogger
+}
+
+func SetDefault(l *Logger) {
+	defaultMu.Lock()
+	defer defaultMu.Unlock()
+	defaultLogger = l
+}
@@ -0,0 +1,13 @@
+package hwclog_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func TestHwclog(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Hwclog Suite")
+}
@@ -0,0 +1,103 @@
+package hwclog_test
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"code.cloudfoundry.org/hwc/hwclog"
+)
+
+var _ = Describe("Logger", func() {
+	var (
+		stdout *bytes.Buffer
+		stderr *bytes.Buffer
+		logger *hwclog.Logger
+		format string
+	)
+
+	BeforeEach(func() {
+		stdout = &bytes.Buffer{}
+		stderr = &bytes.Buffer{}
+		format = "text"
+	})
+
+	JustBeforeEach(func() {
+		var err error
+		logger, err = hwclog.New(format, stdout, stderr)
+		Expect(err).ToNot(HaveOccurred())
+		logger.Now = func() time.Time {
+			return time.Date(2024, 1, 1, 12, 30, 0, 0, time.FixedZone("CET", 3600))
+		}
+	})
+
+	Context("in the text format", func() {
+		It("prints the message the way hwc always has", func() {
+			logger.Info("server-started", "Server Started for someuid12345", hwclog.Fields{"instance": "someuid12345"})
+			logger.Warn("web-config", "<httpCompression> should not have any attributes", nil)
+			logger.Error("access-log", "Following access log: boom", nil)
+			logger.Fatal("startup", errors.New("Missing PORT environment variable"), nil)
+
+			Expect(stdout.String()).To(Equal("Server Started for someuid12345\n"))
+			Expect(stderr.String()).To(Equal("Warning: <httpCompression> should not have any attributes\n" +
+				"Following access log: boom\n" +
+				"\nMissing PORT environment variable\n"))
+		})
+	})
+
+	Context("in the JSON format", func() {
+		BeforeEach(func() {
+			format = "json"
+		})
+
+		It("prints one object per message with the fields in name order", func() {
+			logger.Info("server-started", "Server Started for someuid12345", hwclog.Fields{
+				"port":        8080,
+				"instance":    "someuid12345",
+				"contextPath": "/foo",
+			})
+
+			Expect(stdout.String()).To(Equal(`{"timestamp":"2024-01-01T11:30:00Z","level":"info","event":"server-started","message":"Server Started for someuid12345","contextPath":"/foo","instance":"someuid12345","port":8080}` + "\n"))
+			Expect(stderr.String()).To(BeEmpty())
+		})
+
+		It("writes warnings and errors to stderr", func() {
+			logger.Warn("web-config", "<httpCompression> should not have any attributes", nil)
+			logger.Fatal("startup", errors.New("Missing PORT environment variable"), hwclog.Fields{"cause": errors.New("unset")})
+
+			Expect(stdout.String()).To(BeEmpty())
+			Expect(stderr.String()).To(Equal(
+				`{"timestamp":"2024-01-01T11:30:00Z","level":"warn","event":"web-config","message":"<httpCompression> should not have any attributes"}` + "\n" +
+					`{"timestamp":"2024-01-01T11:30:00Z","level":"fatal","event":"startup","message":"Missing PORT environment variable","cause":"unset"}` + "\n"))
+		})
+	})
+
+	Describe("FromEnv", func() {
+		AfterEach(func() {
+			Expect(os.Unsetenv("HWC_LOG_FORMAT")).To(Succeed())
+		})
+
+		It("defaults to text", func() {
+			logger, err := hwclog.FromEnv(stdout, stderr)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(logger.Format).To(Equal(hwclog.Text))
+		})
+
+		It("uses HWC_LOG_FORMAT", func() {
+			Expect(os.Setenv("HWC_LOG_FORMAT", "json")).To(Succeed())
+			logger, err := hwclog.FromEnv(stdout, stderr)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(logger.Format).To(Equal(hwclog.JSON))
+		})
+
+		It("rejects unknown formats", func() {
+			Expect(os.Setenv("HWC_LOG_FORMAT", "xml")).To(Succeed())
+			_, err := hwclog.FromEnv(stdout, stderr)
+			Expect(err).To(MatchError(`Unknown log format "xml" (expected text or json)`))
+		})
+	})
+})
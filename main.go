@@ -8,30 +8,61 @@ import (
 	"flag"
 	"fmt"
 	"io"
+	"net/http"
 	"os"
 	"os/signal"
 	"path/filepath"
 	_ "runtime/cgo"
 	"strconv"
 	"syscall"
+	"time"
 
 	cfenv "github.com/cloudfoundry-community/go-cfenv"
 
+	"code.cloudfoundry.org/hwc/configdiff"
 	"code.cloudfoundry.org/hwc/contextpath"
+	"code.cloudfoundry.org/hwc/freb"
+	"code.cloudfoundry.org/hwc/healthcheck"
 	"code.cloudfoundry.org/hwc/hwcconfig"
+	"code.cloudfoundry.org/hwc/hwclog"
+	"code.cloudfoundry.org/hwc/metrics"
+	"code.cloudfoundry.org/hwc/servicebindings"
 	"code.cloudfoundry.org/hwc/validator"
+	"code.cloudfoundry.org/hwc/w3clog"
+	"code.cloudfoundry.org/hwc/warmup"
+	"code.cloudfoundry.org/hwc/watchdog"
+	"code.cloudfoundry.org/hwc/webconfig"
 	"code.cloudfoundry.org/hwc/webcore"
 )
 
 var appRootPath string
+var profile string
+
+var log = hwclog.Default()
+
+// restoreWebConfig puts the app's own Web.config back once hwc has replaced
+// it with the effective one. checkErr calls it too, so the effective
+// Web.config, which may hold service credentials, doesn't outlive hwc on any
+// way out.
+var restoreWebConfig = func() {}
 
 func init() {
 	flag.StringVar(&appRootPath, "appRootPath", ".", "app web root path")
+	flag.StringVar(&profile, "profile", "", "hosting profile: full, static, aspnet45, asp-classic or auto (overrides HWC_PROFILE)")
 }
 
 func main() {
 	flag.Parse()
 
+	logger, err := hwclog.FromEnv(os.Stdout, os.Stderr)
+	checkErr(err)
+	hwclog.SetDefault(logger)
+	log = logger
+
+	if flag.Arg(0) == "diff" {
+		os.Exit(diffConfigs(flag.Args()[1:]))
+	}
+
 	if os.Getenv("PORT") == "" {
 		checkErr(errors.New("Missing PORT environment variable"))
 	}
@@ -51,8 +82,9 @@ func main() {
 	checkErr(err)
 
 	contextPath := contextpath.Default()
+	var appEnv *cfenv.App
 	if cfenv.IsRunningOnCF() {
-		appEnv, err := cfenv.Current()
+		appEnv, err = cfenv.Current()
 		if err != nil {
 			checkErr(fmt.Errorf("Getting current CF environment: %v", err))
 		}
@@ -62,7 +94,7 @@ func main() {
 			checkErr(fmt.Errorf("Getting CF application context path: %v", err))
 		}
 
-		fmt.Println(fmt.Sprintf("Context Path %s", contextPath))
+		log.Info("context-path", fmt.Sprintf("Context Path %s", contextPath), hwclog.Fields{"contextPath": contextPath})
 	}
 
 	uuid, err := generateUUID()
@@ -70,34 +102,349 @@ func main() {
 		checkErr(fmt.Errorf("Generating UUID: %v", err))
 	}
 
-	err, config := hwcconfig.New(port, rootPath, tmpPath, contextPath, uuid)
+	stats := metrics.New()
+	configStart := time.Now()
+	err, config := hwcconfig.New(port, rootPath, tmpPath, contextPath, uuid, appEnv, profile)
 	checkErr(err)
+	stats.SetConfigGenerationDuration(time.Since(configStart))
+	logFingerprints(config)
 
-	err = validator.ValidateWebConfig(filepath.Join(rootPath, "Web.config"), os.Stderr)
+	err = webconfig.WriteEffective(rootPath, webConfigTransforms(config, appEnv)...)
+	if err != nil {
+		checkErr(fmt.Errorf("Writing effective Web.config: %v", err))
+	}
+	restoreWebConfig = func() {
+		restoreWebConfig = func() {}
+		if err := webconfig.RestoreOriginal(rootPath); err != nil {
+			log.Error("web-config-restore-failed", fmt.Sprintf("Restoring the app's Web.config: %v", err), hwclog.Fields{"error": err})
+		}
+	}
+
+	err = validator.ValidateWebConfigForHost(filepath.Join(rootPath, "Web.config"), hostRequestFiltering(config.RequestFiltering), log)
 	checkErr(err)
 
+	if config.Metrics.Enabled() {
+		startMetrics(config, stats)
+	}
+
+	dog := newWatchdog(config, stats)
+
 	err, wc := webcore.New()
 	checkErr(err)
 	defer syscall.FreeLibrary(wc.Handle)
 
+	stopAccessLog, accessLogDone := startAccessLog(config, stats)
+	stopFailedRequests, failedRequestsDone := startFailedRequestSummaries(config)
+	stopHTTPPlatformLog, httpPlatformLogDone := startHTTPPlatformLog(config)
+
+	activationStart := time.Now()
 	checkErr(wc.Activate(
 		config.ApplicationHostConfigPath,
 		config.WebConfigPath,
 		config.Instance))
+	stats.SetActivationDuration(time.Since(activationStart))
+
+	if config.Warmup.Enabled() && config.Warmup.DelayServerStarted {
+		warmUp(config)
+	}
+	log.Info("server-started", fmt.Sprintf("Server Started for %+v", config.Instance), hwclog.Fields{
+		"instance":    config.Instance,
+		"port":        config.Port,
+		"contextPath": contextPath,
+		"modules":     config.NativeModules,
+	})
+	if config.Warmup.Enabled() && !config.Warmup.DelayServerStarted {
+		go warmUp(config)
+	}
+
+	if config.HealthCheck.Enabled() {
+		probe := healthcheck.New(config.LocalURL(config.HealthCheck.Path), int(config.HealthCheck.ExpectedStatus), time.Duration(config.HealthCheck.Timeout))
+		probe.OnCheck = stats.ObserveHealthCheck
+		err = probe.WaitUntilReady(time.Duration(config.HealthCheck.Deadline))
+		if err != nil {
+			wc.Shutdown(1, config.Instance)
+			checkErr(err)
+		}
+		log.Info("app-ready", "App ready", hwclog.Fields{"instance": config.Instance, "url": probe.URL})
+	}
+
+	stopWatchdog := make(chan struct{})
+	watchdogFailed := make(chan error, 1)
+	if dog != nil {
+		go func() {
+			if err := dog.Run(stopWatchdog); err != nil {
+				watchdogFailed <- err
+			}
+		}()
+	}
 
 	c := make(chan os.Signal, 1)
 	signal.Notify(c, os.Interrupt)
-	<-c
+	var exitErr error
+	select {
+	case <-c:
+	case exitErr = <-watchdogFailed:
+	}
+	close(stopWatchdog)
 	checkErr(wc.Shutdown(1, config.Instance))
+	restoreWebConfig()
+
+	close(stopAccessLog)
+	close(stopFailedRequests)
+	close(stopHTTPPlatformLog)
+	<-accessLogDone
+	<-failedRequestsDone
+	<-httpPlatformLogDone
+	checkErr(exitErr)
+}
+
+// diffConfigs compares the configs in two directories, such as the tmp\config
+// directories of two instances, and returns the exit status: 0 when their
+// settings match, 1 when they differ and 2 when they can't be compared.
+func diffConfigs(args []string) int {
+	if len(args) != 2 {
+		fmt.Fprintln(os.Stderr, "Usage: hwc diff <dirA> <dirB>")
+		return 2
+	}
+	diffs, err := configdiff.Dirs(args[0], args[1])
+	if err != nil {
+		log.Error("diff-failed", fmt.Sprintf("Comparing configs: %v", err), hwclog.Fields{"error": err})
+		return 2
+	}
+	if configdiff.Write(os.Stdout, diffs) {
+		return 1
+	}
+	return 0
+}
+
+// logFingerprints prints a hash of the settings in each generated config, so
+// instances that behave differently can be checked for different configs. The
+// instance index and GUID are left out, so instances of an app can match.
+func logFingerprints(config *hwcconfig.HwcConfig) {
+	for _, path := range []string{config.ApplicationHostConfigPath, config.AspnetConfigPath, config.WebConfigPath} {
+		fingerprint, err := configdiff.FingerprintFile(path, config.InstanceMetadata.PerInstanceNames()...)
+		checkErr(err)
+		file := filepath.Base(path)
+		log.Info("config-fingerprint", fmt.Sprintf("%s sha256:%s", file, fingerprint), hwclog.Fields{"file": file, "sha256": fingerprint})
+	}
+}
+
+// newWatchdog returns nil unless the watchdog is enabled. It probes the health
+// check path, or any response from the app's root when there isn't one.
+func newWatchdog(config *hwcconfig.HwcConfig, stats *metrics.Metrics) *watchdog.Watchdog {
+	if !config.Watchdog.Enabled {
+		return nil
+	}
+
+	path, expectedStatus := "/", 0
+	if config.HealthCheck.Enabled() {
+		path, expectedStatus = config.HealthCheck.Path, int(config.HealthCheck.ExpectedStatus)
+	}
+	probe := healthcheck.New(config.LocalURL(path), expectedStatus, time.Duration(config.HealthCheck.Timeout))
+	probe.OnCheck = stats.ObserveHealthCheck
+	dog := watchdog.New(probe, time.Duration(config.Watchdog.Interval), int(config.Watchdog.FailureThreshold), config.Watchdog.Markers)
+
+	if len(dog.Markers) > 0 {
+		err := captureStderr(dog)
+		if err != nil {
+			checkErr(fmt.Errorf("Redirecting stderr: %v", err))
+		}
+	}
+	return dog
+}
+
+// captureStderr points the process' standard error handle, which IIS and the
+// CLR write to, at a pipe the watchdog scans before passing the output on.
+// hwc's own os.Stderr keeps writing to the original handle.
+func captureStderr(dog *watchdog.Watchdog) error {
+	r, w, err := os.Pipe()
+	if err != nil {
+		return err
+	}
+
+	stdErrorHandle := syscall.STD_ERROR_HANDLE
+	setStdHandle := syscall.NewLazyDLL("kernel32.dll").NewProc("SetStdHandle")
+	if ok, _, err := setStdHandle.Call(uintptr(stdErrorHandle), w.Fd()); ok == 0 {
+		return err
+	}
+
+	go func() {
+		if err := dog.ScanOutput(r, os.Stderr); err != nil {
+			log.Error("stderr-scan-failed", fmt.Sprintf("Watchdog stopped looking for markers in stderr: %v", err), hwclog.Fields{"error": err})
+		}
+	}()
+	return nil
+}
+
+// warmUp requests the configured paths so the app has started and compiled
+// its pages before real requests arrive.
+func warmUp(config *hwcconfig.HwcConfig) {
+	var urls []string
+	for _, path := range config.Warmup.Paths {
+		urls = append(urls, config.LocalURL(path))
+	}
+
+	start := time.Now()
+	warmer := warmup.New(config.Warmup.Host, int(config.Warmup.Concurrency), time.Duration(config.Warmup.Timeout))
+	results := warmer.Run(urls, func(r warmup.Result) {
+		fields := hwclog.Fields{"url": r.URL, "duration": r.Duration.Seconds()}
+		if r.Err != nil {
+			fields["error"] = r.Err
+		} else {
+			fields["status"] = r.StatusCode
+		}
+		log.Info("warmup-request", r.String(), fields)
+	})
+	elapsed := time.Since(start)
+	log.Info("warmup-finished", fmt.Sprintf("Warm-up finished in %s", elapsed), hwclog.Fields{
+		"requests": len(results),
+		"duration": elapsed.Seconds(),
+	})
+}
+
+// startAccessLog follows the requests IIS logs for the site, counting them in
+// stats and re-emitting them on stdout when the access log is enabled. Closing
+// the returned stop channel reads any remaining requests before done is closed.
+func startAccessLog(config *hwcconfig.HwcConfig, stats *metrics.Metrics) (chan struct{}, chan struct{}) {
+	stop := make(chan struct{})
+	done := make(chan struct{})
+	if !config.HTTPLoggingEnabled() {
+		close(done)
+		return stop, done
+	}
+
+	var formatter w3clog.Formatter
+	if config.AccessLog.Enabled() {
+		var err error
+		formatter, err = w3clog.NewFormatter(config.AccessLog.Format)
+		checkErr(err)
+	}
+
+	go func() {
+		defer close(done)
+		err := w3clog.Follow(w3clog.NewTailer(config.SiteLogDirectory()), config.AccessLog.CustomFieldNames(), stop, func(e w3clog.Entry) {
+			stats.ObserveRequest(e)
+			if formatter != nil {
+				fmt.Println(formatter(e))
+			}
+		})
+		if err != nil {
+			log.Error("access-log-failed", fmt.Sprintf("Following access log: %v", err), hwclog.Fields{"error": err})
+		}
+	}()
+	return stop, done
+}
+
+// startMetrics serves stats on the metrics port. Starts are counted in hwc's
+// tmp directory, so restarts are only seen when hwc is started again in the
+// same container or on the same machine.
+func startMetrics(config *hwcconfig.HwcConfig, stats *metrics.Metrics) {
+	restarts, err := metrics.RecordStart(filepath.Join(config.TempDirectory, "hwc-starts"))
+	if err != nil {
+		checkErr(fmt.Errorf("Recording start: %v", err))
+	}
+	stats.SetRestarts(restarts)
+
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", stats)
+	go func() {
+		err := http.ListenAndServe(config.Metrics.Address(), mux)
+		log.Error("metrics-failed", fmt.Sprintf("Serving metrics: %v", err), hwclog.Fields{"error": err})
+	}()
+	log.Info("metrics-serving", fmt.Sprintf("Serving metrics on %s/metrics", config.Metrics.Address()), hwclog.Fields{"port": config.Metrics.Port})
+}
+
+// startFailedRequestSummaries prints a one line summary of each failed request
+// trace IIS writes for the site.
+func startFailedRequestSummaries(config *hwcconfig.HwcConfig) (chan struct{}, chan struct{}) {
+	stop := make(chan struct{})
+	done := make(chan struct{})
+	if !config.FailedRequestTracing.Enabled || !config.FailedRequestTracing.Summarize {
+		close(done)
+		return stop, done
+	}
+
+	go func() {
+		defer close(done)
+		err := freb.NewWatcher(config.SiteFailedRequestLogDirectory()).Run(stop, func(s freb.Summary) {
+			log.Info("failed-request", s.String(), hwclog.Fields{
+				"url":           s.URL,
+				"verb":          s.Verb,
+				"status":        s.StatusCode,
+				"timeTaken":     s.TimeTaken,
+				"failureReason": s.FailureReason,
+				"file":          s.File,
+			})
+		})
+		if err != nil {
+			log.Error("failed-request-tracing-failed", fmt.Sprintf("Watching failed request traces: %v", err), hwclog.Fields{"error": err})
+		}
+	}()
+	return stop, done
+}
+
+// startHTTPPlatformLog copies what the HttpPlatformHandler's backend process
+// writes to stdout onto hwc's stdout, so it reaches the app's logs, unless the
+// app's Web.config sends it elsewhere.
+func startHTTPPlatformLog(config *hwcconfig.HwcConfig) (chan struct{}, chan struct{}) {
+	stop := make(chan struct{})
+	done := make(chan struct{})
+	if !config.HTTPPlatform.CapturesOutput() {
+		close(done)
+		return stop, done
+	}
+
+	tailer := w3clog.NewTailer(config.HTTPPlatformLogDirectory())
+	tailer.Pattern = "stdout*.log"
+	go func() {
+		defer close(done)
+		err := tailer.Run(stop, func(line w3clog.Line) {
+			if !line.Backlog {
+				fmt.Println(line.Text)
+			}
+		})
+		if err != nil {
+			log.Error("http-platform-log-failed", fmt.Sprintf("Following HttpPlatformHandler output: %v", err), hwclog.Fields{"error": err})
+		}
+	}()
+	return stop, done
 }
 
 func checkErr(err error) {
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "\n%s\n", err)
+		restoreWebConfig()
+		log.Fatal("exit", err, nil)
 		os.Exit(1)
 	}
 }
 
+// webConfigTransforms are the changes hwc makes to the app's own Web.config
+// before IIS loads it. Placeholders are expanded before the bound services are
+// merged so credentials are never treated as placeholders.
+func webConfigTransforms(config *hwcconfig.HwcConfig, appEnv *cfenv.App) []webconfig.Transform {
+	var transforms []webconfig.Transform
+	if config.EnvSubstitution.Enabled() {
+		transforms = append(transforms, webconfig.ExpandEnv(config.EnvSubstitution.Strict()))
+	}
+	if config.ServiceBindings.Enabled {
+		overlay, err := servicebindings.New(appEnv, config.ServiceBindings.Rules)
+		if err != nil {
+			checkErr(fmt.Errorf("Mapping bound services: %v", err))
+		}
+		transforms = append(transforms, overlay.Apply)
+	}
+	return transforms
+}
+
+func hostRequestFiltering(rf hwcconfig.RequestFiltering) validator.HostRequestFiltering {
+	return validator.HostRequestFiltering{
+		MaxAllowedContentLength: rf.MaxAllowedContentLength,
+		MaxURL:                  rf.MaxURL,
+		MaxQueryString:          rf.MaxQueryString,
+		DenyURLSequences:        rf.DenyURLSequences,
+	}
+}
+
 func generateUUID() (string, error) {
 	const size = 128 / 8
 	const format = "%08x-%04x-%04x-%04x-%012x"
@@ -394,6 +394,33 @@ var _ = Describe("HWC", func() {
 				" and <dynamicTypes> but it has <scheme>"))
 		})
 	})
+
+	Context("when running hwc diff", func() {
+		var diff = func(args ...string) *gexec.Session {
+			cmd := exec.Command(hwcBinPath, append([]string{"diff"}, args...)...)
+			session, err := gexec.Start(cmd, GinkgoWriter, GinkgoWriter)
+			Expect(err).ToNot(HaveOccurred())
+			return session
+		}
+
+		It("exits 1 and prints the changes between two config directories", func() {
+			session := diff(filepath.Join("fixtures", "configdiff", "a"), filepath.Join("fixtures", "configdiff", "b"))
+			Eventually(session).Should(gexec.Exit(1))
+			Expect(session.Out).To(gbytes.Say(`- system.webServer/handlers/add\[@name='TRACEVerbHandler'\]`))
+			Expect(session.Out).To(gbytes.Say("Aspnet.config: no differences"))
+		})
+
+		It("exits 0 when the settings match", func() {
+			dir := filepath.Join("fixtures", "configdiff", "a")
+			Eventually(diff(dir, dir)).Should(gexec.Exit(0))
+		})
+
+		It("exits 2 without two directories", func() {
+			session := diff(filepath.Join("fixtures", "configdiff", "a"))
+			Eventually(session).Should(gexec.Exit(2))
+			Expect(session.Err).To(gbytes.Say("Usage: hwc diff <dirA> <dirB>"))
+		})
+	})
 })
 
 type hwcApp struct {
@@ -0,0 +1,214 @@
+// Package metrics keeps process, request and health check metrics for hwc
+// and serves them in the Prometheus text exposition format.
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"code.cloudfoundry.org/hwc/w3clog"
+)
+
+// latencyBuckets are the upper bounds, in seconds, of the request duration
+// histogram.
+var latencyBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+type requestKey struct {
+	method string
+	status string
+}
+
+type Metrics struct {
+	mutex sync.Mutex
+
+	started                  time.Time
+	restarts                 uint64
+	configGenerationDuration time.Duration
+	activationDuration       time.Duration
+
+	requests      map[requestKey]uint64
+	latencyCounts []uint64
+	latencyCount  uint64
+	latencySum    float64
+	healthChecks  map[string]uint64
+	healthCheckUp float64
+	healthChecked bool
+}
+
+func New() *Metrics {
+	return &Metrics{
+		started:       time.Now(),
+		requests:      map[requestKey]uint64{},
+		latencyCounts: make([]uint64, len(latencyBuckets)),
+		healthChecks:  map[string]uint64{},
+	}
+}
+
+// RecordStart counts a start of hwc in the file at path and returns the number
+// of times hwc was started before.
+func RecordStart(path string) (uint64, error) {
+	var starts uint64
+	data, err := ioutil.ReadFile(path)
+	if err == nil {
+		starts, _ = strconv.ParseUint(strings.TrimSpace(string(data)), 10, 64)
+	} else if !os.IsNotExist(err) {
+		return 0, err
+	}
+
+	if err := ioutil.WriteFile(path, []byte(strconv.FormatUint(starts+1, 10)), 0600); err != nil {
+		return 0, err
+	}
+	return starts, nil
+}
+
+func (m *Metrics) SetRestarts(n uint64) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.restarts = n
+}
+
+func (m *Metrics) SetConfigGenerationDuration(d time.Duration) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.configGenerationDuration = d
+}
+
+func (m *Metrics) SetActivationDuration(d time.Duration) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.activationDuration = d
+}
+
+// ObserveRequest counts a request logged by IIS. Requests are counted by
+// cs-method and sc-status, and time-taken feeds the duration histogram.
+func (m *Metrics) ObserveRequest(e w3clog.Entry) {
+	method, _ := e.Get("cs-method")
+	status, _ := e.Get("sc-status")
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.requests[requestKey{method: method, status: status}]++
+
+	timeTaken, ok := e.Get("time-taken")
+	if !ok {
+		return
+	}
+	ms, err := strconv.ParseFloat(timeTaken, 64)
+	if err != nil {
+		return
+	}
+	seconds := ms / 1000
+	for i, bound := range latencyBuckets {
+		if seconds <= bound {
+			m.latencyCounts[i]++
+		}
+	}
+	m.latencyCount++
+	m.latencySum += seconds
+}
+
+// ObserveHealthCheck records the result of a health check.
+func (m *Metrics) ObserveHealthCheck(err error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.healthChecked = true
+	if err != nil {
+		m.healthChecks["failure"]++
+		m.healthCheckUp = 0
+		return
+	}
+	m.healthChecks["success"]++
+	m.healthCheckUp = 1
+}
+
+func (m *Metrics) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	m.WriteTo(w)
+}
+
+// WriteTo writes the metrics in the Prometheus text exposition format.
+func (m *Metrics) WriteTo(w io.Writer) (int64, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	e := &exposition{w: w}
+	e.metric("hwc_uptime_seconds", "gauge", "Time since hwc started.")
+	e.sample("hwc_uptime_seconds", "", time.Since(m.started).Seconds())
+	e.metric("hwc_restarts", "gauge", "Times hwc has been restarted in this container.")
+	e.sample("hwc_restarts", "", float64(m.restarts))
+	e.metric("hwc_config_generation_duration_seconds", "gauge", "Time taken to generate the IIS configuration.")
+	e.sample("hwc_config_generation_duration_seconds", "", m.configGenerationDuration.Seconds())
+	e.metric("hwc_activation_duration_seconds", "gauge", "Time taken by WebCoreActivate.")
+	e.sample("hwc_activation_duration_seconds", "", m.activationDuration.Seconds())
+
+	e.metric("hwc_http_requests_total", "counter", "Requests logged by IIS, by method and status code.")
+	keys := make([]requestKey, 0, len(m.requests))
+	for key := range m.requests {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].method != keys[j].method {
+			return keys[i].method < keys[j].method
+		}
+		return keys[i].status < keys[j].status
+	})
+	for _, key := range keys {
+		e.sample("hwc_http_requests_total", fmt.Sprintf(`method=%q,status=%q`, key.method, key.status), float64(m.requests[key]))
+	}
+
+	e.metric("hwc_http_request_duration_seconds", "histogram", "Time taken to serve requests, as logged by IIS.")
+	for i, bound := range latencyBuckets {
+		e.sample("hwc_http_request_duration_seconds_bucket", fmt.Sprintf(`le="%s"`, formatFloat(bound)), float64(m.latencyCounts[i]))
+	}
+	e.sample("hwc_http_request_duration_seconds_bucket", `le="+Inf"`, float64(m.latencyCount))
+	e.sample("hwc_http_request_duration_seconds_sum", "", m.latencySum)
+	e.sample("hwc_http_request_duration_seconds_count", "", float64(m.latencyCount))
+
+	e.metric("hwc_health_checks_total", "counter", "Health checks made against the app, by result.")
+	for _, result := range []string{"success", "failure"} {
+		e.sample("hwc_health_checks_total", fmt.Sprintf(`result=%q`, result), float64(m.healthChecks[result]))
+	}
+	if m.healthChecked {
+		e.metric("hwc_health_check_up", "gauge", "Whether the last health check passed.")
+		e.sample("hwc_health_check_up", "", m.healthCheckUp)
+	}
+	return e.n, e.err
+}
+
+type exposition struct {
+	w   io.Writer
+	n   int64
+	err error
+}
+
+func (e *exposition) printf(format string, args ...interface{}) {
+	if e.err != nil {
+		return
+	}
+	n, err := fmt.Fprintf(e.w, format, args...)
+	e.n += int64(n)
+	e.err = err
+}
+
+func (e *exposition) metric(name, kind, help string) {
+	e.printf("# HELP %s %s\n# TYPE %s %s\n", name, help, name, kind)
+}
+
+func (e *exposition) sample(name, labels string, value float64) {
+	if labels != "" {
+		name += "{" + labels + "}"
+	}
+	e.printf("%s %s\n", name, formatFloat(value))
+}
+
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
@@ -0,0 +1,120 @@
+package metrics_test
+
+import (
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"code.cloudfoundry.org/hwc/metrics"
+	"code.cloudfoundry.org/hwc/w3clog"
+)
+
+var _ = Describe("Metrics", func() {
+	var m *metrics.Metrics
+
+	var expose = func() string {
+		var b strings.Builder
+		_, err := m.WriteTo(&b)
+		Expect(err).ToNot(HaveOccurred())
+		return b.String()
+	}
+
+	BeforeEach(func() {
+		m = metrics.New()
+	})
+
+	It("exposes the process metrics", func() {
+		m.SetRestarts(2)
+		m.SetConfigGenerationDuration(150 * time.Millisecond)
+		m.SetActivationDuration(2500 * time.Millisecond)
+
+		exposed := expose()
+		Expect(exposed).To(MatchRegexp(`(?m)^# TYPE hwc_uptime_seconds gauge\nhwc_uptime_seconds \d`))
+		Expect(exposed).To(ContainSubstring("# TYPE hwc_restarts gauge\nhwc_restarts 2\n"))
+		Expect(exposed).To(ContainSubstring("\nhwc_config_generation_duration_seconds 0.15\n"))
+		Expect(exposed).To(ContainSubstring("\nhwc_activation_duration_seconds 2.5\n"))
+		Expect(exposed).ToNot(ContainSubstring("hwc_health_check_up"))
+	})
+
+	It("counts the requests IIS logs", func() {
+		data, err := ioutil.ReadFile("../fixtures/w3clogs/u_ex240101.log")
+		Expect(err).ToNot(HaveOccurred())
+		var parser w3clog.Parser
+		for _, line := range strings.Split(string(data), "\n") {
+			entry, ok, err := parser.Parse(line)
+			Expect(err).ToNot(HaveOccurred())
+			if ok {
+				m.ObserveRequest(entry)
+			}
+		}
+
+		exposed := expose()
+		Expect(exposed).To(ContainSubstring(`# TYPE hwc_http_requests_total counter
+hwc_http_requests_total{method="GET",status="200"} 2
+hwc_http_requests_total{method="POST",status="500"} 1
+`))
+		Expect(exposed).To(ContainSubstring(`# TYPE hwc_http_request_duration_seconds histogram
+hwc_http_request_duration_seconds_bucket{le="0.005"} 0
+hwc_http_request_duration_seconds_bucket{le="0.01"} 0
+hwc_http_request_duration_seconds_bucket{le="0.025"} 1
+hwc_http_request_duration_seconds_bucket{le="0.05"} 2
+hwc_http_request_duration_seconds_bucket{le="0.1"} 2
+hwc_http_request_duration_seconds_bucket{le="0.25"} 2
+hwc_http_request_duration_seconds_bucket{le="0.5"} 2
+hwc_http_request_duration_seconds_bucket{le="1"} 2
+hwc_http_request_duration_seconds_bucket{le="2.5"} 3
+hwc_http_request_duration_seconds_bucket{le="5"} 3
+hwc_http_request_duration_seconds_bucket{le="10"} 3
+hwc_http_request_duration_seconds_bucket{le="+Inf"} 3
+hwc_http_request_duration_seconds_sum 1.791
+hwc_http_request_duration_seconds_count 3
+`))
+	})
+
+	It("records health check results", func() {
+		m.ObserveHealthCheck(nil)
+		m.ObserveHealthCheck(errors.New("GET http://localhost:8080/ returned 503"))
+
+		exposed := expose()
+		Expect(exposed).To(ContainSubstring(`hwc_health_checks_total{result="success"} 1
+hwc_health_checks_total{result="failure"} 1
+`))
+		Expect(exposed).To(ContainSubstring("# TYPE hwc_health_check_up gauge\nhwc_health_check_up 0\n"))
+	})
+
+	It("serves the metrics over HTTP", func() {
+		server := httptest.NewServer(m)
+		defer server.Close()
+
+		res, err := http.Get(server.URL + "/metrics")
+		Expect(err).ToNot(HaveOccurred())
+		defer res.Body.Close()
+		Expect(res.Header.Get("Content-Type")).To(Equal("text/plain; version=0.0.4; charset=utf-8"))
+		body, err := ioutil.ReadAll(res.Body)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(body)).To(ContainSubstring("hwc_uptime_seconds"))
+	})
+
+	Describe("RecordStart", func() {
+		It("counts the previous starts", func() {
+			dir, err := ioutil.TempDir("", "metrics")
+			Expect(err).ToNot(HaveOccurred())
+			defer os.RemoveAll(dir)
+
+			path := filepath.Join(dir, "starts")
+			for i := uint64(0); i < 3; i++ {
+				restarts, err := metrics.RecordStart(path)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(restarts).To(Equal(i))
+			}
+		})
+	})
+})
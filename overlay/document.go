@@ -0,0 +1,151 @@
+package overlay
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// node is an element, or the text, comment or other markup between elements.
+// Markup other than elements is kept as written in raw. The document itself
+// is a nameless node whose children are the markup around the root element.
+type node struct {
+	parent   *node
+	name     string
+	attr     []xml.Attr
+	children []*node
+	raw      []byte
+}
+
+func (n *node) isElement() bool {
+	return n.name != ""
+}
+
+func (n *node) attrValue(name string) (string, bool) {
+	for _, a := range n.attr {
+		if attrName(a.Name) == name {
+			return a.Value, true
+		}
+	}
+	return "", false
+}
+
+func (n *node) setAttr(name, value string) {
+	for i, a := range n.attr {
+		if attrName(a.Name) == name {
+			n.attr[i].Value = value
+			return
+		}
+	}
+	n.attr = append(n.attr, xml.Attr{Name: xml.Name{Local: name}, Value: value})
+}
+
+// parse reads a document into a tree.
+func parse(data []byte) (*node, error) {
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	document := &node{}
+	stack := []*node{document}
+	for {
+		token, err := decoder.RawToken()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		parent := stack[len(stack)-1]
+		switch t := token.(type) {
+		case xml.StartElement:
+			element := &node{parent: parent, name: attrName(t.Name), attr: t.Copy().Attr}
+			parent.children = append(parent.children, element)
+			stack = append(stack, element)
+		case xml.EndElement:
+			if len(stack) == 1 {
+				return nil, fmt.Errorf("unexpected </%s>", attrName(t.Name))
+			}
+			stack = stack[:len(stack)-1]
+		case xml.CharData:
+			var b bytes.Buffer
+			escapeText(&b, string(t))
+			parent.children = append(parent.children, &node{parent: parent, raw: b.Bytes()})
+		case xml.Comment:
+			parent.children = append(parent.children, &node{parent: parent, raw: []byte("<!--" + string(t) + "-->")})
+		case xml.ProcInst:
+			parent.children = append(parent.children, &node{parent: parent, raw: []byte("<?" + t.Target + " " + string(t.Inst) + "?>")})
+		case xml.Directive:
+			parent.children = append(parent.children, &node{parent: parent, raw: []byte("<!" + string(t) + ">")})
+		}
+	}
+	if len(stack) != 1 {
+		return nil, fmt.Errorf("<%s> is not closed", stack[len(stack)-1].name)
+	}
+	if document.root() == nil {
+		return nil, fmt.Errorf("no document element")
+	}
+	return document, nil
+}
+
+// root is the document element.
+func (n *node) root() *node {
+	for _, child := range n.children {
+		if child.isElement() {
+			return child
+		}
+	}
+	return nil
+}
+
+func (n *node) render() []byte {
+	var b bytes.Buffer
+	for _, child := range n.children {
+		child.write(&b)
+	}
+	return b.Bytes()
+}
+
+func (n *node) write(b *bytes.Buffer) {
+	if !n.isElement() {
+		b.Write(n.raw)
+		return
+	}
+	b.WriteString("<" + n.name)
+	for _, a := range n.attr {
+		b.WriteString(" " + attrName(a.Name) + `="`)
+		escapeAttr(b, a.Value)
+		b.WriteString(`"`)
+	}
+	if len(n.children) == 0 {
+		b.WriteString(" />")
+		return
+	}
+	b.WriteString(">")
+	for _, child := range n.children {
+		child.write(b)
+	}
+	b.WriteString("</" + n.name + ">")
+}
+
+func attrName(name xml.Name) string {
+	if name.Space == "" {
+		return name.Local
+	}
+	return name.Space + ":" + name.Local
+}
+
+var textEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;")
+
+var attrEscaper = strings.NewReplacer(
+	"&", "&amp;", "<", "&lt;", ">", "&gt;", `"`, "&quot;",
+	"\n", "&#xA;", "\r", "&#xD;", "\t", "&#x9;",
+)
+
+func escapeText(b *bytes.Buffer, s string) {
+	textEscaper.WriteString(b, s)
+}
+
+func escapeAttr(b *bytes.Buffer, s string) {
+	attrEscaper.WriteString(b, s)
+}
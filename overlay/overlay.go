@@ -0,0 +1,338 @@
+// Package overlay applies operator-supplied patches to the configuration
+// files hwc generates. An overlay is a JSON document naming the file it
+// patches and a list of operations, each addressed by an element path below
+// <configuration>:
+//
+//	{
+//	  "target": "ApplicationHost.config",
+//	  "operations": [
+//	    {"op": "set", "path": "system.webServer/security/requestFiltering/requestLimits",
+//	     "attributes": {"maxUrl": "8192"}},
+//	    {"op": "add", "path": "system.webServer/staticContent",
+//	     "element": "mimeMap", "attributes": {"fileExtension": ".avif", "mimeType": "image/avif"}},
+//	    {"op": "remove", "path": "system.webServer/handlers/add[@name='TRACEVerbHandler']"}
+//	  ]
+//	}
+//
+// Path segments are separated by / and select child elements by name. Like
+// the xdt:Locator="Match(...)" of Web.config transforms, a segment can carry
+// predicates on key attributes, such as add[@name='StaticFile']. An operation
+// applies to every element its path matches and fails when there are none.
+package overlay
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// The files an overlay can target.
+const (
+	ApplicationHost = "ApplicationHost.config"
+	Web             = "Web.config"
+)
+
+const (
+	// Set sets attributes on the matched elements, adding those they don't
+	// have yet.
+	Set = "set"
+	// Add appends a new Element with Attributes to each matched element.
+	Add = "add"
+	// Remove deletes the matched elements.
+	Remove = "remove"
+)
+
+type Operation struct {
+	Op         string            `json:"op"`
+	Path       string            `json:"path"`
+	Element    string            `json:"element,omitempty"`
+	Attributes map[string]string `json:"attributes,omitempty"`
+
+	steps []step
+}
+
+type Overlay struct {
+	// File is where the overlay was loaded from, for error messages.
+	File       string      `json:"-"`
+	Target     string      `json:"target"`
+	Operations []Operation `json:"operations"`
+}
+
+// Load reads the *.json files in dir in name order.
+func Load(dir string) ([]Overlay, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(paths)
+
+	var overlays []Overlay
+	for _, path := range paths {
+		o, err := LoadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		overlays = append(overlays, o)
+	}
+	return overlays, nil
+}
+
+func LoadFile(path string) (Overlay, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return Overlay{}, err
+	}
+	defer file.Close()
+
+	o := Overlay{File: path}
+	decoder := json.NewDecoder(file)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&o); err != nil {
+		return Overlay{}, fmt.Errorf("Invalid overlay %s: %v", path, err)
+	}
+	if err := o.validate(); err != nil {
+		return Overlay{}, fmt.Errorf("Invalid overlay %s: %v", path, err)
+	}
+	return o, nil
+}
+
+func (o *Overlay) validate() error {
+	if o.Target != ApplicationHost && o.Target != Web {
+		return fmt.Errorf("unknown target %q (expected %s or %s)", o.Target, ApplicationHost, Web)
+	}
+	for i := range o.Operations {
+		op := &o.Operations[i]
+		if err := op.validate(); err != nil {
+			return fmt.Errorf("operation %d: %v", i+1, err)
+		}
+	}
+	return nil
+}
+
+func (op *Operation) validate() error {
+	switch op.Op {
+	case Set:
+		if len(op.Attributes) == 0 {
+			return fmt.Errorf("set needs attributes")
+		}
+	case Add:
+		if op.Element == "" {
+			return fmt.Errorf("add needs an element")
+		}
+	case Remove:
+		if op.Element != "" || len(op.Attributes) != 0 {
+			return fmt.Errorf("remove takes only a path")
+		}
+	default:
+		return fmt.Errorf("unknown op %q (expected set, add or remove)", op.Op)
+	}
+	if op.Element != "" && !isName(op.Element) {
+		return fmt.Errorf("invalid element name %q", op.Element)
+	}
+	var names []string
+	for name := range op.Attributes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if !isName(name) {
+			return fmt.Errorf("invalid attribute name %q", name)
+		}
+	}
+
+	steps, err := parsePath(op.Path)
+	if err != nil {
+		return err
+	}
+	op.steps = steps
+	return nil
+}
+
+// Apply applies the overlays that target the named file to its contents, in
+// order.
+func Apply(overlays []Overlay, target string, data []byte) ([]byte, error) {
+	var doc *node
+	for _, o := range overlays {
+		if o.Target != target {
+			continue
+		}
+		if doc == nil {
+			var err error
+			if doc, err = parse(data); err != nil {
+				return nil, fmt.Errorf("Unable to apply overlay %s: %s is not valid XML: %v", o.File, target, err)
+			}
+		}
+		if err := o.apply(doc.root()); err != nil {
+			return nil, err
+		}
+	}
+	if doc == nil {
+		return data, nil
+	}
+	return doc.render(), nil
+}
+
+func (o Overlay) apply(root *node) error {
+	for i, op := range o.Operations {
+		if op.steps == nil {
+			if err := op.validate(); err != nil {
+				return fmt.Errorf("Invalid overlay %s: operation %d: %v", o.File, i+1, err)
+			}
+		}
+		matches := find(root, op.steps)
+		if len(matches) == 0 {
+			return fmt.Errorf("Overlay %s: operation %d (%s %s) matched nothing in %s", o.File, i+1, op.Op, op.Path, o.Target)
+		}
+		for _, n := range matches {
+			op.applyTo(n)
+		}
+	}
+	return nil
+}
+
+func (op Operation) applyTo(n *node) {
+	switch op.Op {
+	case Set:
+		for _, name := range sortedKeys(op.Attributes) {
+			n.setAttr(name, op.Attributes[name])
+		}
+	case Add:
+		child := &node{name: op.Element}
+		for _, name := range sortedKeys(op.Attributes) {
+			child.setAttr(name, op.Attributes[name])
+		}
+		appendChild(n, child)
+	case Remove:
+		removeChild(n.parent, n)
+	}
+}
+
+// appendChild adds child after the last element in parent, indented like its
+// siblings, or like a first child of parent when it has none.
+func appendChild(parent, child *node) {
+	child.parent = parent
+
+	last := -1
+	for i, c := range parent.children {
+		if c.isElement() {
+			last = i
+		}
+	}
+	if last != -1 {
+		nodes := []*node{child}
+		if ws, ok := whitespaceBefore(parent, last); ok {
+			nodes = []*node{{parent: parent, raw: []byte(ws)}, child}
+		}
+		parent.children = insert(parent.children, last+1, nodes...)
+		return
+	}
+
+	indent, ok := indentOf(parent)
+	if !ok {
+		parent.children = append(parent.children, child)
+		return
+	}
+	nodes := []*node{{parent: parent, raw: []byte("\n" + indent + "  ")}, child}
+	if len(parent.children) == 0 {
+		nodes = append(nodes, &node{parent: parent, raw: []byte("\n" + indent)})
+	}
+	parent.children = insert(parent.children, 0, nodes...)
+}
+
+// removeChild deletes child along with the whitespace that indents it.
+// A parent left without elements is emptied so it renders self-closed.
+func removeChild(parent, child *node) {
+	for i, c := range parent.children {
+		if c != child {
+			continue
+		}
+		start := i
+		if _, ok := whitespaceBefore(parent, i); ok {
+			start--
+		}
+		parent.children = append(parent.children[:start], parent.children[i+1:]...)
+		break
+	}
+
+	for _, c := range parent.children {
+		if c.isElement() || !isWhitespace(c.raw) {
+			return
+		}
+	}
+	if parent.parent != nil {
+		parent.children = nil
+	}
+}
+
+// whitespaceBefore returns the whitespace-only text directly preceding the
+// child at index i.
+func whitespaceBefore(parent *node, i int) (string, bool) {
+	if i == 0 {
+		return "", false
+	}
+	prev := parent.children[i-1]
+	if prev.isElement() || !isWhitespace(prev.raw) {
+		return "", false
+	}
+	return string(prev.raw), true
+}
+
+// indentOf returns the indentation of the line n starts on.
+func indentOf(n *node) (string, bool) {
+	if n.parent == nil {
+		return "", false
+	}
+	for i, c := range n.parent.children {
+		if c != n {
+			continue
+		}
+		ws, ok := whitespaceBefore(n.parent, i)
+		if !ok {
+			return "", false
+		}
+		nl := strings.LastIndex(ws, "\n")
+		if nl == -1 {
+			return "", false
+		}
+		return ws[nl+1:], true
+	}
+	return "", false
+}
+
+func isWhitespace(raw []byte) bool {
+	return len(raw) != 0 && strings.TrimSpace(string(raw)) == ""
+}
+
+func insert(nodes []*node, i int, more ...*node) []*node {
+	result := make([]*node, 0, len(nodes)+len(more))
+	result = append(result, nodes[:i]...)
+	result = append(result, more...)
+	return append(result, nodes[i:]...)
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// isName reports whether s can be written as an XML element or attribute
+// name, so an overlay can't produce a config IIS fails to parse.
+func isName(s string) bool {
+	for i, r := range s {
+		switch {
+		case unicode.IsLetter(r), r == '_', r == ':':
+		case i > 0 && (unicode.IsDigit(r) || r == '.' || r == '-'):
+		default:
+			return false
+		}
+	}
+	return s != ""
+}
@@ -0,0 +1,212 @@
+package overlay_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"code.cloudfoundry.org/hwc/overlay"
+)
+
+const document = `<?xml version="1.0" encoding="UTF-8"?>
+<!-- generated -->
+<configuration>
+  <system.webServer>
+    <handlers accessPolicy="Read, Script">
+      <add name="TRACEVerbHandler" path="*" verb="TRACE" />
+      <add name="StaticFile" path="*" verb="*" />
+    </handlers>
+    <security>
+      <requestFiltering>
+        <requestLimits maxAllowedContentLength="30000000" />
+      </requestFiltering>
+    </security>
+    <staticContent />
+    <location path="Default Web Site/api" />
+  </system.webServer>
+</configuration>
+`
+
+var _ = Describe("Overlay", func() {
+	var apply = func(ops ...overlay.Operation) (string, error) {
+		o := overlay.Overlay{File: "test.json", Target: overlay.ApplicationHost, Operations: ops}
+		out, err := overlay.Apply([]overlay.Overlay{o}, overlay.ApplicationHost, []byte(document))
+		return string(out), err
+	}
+
+	It("leaves documents without overlays untouched", func() {
+		o := overlay.Overlay{Target: overlay.Web, Operations: []overlay.Operation{{Op: overlay.Remove, Path: "system.webServer"}}}
+		out, err := overlay.Apply([]overlay.Overlay{o}, overlay.ApplicationHost, []byte(document))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(out)).To(Equal(document))
+	})
+
+	It("sets attributes, keeping the ones already there in place", func() {
+		out, err := apply(overlay.Operation{
+			Op:         overlay.Set,
+			Path:       "system.webServer/security/requestFiltering/requestLimits",
+			Attributes: map[string]string{"maxUrl": "8192", "maxAllowedContentLength": "1000"},
+		})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(out).To(ContainSubstring(`<requestLimits maxAllowedContentLength="1000" maxUrl="8192" />`))
+		Expect(out).To(HavePrefix("<?xml version=\"1.0\" encoding=\"UTF-8\"?>\n<!-- generated -->\n<configuration>"))
+	})
+
+	It("selects elements by their key attributes", func() {
+		out, err := apply(overlay.Operation{
+			Op:         overlay.Set,
+			Path:       "system.webServer/handlers/add[@name='StaticFile'][@verb='*']",
+			Attributes: map[string]string{"requireAccess": "Read"},
+		})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(out).To(ContainSubstring(`<add name="StaticFile" path="*" verb="*" requireAccess="Read" />`))
+		Expect(out).To(ContainSubstring(`<add name="TRACEVerbHandler" path="*" verb="TRACE" />`))
+	})
+
+	It("allows / inside key values", func() {
+		out, err := apply(overlay.Operation{
+			Op:         overlay.Set,
+			Path:       `system.webServer/location[@path="Default Web Site/api"]`,
+			Attributes: map[string]string{"overrideMode": "Allow"},
+		})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(out).To(ContainSubstring(`<location path="Default Web Site/api" overrideMode="Allow" />`))
+	})
+
+	It("adds elements after their siblings, with attributes in name order", func() {
+		out, err := apply(overlay.Operation{
+			Op:         overlay.Add,
+			Path:       "system.webServer/handlers",
+			Element:    "add",
+			Attributes: map[string]string{"verb": "GET", "path": "*.svg", "name": "Svg"},
+		})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(out).To(ContainSubstring(`      <add name="StaticFile" path="*" verb="*" />
+      <add name="Svg" path="*.svg" verb="GET" />
+    </handlers>`))
+	})
+
+	It("indents elements added to an empty element", func() {
+		out, err := apply(overlay.Operation{
+			Op:         overlay.Add,
+			Path:       "system.webServer/staticContent",
+			Element:    "mimeMap",
+			Attributes: map[string]string{"fileExtension": ".avif", "mimeType": "image/avif"},
+		})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(out).To(ContainSubstring(`    <staticContent>
+      <mimeMap fileExtension=".avif" mimeType="image/avif" />
+    </staticContent>`))
+	})
+
+	It("removes elements along with their indentation", func() {
+		out, err := apply(overlay.Operation{
+			Op:   overlay.Remove,
+			Path: "system.webServer/handlers/add[@name='TRACEVerbHandler']",
+		})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(out).To(ContainSubstring(`    <handlers accessPolicy="Read, Script">
+      <add name="StaticFile" path="*" verb="*" />
+    </handlers>`))
+	})
+
+	It("self-closes elements left empty", func() {
+		out, err := apply(overlay.Operation{Op: overlay.Remove, Path: "system.webServer/handlers/add"})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(out).To(ContainSubstring(`    <handlers accessPolicy="Read, Script" />
+    <security>`))
+	})
+
+	It("escapes the values it writes", func() {
+		out, err := apply(overlay.Operation{
+			Op:         overlay.Add,
+			Path:       "system.webServer/staticContent",
+			Element:    "clientCache",
+			Attributes: map[string]string{"cacheControlCustom": `R&D <"x">`},
+		})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(out).To(ContainSubstring(`<clientCache cacheControlCustom="R&amp;D &lt;&quot;x&quot;&gt;" />`))
+	})
+
+	It("fails when a path matches nothing", func() {
+		_, err := apply(
+			overlay.Operation{Op: overlay.Set, Path: "system.webServer/handlers", Attributes: map[string]string{"accessPolicy": "Read"}},
+			overlay.Operation{Op: overlay.Remove, Path: "system.webServer/handlers/add[@name='NoSuchHandler']"},
+		)
+		Expect(err).To(MatchError("Overlay test.json: operation 2 (remove system.webServer/handlers/add[@name='NoSuchHandler']) matched nothing in ApplicationHost.config"))
+	})
+
+	It("fails on paths it can't parse", func() {
+		_, err := apply(overlay.Operation{Op: overlay.Remove, Path: "system.webServer/handlers/add[name=StaticFile]"})
+		Expect(err).To(MatchError(ContainSubstring("Invalid overlay test.json: operation 1: predicates must look like [@name='value']")))
+	})
+
+	Describe("Load", func() {
+		It("reads the JSON files in a directory in name order", func() {
+			overlays, err := overlay.Load(filepath.Join("..", "fixtures", "overlays", "valid"))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(overlays).To(HaveLen(2))
+			Expect(overlays[0].File).To(HaveSuffix("10-limits.json"))
+			Expect(overlays[0].Target).To(Equal(overlay.ApplicationHost))
+			Expect(overlays[0].Operations).To(HaveLen(3))
+			Expect(overlays[1].Target).To(Equal(overlay.Web))
+		})
+
+		It("returns nothing for a directory without overlays", func() {
+			dir, err := ioutil.TempDir("", "overlay_test")
+			Expect(err).ToNot(HaveOccurred())
+			defer os.RemoveAll(dir)
+
+			overlays, err := overlay.Load(dir)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(overlays).To(BeEmpty())
+		})
+
+		Context("when an overlay is invalid", func() {
+			var dir string
+
+			BeforeEach(func() {
+				var err error
+				dir, err = ioutil.TempDir("", "overlay_test")
+				Expect(err).ToNot(HaveOccurred())
+			})
+
+			AfterEach(func() {
+				_ = os.RemoveAll(dir)
+			})
+
+			It("rejects unknown targets", func() {
+				Expect(ioutil.WriteFile(filepath.Join(dir, "a.json"), []byte(`{"target": "Machine.config", "operations": []}`), 0644)).To(Succeed())
+				_, err := overlay.Load(dir)
+				Expect(err).To(MatchError(ContainSubstring(`unknown target "Machine.config" (expected ApplicationHost.config or Web.config)`)))
+			})
+
+			It("rejects unknown ops", func() {
+				Expect(ioutil.WriteFile(filepath.Join(dir, "a.json"), []byte(`{"target": "Web.config", "operations": [{"op": "replace", "path": "system.web"}]}`), 0644)).To(Succeed())
+				_, err := overlay.Load(dir)
+				Expect(err).To(MatchError(ContainSubstring(`operation 1: unknown op "replace" (expected set, add or remove)`)))
+			})
+
+			It("rejects element names that aren't XML names", func() {
+				Expect(ioutil.WriteFile(filepath.Join(dir, "a.json"), []byte(`{"target": "Web.config", "operations": [{"op": "add", "path": "system.web", "element": "a b"}]}`), 0644)).To(Succeed())
+				_, err := overlay.Load(dir)
+				Expect(err).To(MatchError(ContainSubstring(`operation 1: invalid element name "a b"`)))
+			})
+
+			It("rejects attribute names that aren't XML names", func() {
+				Expect(ioutil.WriteFile(filepath.Join(dir, "a.json"), []byte(`{"target": "Web.config", "operations": [{"op": "set", "path": "system.web", "attributes": {"debug": "true", "x\"y": "1"}}]}`), 0644)).To(Succeed())
+				_, err := overlay.Load(dir)
+				Expect(err).To(MatchError(ContainSubstring(`operation 1: invalid attribute name "x\"y"`)))
+			})
+
+			It("rejects unknown fields", func() {
+				Expect(ioutil.WriteFile(filepath.Join(dir, "a.json"), []byte(`{"target": "Web.config", "transform": "Replace"}`), 0644)).To(Succeed())
+				_, err := overlay.Load(dir)
+				Expect(err).To(MatchError(ContainSubstring("Invalid overlay")))
+			})
+		})
+	})
+})
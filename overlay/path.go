@@ -0,0 +1,118 @@
+package overlay
+
+import (
+	"fmt"
+	"strings"
+)
+
+// step is one segment of a path: an element name and the attribute values an
+// element must have to match, as in add[@name='StaticFile'].
+type step struct {
+	name string
+	keys []key
+}
+
+type key struct {
+	attr  string
+	value string
+}
+
+// parsePath splits a path such as
+// system.webServer/handlers/add[@name='StaticFile'] into steps. Values are
+// quoted with ' or " and may contain /.
+func parsePath(path string) ([]step, error) {
+	if path == "" {
+		return nil, fmt.Errorf("empty path")
+	}
+
+	var steps []step
+	rest := path
+	for {
+		end := strings.IndexAny(rest, "/[")
+		if end == -1 {
+			end = len(rest)
+		}
+		s := step{name: rest[:end]}
+		if s.name == "" {
+			return nil, fmt.Errorf("missing element name in %q", path)
+		}
+		rest = rest[end:]
+
+		for strings.HasPrefix(rest, "[") {
+			k, n, err := parseKey(rest)
+			if err != nil {
+				return nil, fmt.Errorf("%v in %q", err, path)
+			}
+			s.keys = append(s.keys, k)
+			rest = rest[n:]
+		}
+		steps = append(steps, s)
+
+		if rest == "" {
+			return steps, nil
+		}
+		if rest[0] != '/' {
+			return nil, fmt.Errorf("unexpected %q in %q", rest, path)
+		}
+		rest = rest[1:]
+	}
+}
+
+// parseKey reads a predicate of the form [@attr='value'] and returns it along
+// with the number of bytes it took up.
+func parseKey(s string) (key, int, error) {
+	if !strings.HasPrefix(s, "[@") {
+		return key{}, 0, fmt.Errorf("predicates must look like [@name='value']")
+	}
+	eq := strings.IndexByte(s, '=')
+	if eq == -1 || eq+1 >= len(s) {
+		return key{}, 0, fmt.Errorf("predicates must look like [@name='value']")
+	}
+	quote := s[eq+1]
+	if quote != '\'' && quote != '"' {
+		return key{}, 0, fmt.Errorf("predicate values must be quoted")
+	}
+	closing := strings.IndexByte(s[eq+2:], quote)
+	if closing == -1 {
+		return key{}, 0, fmt.Errorf("unterminated predicate value")
+	}
+	end := eq + 2 + closing
+	if end+1 >= len(s) || s[end+1] != ']' {
+		return key{}, 0, fmt.Errorf("predicates must look like [@name='value']")
+	}
+	k := key{attr: strings.TrimSpace(s[2:eq]), value: s[eq+2 : end]}
+	if k.attr == "" {
+		return key{}, 0, fmt.Errorf("missing attribute name")
+	}
+	return k, end + 2, nil
+}
+
+// find returns the elements below root that the steps lead to, in document
+// order.
+func find(root *node, steps []step) []*node {
+	matches := []*node{root}
+	for _, s := range steps {
+		var next []*node
+		for _, parent := range matches {
+			for _, child := range parent.children {
+				if s.matches(child) {
+					next = append(next, child)
+				}
+			}
+		}
+		matches = next
+	}
+	return matches
+}
+
+func (s step) matches(n *node) bool {
+	if !n.isElement() || n.name != s.name {
+		return false
+	}
+	for _, k := range s.keys {
+		if v, ok := n.attrValue(k.attr); !ok || v != k.value {
+			return false
+		}
+	}
+	return true
+}
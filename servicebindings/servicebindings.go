@@ -0,0 +1,280 @@
+// Package servicebindings maps the services bound to a Cloud Foundry app to
+// the connection strings and app settings .NET Framework apps read through
+// ConfigurationManager.
+package servicebindings
+
+import (
+	"encoding/xml"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/cloudfoundry-community/go-cfenv"
+
+	"code.cloudfoundry.org/hwc/webconfig"
+)
+
+// Rule maps the bound services it matches. A service matches when it has
+// every non-empty Name, Label and Tag the rule sets. Values may refer to the
+// service's credentials as ${key}, or ${key.nested} for nested objects.
+type Rule struct {
+	Name  string `json:"name"`
+	Label string `json:"label"`
+	Tag   string `json:"tag"`
+
+	ConnectionString *ConnectionStringRule `json:"connectionString"`
+	AppSettings      map[string]string     `json:"appSettings"`
+}
+
+// ConnectionStringRule describes the connection string a Rule adds. Name
+// defaults to the service name.
+type ConnectionStringRule struct {
+	Name         string `json:"name"`
+	Value        string `json:"value"`
+	ProviderName string `json:"providerName"`
+}
+
+type ConnectionString struct {
+	Name             string
+	ConnectionString string
+	ProviderName     string
+}
+
+type AppSetting struct {
+	Key   string
+	Value string
+}
+
+// Overlay is what the bound services add to the app's Web.config.
+type Overlay struct {
+	ConnectionStrings []ConnectionString
+	AppSettings       []AppSetting
+}
+
+var credentialPattern = regexp.MustCompile(`\$\{([^}]*)\}`)
+
+var connectionStringCredentials = []string{"connectionString", "connection_string", "connectionstring"}
+
+var providerNames = []struct {
+	match        string
+	providerName string
+}{
+	{"sqlserver", "System.Data.SqlClient"},
+	{"mssql", "System.Data.SqlClient"},
+	{"mysql", "MySql.Data.MySqlClient"},
+	{"postgres", "Npgsql"},
+	{"oracle", "Oracle.ManagedDataAccess.Client"},
+}
+
+// New maps the services bound to appEnv, which is nil when hwc isn't running
+// on CF. Services no rule matches get a connection string named after the
+// service if their credentials include a connectionString.
+func New(appEnv *cfenv.App, rules []Rule) (Overlay, error) {
+	for i, rule := range rules {
+		if rule.Name == "" && rule.Label == "" && rule.Tag == "" {
+			return Overlay{}, fmt.Errorf("Service binding rule %d must set a name, label or tag", i+1)
+		}
+	}
+
+	var overlay Overlay
+	for _, service := range boundServices(appEnv) {
+		matched := false
+		for _, rule := range rules {
+			if !rule.matches(service) {
+				continue
+			}
+			matched = true
+			if err := overlay.addRule(rule, service); err != nil {
+				return Overlay{}, err
+			}
+		}
+		if !matched {
+			overlay.addDefault(service)
+		}
+	}
+
+	if err := overlay.checkUnique(); err != nil {
+		return Overlay{}, err
+	}
+	return overlay, nil
+}
+
+func boundServices(appEnv *cfenv.App) []cfenv.Service {
+	var services []cfenv.Service
+	if appEnv == nil {
+		return services
+	}
+	for _, s := range appEnv.Services {
+		services = append(services, s...)
+	}
+	sort.Slice(services, func(i, j int) bool {
+		return services[i].Name < services[j].Name
+	})
+	return services
+}
+
+func (r Rule) matches(service cfenv.Service) bool {
+	if r.Name != "" && r.Name != service.Name {
+		return false
+	}
+	if r.Label != "" && r.Label != service.Label {
+		return false
+	}
+	if r.Tag != "" && !hasTag(service, r.Tag) {
+		return false
+	}
+	return true
+}
+
+func hasTag(service cfenv.Service, tag string) bool {
+	for _, t := range service.Tags {
+		if strings.EqualFold(t, tag) {
+			return true
+		}
+	}
+	return false
+}
+
+func (o *Overlay) addRule(rule Rule, service cfenv.Service) error {
+	if cs := rule.ConnectionString; cs != nil {
+		value, err := expand(service, cs.Value)
+		if err != nil {
+			return err
+		}
+		name := cs.Name
+		if name == "" {
+			name = service.Name
+		}
+		providerName := cs.ProviderName
+		if providerName == "" {
+			providerName = defaultProviderName(service)
+		}
+		o.ConnectionStrings = append(o.ConnectionStrings, ConnectionString{Name: name, ConnectionString: value, ProviderName: providerName})
+	}
+
+	keys := make([]string, 0, len(rule.AppSettings))
+	for key := range rule.AppSettings {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		value, err := expand(service, rule.AppSettings[key])
+		if err != nil {
+			return err
+		}
+		o.AppSettings = append(o.AppSettings, AppSetting{Key: key, Value: value})
+	}
+	return nil
+}
+
+func (o *Overlay) addDefault(service cfenv.Service) {
+	for _, key := range connectionStringCredentials {
+		if value, ok := service.CredentialString(key); ok {
+			o.ConnectionStrings = append(o.ConnectionStrings, ConnectionString{
+				Name:             service.Name,
+				ConnectionString: value,
+				ProviderName:     defaultProviderName(service),
+			})
+			return
+		}
+	}
+}
+
+// defaultProviderName guesses the ADO.NET provider from the service's label
+// and tags.
+func defaultProviderName(service cfenv.Service) string {
+	for _, p := range providerNames {
+		for _, name := range append([]string{service.Label}, service.Tags...) {
+			if strings.Contains(strings.ToLower(name), p.match) {
+				return p.providerName
+			}
+		}
+	}
+	return ""
+}
+
+func (o *Overlay) checkUnique() error {
+	names := map[string]bool{}
+	for _, cs := range o.ConnectionStrings {
+		name := strings.ToLower(cs.Name)
+		if names[name] {
+			return fmt.Errorf("Bound services map to more than one connection string named %s", cs.Name)
+		}
+		names[name] = true
+	}
+	keys := map[string]bool{}
+	for _, setting := range o.AppSettings {
+		key := strings.ToLower(setting.Key)
+		if keys[key] {
+			return fmt.Errorf("Bound services map to more than one app setting named %s", setting.Key)
+		}
+		keys[key] = true
+	}
+	return nil
+}
+
+func expand(service cfenv.Service, template string) (string, error) {
+	var err error
+	value := credentialPattern.ReplaceAllStringFunc(template, func(match string) string {
+		path := credentialPattern.FindStringSubmatch(match)[1]
+		credential, ok := lookupCredential(service.Credentials, path)
+		if !ok && err == nil {
+			err = fmt.Errorf("Service %s has no credential %s", service.Name, path)
+		}
+		return credential
+	})
+	return value, err
+}
+
+func lookupCredential(credentials map[string]interface{}, path string) (string, bool) {
+	var value interface{} = credentials
+	for _, key := range strings.Split(path, ".") {
+		object, ok := value.(map[string]interface{})
+		if !ok {
+			return "", false
+		}
+		if value, ok = object[key]; !ok {
+			return "", false
+		}
+	}
+
+	switch v := value.(type) {
+	case string:
+		return v, true
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), true
+	case bool:
+		return strconv.FormatBool(v), true
+	}
+	return "", false
+}
+
+func (o Overlay) Empty() bool {
+	return len(o.ConnectionStrings) == 0 && len(o.AppSettings) == 0
+}
+
+// Apply merges the overlay into the text of a Web.config, replacing entries
+// the app already defines with the same name.
+func (o Overlay) Apply(webConfig []byte) ([]byte, error) {
+	connectionStrings := webconfig.Collection{Section: "connectionStrings", Key: "name"}
+	for _, cs := range o.ConnectionStrings {
+		add := webconfig.Add{attr("name", cs.Name), attr("connectionString", cs.ConnectionString)}
+		if cs.ProviderName != "" {
+			add = append(add, attr("providerName", cs.ProviderName))
+		}
+		connectionStrings.Adds = append(connectionStrings.Adds, add)
+	}
+
+	appSettings := webconfig.Collection{Section: "appSettings", Key: "key"}
+	for _, setting := range o.AppSettings {
+		appSettings.Adds = append(appSettings.Adds, webconfig.Add{attr("key", setting.Key), attr("value", setting.Value)})
+	}
+
+	return webconfig.Merge(webConfig, connectionStrings, appSettings)
+}
+
+func attr(name, value string) xml.Attr {
+	return xml.Attr{Name: xml.Name{Local: name}, Value: value}
+}
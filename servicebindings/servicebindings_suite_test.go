@@ -0,0 +1,13 @@
+package servicebindings_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func TestServicebindings(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Servicebindings Suite")
+}
@@ -0,0 +1,172 @@
+package servicebindings_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"code.cloudfoundry.org/hwc/servicebindings"
+	"github.com/cloudfoundry-community/go-cfenv"
+)
+
+var _ = Describe("Servicebindings", func() {
+	var appEnv *cfenv.App
+
+	BeforeEach(func() {
+		appEnv = &cfenv.App{
+			Services: cfenv.Services{
+				"azure-sqldb": []cfenv.Service{{
+					Name:  "orders-db",
+					Label: "azure-sqldb",
+					Tags:  []string{"sqlserver", "relational"},
+					Credentials: map[string]interface{}{
+						"connectionString": "Server=tcp:orders.example.com,1433;Database=orders",
+						"hostname":         "orders.example.com",
+						"port":             float64(1433),
+					},
+				}},
+				"p.redis": []cfenv.Service{{
+					Name:  "cache",
+					Label: "p.redis",
+					Tags:  []string{"redis"},
+					Credentials: map[string]interface{}{
+						"host":     "redis.example.com",
+						"password": "secret",
+						"tls":      map[string]interface{}{"port": float64(6380)},
+					},
+				}},
+				"user-provided": []cfenv.Service{{
+					Name:  "payments",
+					Label: "user-provided",
+					Credentials: map[string]interface{}{
+						"connection_string": "Host=payments;Database=payments",
+					},
+				}},
+			},
+		}
+	})
+
+	Describe("New", func() {
+		It("adds a connection string for each service with one in its credentials", func() {
+			overlay, err := servicebindings.New(appEnv, nil)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(overlay.ConnectionStrings).To(Equal([]servicebindings.ConnectionString{
+				{Name: "orders-db", ConnectionString: "Server=tcp:orders.example.com,1433;Database=orders", ProviderName: "System.Data.SqlClient"},
+				{Name: "payments", ConnectionString: "Host=payments;Database=payments"},
+			}))
+			Expect(overlay.AppSettings).To(BeEmpty())
+		})
+
+		It("maps services matched by a rule", func() {
+			overlay, err := servicebindings.New(appEnv, []servicebindings.Rule{
+				{
+					Tag: "redis",
+					AppSettings: map[string]string{
+						"RedisHost":       "${host}",
+						"RedisConnection": "${host}:${tls.port},password=${password},ssl=true",
+					},
+				},
+				{
+					Name: "payments",
+					ConnectionString: &servicebindings.ConnectionStringRule{
+						Name:         "PaymentsContext",
+						Value:        "${connection_string};Pooling=true",
+						ProviderName: "Npgsql",
+					},
+				},
+			})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(overlay.ConnectionStrings).To(Equal([]servicebindings.ConnectionString{
+				{Name: "orders-db", ConnectionString: "Server=tcp:orders.example.com,1433;Database=orders", ProviderName: "System.Data.SqlClient"},
+				{Name: "PaymentsContext", ConnectionString: "Host=payments;Database=payments;Pooling=true", ProviderName: "Npgsql"},
+			}))
+			Expect(overlay.AppSettings).To(Equal([]servicebindings.AppSetting{
+				{Key: "RedisConnection", Value: "redis.example.com:6380,password=secret,ssl=true"},
+				{Key: "RedisHost", Value: "redis.example.com"},
+			}))
+		})
+
+		It("requires every selector of a rule to match", func() {
+			overlay, err := servicebindings.New(appEnv, []servicebindings.Rule{{
+				Label:       "azure-sqldb",
+				Tag:         "redis",
+				AppSettings: map[string]string{"Unexpected": "${host}"},
+			}})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(overlay.AppSettings).To(BeEmpty())
+		})
+
+		It("names the connection string after the service by default", func() {
+			overlay, err := servicebindings.New(appEnv, []servicebindings.Rule{{
+				Label:            "azure-sqldb",
+				ConnectionString: &servicebindings.ConnectionStringRule{Value: "Server=${hostname},${port}"},
+			}})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(overlay.ConnectionStrings[0]).To(Equal(servicebindings.ConnectionString{
+				Name:             "orders-db",
+				ConnectionString: "Server=orders.example.com,1433",
+				ProviderName:     "System.Data.SqlClient",
+			}))
+		})
+
+		It("fails when a credential is missing", func() {
+			_, err := servicebindings.New(appEnv, []servicebindings.Rule{{
+				Name:        "cache",
+				AppSettings: map[string]string{"RedisUser": "${username}"},
+			}})
+			Expect(err).To(MatchError("Service cache has no credential username"))
+		})
+
+		It("fails when two services map to the same connection string", func() {
+			_, err := servicebindings.New(appEnv, []servicebindings.Rule{{
+				Name:             "cache",
+				ConnectionString: &servicebindings.ConnectionStringRule{Name: "ORDERS-DB", Value: "${host}"},
+			}})
+			Expect(err).To(MatchError("Bound services map to more than one connection string named orders-db"))
+		})
+
+		It("rejects rules without a selector", func() {
+			_, err := servicebindings.New(appEnv, []servicebindings.Rule{{AppSettings: map[string]string{"a": "b"}}})
+			Expect(err).To(MatchError("Service binding rule 1 must set a name, label or tag"))
+		})
+
+		It("is empty when no services are bound", func() {
+			overlay, err := servicebindings.New(&cfenv.App{}, nil)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(overlay.Empty()).To(BeTrue())
+		})
+
+		It("is empty when hwc isn't running on CF", func() {
+			overlay, err := servicebindings.New(nil, nil)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(overlay.Empty()).To(BeTrue())
+		})
+	})
+
+	Describe("Apply", func() {
+		It("merges the overlay into the Web.config", func() {
+			overlay := servicebindings.Overlay{
+				ConnectionStrings: []servicebindings.ConnectionString{{Name: "orders", ConnectionString: "Server=db", ProviderName: "System.Data.SqlClient"}},
+				AppSettings:       []servicebindings.AppSetting{{Key: "RedisHost", Value: "redis.example.com"}},
+			}
+
+			webConfig, err := overlay.Apply([]byte(`<configuration>
+  <appSettings>
+    <add key="RedisHost" value="localhost" />
+    <add key="Theme" value="dark" />
+  </appSettings>
+</configuration>
+`))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(string(webConfig)).To(Equal(`<configuration>
+  <appSettings>
+    <add key="Theme" value="dark" />
+    <add key="RedisHost" value="redis.example.com" />
+  </appSettings>
+  <connectionStrings>
+    <add name="orders" connectionString="Server=db" providerName="System.Data.SqlClient" />
+  </connectionStrings>
+</configuration>
+`))
+		})
+	})
+})
@@ -3,20 +3,49 @@ package validator
 import (
 	"encoding/xml"
 	"fmt"
-	"io"
 	"io/ioutil"
 	_ "runtime/cgo"
+	"strconv"
 	"strings"
+
+	"code.cloudfoundry.org/hwc/hwclog"
 )
 
 type Configuration struct {
 	XMLName         xml.Name `xml:"configuration"`
+	SystemWeb       SystemWeb
 	SystemWebServer SystemWebServer
+	Locations       []Location `xml:"location"`
+}
+
+type Location struct {
+	Path            string `xml:"path,attr"`
+	SystemWeb       SystemWeb
+	SystemWebServer SystemWebServer
+}
+
+type SystemWeb struct {
+	XMLName     xml.Name `xml:"system.web"`
+	HTTPRuntime HTTPRuntime
+}
+
+type HTTPRuntime struct {
+	XMLName                      xml.Name `xml:"httpRuntime"`
+	MaxRequestLength             string   `xml:"maxRequestLength,attr"`
+	MaxURLLength                 string   `xml:"maxUrlLength,attr"`
+	MaxQueryStringLength         string   `xml:"maxQueryStringLength,attr"`
+	RequestPathInvalidCharacters *string  `xml:"requestPathInvalidCharacters,attr"`
 }
 
 type SystemWebServer struct {
 	XMLName         xml.Name `xml:"system.webServer"`
 	HTTPCompression HTTPCompression
+	Security        Security
+	WebSocket       *WebSocket
+}
+
+type WebSocket struct {
+	XMLName xml.Name `xml:"webSocket"`
 }
 
 type HTTPCompression struct {
@@ -31,7 +60,54 @@ type HTTPCompression struct {
 	UnwantedTags []xml.Name `xml:",any"`
 }
 
-func ValidateWebConfig(path string, writer io.Writer) error {
+type Security struct {
+	XMLName          xml.Name `xml:"security"`
+	RequestFiltering RequestFiltering
+}
+
+type RequestFiltering struct {
+	XMLName       xml.Name `xml:"requestFiltering"`
+	RequestLimits struct {
+		XMLName                 xml.Name `xml:"requestLimits"`
+		MaxAllowedContentLength string   `xml:"maxAllowedContentLength,attr"`
+		MaxURL                  string   `xml:"maxUrl,attr"`
+		MaxQueryString          string   `xml:"maxQueryString,attr"`
+	}
+	DenyURLSequences struct {
+		XMLName xml.Name     `xml:"denyUrlSequences"`
+		Entries []collection `xml:",any"`
+	}
+}
+
+type collection struct {
+	XMLName  xml.Name
+	Sequence string `xml:"sequence,attr"`
+}
+
+// HostRequestFiltering describes the request filtering rules rendered into
+// ApplicationHost.config. They apply to the app unless its Web.config
+// overrides them.
+type HostRequestFiltering struct {
+	MaxAllowedContentLength uint64
+	MaxURL                  uint64
+	MaxQueryString          uint64
+	DenyURLSequences        []string
+}
+
+func DefaultHostRequestFiltering() HostRequestFiltering {
+	return HostRequestFiltering{
+		MaxAllowedContentLength: 2097152,
+		MaxURL:                  260,
+		MaxQueryString:          2048,
+		DenyURLSequences:        []string{"..", "./", `\`, ":", "%", "&"},
+	}
+}
+
+func ValidateWebConfig(path string, log *hwclog.Logger) error {
+	return ValidateWebConfigForHost(path, DefaultHostRequestFiltering(), log)
+}
+
+func ValidateWebConfigForHost(path string, host HostRequestFiltering, log *hwclog.Logger) error {
 	data, err := ioutil.ReadFile(path)
 	if err != nil {
 		return err
@@ -44,16 +120,175 @@ func ValidateWebConfig(path string, writer io.Writer) error {
 
 	hcAttrs := conf.SystemWebServer.HTTPCompression.Attrs
 	if len(hcAttrs) > 0 {
-		fmt.Fprintf(writer, "Warning: <httpCompression> should not have any attributes but it has %+v\n", collectAttrs(hcAttrs))
+		log.Warn("web-config-warning", fmt.Sprintf("<httpCompression> should not have any attributes but it has %+v", collectAttrs(hcAttrs)),
+			hwclog.Fields{"element": "httpCompression"})
 	}
 
 	unwantedTags := conf.SystemWebServer.HTTPCompression.UnwantedTags
 	if len(unwantedTags) > 0 {
-		fmt.Fprintf(writer, "Warning: <httpCompression> should not have any child tags other than <staticTypes> and <dynamicTypes> but it has %+v\n", collectNames(unwantedTags))
+		log.Warn("web-config-warning", fmt.Sprintf("<httpCompression> should not have any child tags other than <staticTypes> and <dynamicTypes> but it has %+v", collectNames(unwantedTags)),
+			hwclog.Fields{"element": "httpCompression"})
 	}
+
+	validateRequestLimits(conf, host, log)
+	validateWebSocket(conf, log)
 	return nil
 }
 
+// validateWebSocket warns about a <webSocket> section, which hwc locks to
+// ApplicationHost.config. Whatever path a <location> gives, IIS rejects it.
+func validateWebSocket(conf Configuration, log *hwclog.Logger) {
+	configured := conf.SystemWebServer.WebSocket != nil
+	for _, location := range conf.Locations {
+		configured = configured || location.SystemWebServer.WebSocket != nil
+	}
+	if configured {
+		log.Warn("web-config-warning", "<webSocket> is locked in ApplicationHost.config; requests will fail with HTTP 500.19. Use HWC_WEBSOCKET_ENABLED, HWC_WEBSOCKET_PING_INTERVAL and HWC_WEBSOCKET_RECEIVE_BUFFER_LIMIT instead",
+			hwclog.Fields{"element": "webSocket"})
+	}
+}
+
+// validateRequestLimits warns when the ASP.NET limits in <httpRuntime> allow
+// requests that IIS request filtering rejects before they reach the app.
+func validateRequestLimits(conf Configuration, host HostRequestFiltering, log *hwclog.Logger) {
+	runtime := conf.SystemWeb.HTTPRuntime
+	filtering := []RequestFiltering{conf.SystemWebServer.Security.RequestFiltering}
+	for _, location := range conf.Locations {
+		if location.Path != "" && location.Path != "." {
+			continue
+		}
+		runtime = mergeHTTPRuntime(runtime, location.SystemWeb.HTTPRuntime)
+		filtering = append(filtering, location.SystemWebServer.Security.RequestFiltering)
+	}
+
+	effective := host
+	for _, rf := range filtering {
+		effective = applyRequestFiltering(effective, rf)
+	}
+
+	if maxRequestLength, ok := parseLimit(runtime.MaxRequestLength); ok && maxRequestLength*1024 > effective.MaxAllowedContentLength {
+		log.Warn("web-config-warning", fmt.Sprintf("<httpRuntime maxRequestLength=\"%d\"> allows %d bytes but <requestLimits maxAllowedContentLength> is %d; larger requests will fail with HTTP 404.13",
+			maxRequestLength, maxRequestLength*1024, effective.MaxAllowedContentLength), hwclog.Fields{"element": "httpRuntime", "attribute": "maxRequestLength"})
+	}
+
+	if maxURLLength, ok := parseLimit(runtime.MaxURLLength); ok && maxURLLength > effective.MaxURL {
+		log.Warn("web-config-warning", fmt.Sprintf("<httpRuntime maxUrlLength=\"%d\"> exceeds <requestLimits maxUrl> of %d; longer URLs will fail with HTTP 404.14",
+			maxURLLength, effective.MaxURL), hwclog.Fields{"element": "httpRuntime", "attribute": "maxUrlLength"})
+	}
+
+	if maxQueryStringLength, ok := parseLimit(runtime.MaxQueryStringLength); ok && maxQueryStringLength > effective.MaxQueryString {
+		log.Warn("web-config-warning", fmt.Sprintf("<httpRuntime maxQueryStringLength=\"%d\"> exceeds <requestLimits maxQueryString> of %d; longer query strings will fail with HTTP 404.15",
+			maxQueryStringLength, effective.MaxQueryString), hwclog.Fields{"element": "httpRuntime", "attribute": "maxQueryStringLength"})
+	}
+
+	if runtime.RequestPathInvalidCharacters != nil {
+		invalid := splitCharacters(*runtime.RequestPathInvalidCharacters)
+
+		var denied []string
+		for _, sequence := range effective.DenyURLSequences {
+			if allowsSequence(invalid, sequence) {
+				denied = append(denied, fmt.Sprintf("'%s'", sequence))
+			}
+		}
+		if len(denied) > 0 {
+			log.Warn("web-config-warning", fmt.Sprintf("<httpRuntime requestPathInvalidCharacters> allows %s but <denyUrlSequences> still rejects them; such URLs will fail with HTTP 404.5",
+				strings.Join(denied, ", ")), hwclog.Fields{"element": "httpRuntime", "attribute": "requestPathInvalidCharacters"})
+		}
+	}
+}
+
+// defaultRequestPathInvalidCharacters is the ASP.NET default for
+// <httpRuntime requestPathInvalidCharacters>.
+const defaultRequestPathInvalidCharacters = "<,>,*,%,&,:,\\,?"
+
+func splitCharacters(list string) map[rune]bool {
+	characters := map[rune]bool{}
+	for _, entry := range strings.Split(list, ",") {
+		for _, c := range strings.TrimSpace(entry) {
+			characters[c] = true
+		}
+	}
+	return characters
+}
+
+// allowsSequence reports whether the app has removed from its invalid
+// characters something ASP.NET rejects by default, so that a URL containing
+// sequence would reach it if request filtering let it through.
+func allowsSequence(invalid map[rune]bool, sequence string) bool {
+	defaults := splitCharacters(defaultRequestPathInvalidCharacters)
+	relaxed := false
+	for _, c := range sequence {
+		if invalid[c] {
+			return false
+		}
+		relaxed = relaxed || defaults[c]
+	}
+	return relaxed
+}
+
+func mergeHTTPRuntime(base, override HTTPRuntime) HTTPRuntime {
+	if override.MaxRequestLength != "" {
+		base.MaxRequestLength = override.MaxRequestLength
+	}
+	if override.MaxURLLength != "" {
+		base.MaxURLLength = override.MaxURLLength
+	}
+	if override.MaxQueryStringLength != "" {
+		base.MaxQueryStringLength = override.MaxQueryStringLength
+	}
+	if override.RequestPathInvalidCharacters != nil {
+		base.RequestPathInvalidCharacters = override.RequestPathInvalidCharacters
+	}
+	return base
+}
+
+func applyRequestFiltering(host HostRequestFiltering, rf RequestFiltering) HostRequestFiltering {
+	if limit, ok := parseLimit(rf.RequestLimits.MaxAllowedContentLength); ok {
+		host.MaxAllowedContentLength = limit
+	}
+	if limit, ok := parseLimit(rf.RequestLimits.MaxURL); ok {
+		host.MaxURL = limit
+	}
+	if limit, ok := parseLimit(rf.RequestLimits.MaxQueryString); ok {
+		host.MaxQueryString = limit
+	}
+
+	sequences := append([]string{}, host.DenyURLSequences...)
+	for _, entry := range rf.DenyURLSequences.Entries {
+		switch entry.XMLName.Local {
+		case "clear":
+			sequences = nil
+		case "remove":
+			sequences = removeString(sequences, entry.Sequence)
+		case "add":
+			sequences = append(removeString(sequences, entry.Sequence), entry.Sequence)
+		}
+	}
+	host.DenyURLSequences = sequences
+	return host
+}
+
+func parseLimit(value string) (uint64, bool) {
+	if value == "" {
+		return 0, false
+	}
+	limit, err := strconv.ParseUint(value, 10, 64)
+	if err != nil {
+		return 0, false
+	}
+	return limit, true
+}
+
+func removeString(values []string, value string) []string {
+	var kept []string
+	for _, v := range values {
+		if v != value {
+			kept = append(kept, v)
+		}
+	}
+	return kept
+}
+
 func collectAttrs(attrs []xml.Attr) string {
 	collected := make([]string, len(attrs))
 	for i, attr := range attrs {
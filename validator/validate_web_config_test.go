@@ -1,6 +1,7 @@
 package validator_test
 
 import (
+	"code.cloudfoundry.org/hwc/hwclog"
 	"code.cloudfoundry.org/hwc/validator"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
@@ -10,16 +11,20 @@ import (
 var _ = Describe("ValidateWebConfig", func() {
 	var (
 		buf *gbytes.Buffer
+		log *hwclog.Logger
 	)
 
 	BeforeEach(func() {
+		var err error
 		buf = gbytes.NewBuffer()
+		log, err = hwclog.New("text", buf, buf)
+		Expect(err).ToNot(HaveOccurred())
 	})
 
 	Context("when the web.config is valid and all the xml elements are allowed", func() {
 		BeforeEach(func() {
 			webConfig := "../fixtures/webconfigs/Web.config.good"
-			Expect(validator.ValidateWebConfig(webConfig, buf)).To(Succeed())
+			Expect(validator.ValidateWebConfig(webConfig, log)).To(Succeed())
 		})
 
 		It("does not print any warnings", func() {
@@ -30,7 +35,7 @@ var _ = Describe("ValidateWebConfig", func() {
 	Context("when the web.config is valid but some xml elements are not allowed", func() {
 		BeforeEach(func() {
 			webConfig := "../fixtures/webconfigs/Web.config.bad"
-			Expect(validator.ValidateWebConfig(webConfig, buf)).To(Succeed())
+			Expect(validator.ValidateWebConfig(webConfig, log)).To(Succeed())
 		})
 
 		It("contains an error message about <httpCompression> attributes", func() {
@@ -43,17 +48,105 @@ var _ = Describe("ValidateWebConfig", func() {
 		})
 	})
 
+	Context("when logging JSON", func() {
+		BeforeEach(func() {
+			log.Format = hwclog.JSON
+			webConfig := "../fixtures/webconfigs/Web.config.bad"
+			Expect(validator.ValidateWebConfig(webConfig, log)).To(Succeed())
+		})
+
+		It("logs each warning as an event", func() {
+			Eventually(buf).Should(gbytes.Say(`"level":"warn","event":"web-config-warning","message":"<httpCompression> should not have any attributes but it has nastykey, anotherbadkey","element":"httpCompression"}`))
+		})
+	})
+
+	Context("when the web.config raises <httpRuntime> limits beyond the host request filtering", func() {
+		BeforeEach(func() {
+			webConfig := "../fixtures/webconfigs/Web.config.requestlimits"
+			Expect(validator.ValidateWebConfig(webConfig, log)).To(Succeed())
+		})
+
+		It("warns about maxRequestLength", func() {
+			Eventually(buf).Should(gbytes.Say(`Warning: <httpRuntime maxRequestLength="10240"> allows 10485760 bytes but <requestLimits maxAllowedContentLength> is 2097152; larger requests will fail with HTTP 404.13`))
+		})
+
+		It("warns about maxUrlLength", func() {
+			Eventually(buf).Should(gbytes.Say(`Warning: <httpRuntime maxUrlLength="1024"> exceeds <requestLimits maxUrl> of 260; longer URLs will fail with HTTP 404.14`))
+		})
+
+		It("warns about maxQueryStringLength", func() {
+			Eventually(buf).Should(gbytes.Say(`Warning: <httpRuntime maxQueryStringLength="4096"> exceeds <requestLimits maxQueryString> of 2048; longer query strings will fail with HTTP 404.15`))
+		})
+
+		It("warns about characters that are still denied by request filtering", func() {
+			Eventually(buf).Should(gbytes.Say(`Warning: <httpRuntime requestPathInvalidCharacters> allows '\x25', '&' but <denyUrlSequences> still rejects them; such URLs will fail with HTTP 404.5`))
+		})
+	})
+
+	Context("when the web.config raises <httpRuntime> and <requestFiltering> limits together", func() {
+		BeforeEach(func() {
+			webConfig := "../fixtures/webconfigs/Web.config.requestlimits.matching"
+			Expect(validator.ValidateWebConfig(webConfig, log)).To(Succeed())
+		})
+
+		It("does not print any warnings", func() {
+			Eventually(buf.Contents()).Should(BeEmpty())
+		})
+	})
+
+	Context("when the host request filtering is more permissive than the defaults", func() {
+		BeforeEach(func() {
+			host := validator.DefaultHostRequestFiltering()
+			host.MaxAllowedContentLength = 104857600
+			host.MaxURL = 4096
+			host.MaxQueryString = 4096
+			host.DenyURLSequences = []string{"..", "./"}
+
+			webConfig := "../fixtures/webconfigs/Web.config.requestlimits"
+			Expect(validator.ValidateWebConfigForHost(webConfig, host, log)).To(Succeed())
+		})
+
+		It("does not print any warnings", func() {
+			Eventually(buf.Contents()).Should(BeEmpty())
+		})
+	})
+
+	Context("when the host request filtering denies sequences beyond the defaults", func() {
+		BeforeEach(func() {
+			host := validator.DefaultHostRequestFiltering()
+			host.DenyURLSequences = append(host.DenyURLSequences, "*", "~")
+
+			webConfig := "../fixtures/webconfigs/Web.config.requestlimits.asterisk"
+			Expect(validator.ValidateWebConfigForHost(webConfig, host, log)).To(Succeed())
+		})
+
+		It("warns about the characters the web.config allows", func() {
+			Eventually(buf).Should(gbytes.Say(`Warning: <httpRuntime requestPathInvalidCharacters> allows '\*' but <denyUrlSequences> still rejects them; such URLs will fail with HTTP 404.5`))
+		})
+	})
+
+	Context("when the web.config sets the locked <webSocket> section", func() {
+		BeforeEach(func() {
+			webConfig := "../fixtures/webconfigs/Web.config.websocket"
+			Expect(validator.ValidateWebConfig(webConfig, log)).To(Succeed())
+		})
+
+		It("warns to use the hwc settings instead", func() {
+			Eventually(buf).Should(gbytes.Say(`Warning: <webSocket> is locked in ApplicationHost.config; requests will fail with HTTP 500.19. Use HWC_WEBSOCKET_ENABLED, HWC_WEBSOCKET_PING_INTERVAL and HWC_WEBSOCKET_RECEIVE_BUFFER_LIMIT instead`))
+		})
+	})
+
 	Context("when the web.config does not exist", func() {
 		It("returns an error", func() {
 			webConfig := "some/file/that/does/not/exist"
-			Expect(validator.ValidateWebConfig(webConfig, buf)).NotTo(Succeed())
+			Expect(validator.ValidateWebConfig(webConfig, log)).NotTo(Succeed())
 		})
 	})
 
 	Context("when the web.config has invalid xml", func() {
 		It("returns an error", func() {
 			webConfig := "../fixtures/webconfigs/Web.config.invalid"
-			Expect(validator.ValidateWebConfig(webConfig, buf)).NotTo(Succeed())
+			Expect(validator.ValidateWebConfig(webConfig, log)).NotTo(Succeed())
 		})
 	})
 })
@@ -0,0 +1,164 @@
+package w3clog
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// Line is a complete line read from a log file.
+type Line struct {
+	Path string
+	Text string
+
+	// Backlog is set for lines that were already in the file when the Tailer
+	// started.
+	Backlog bool
+}
+
+// Tailer follows every file matching Pattern in Directory, including files
+// created after it starts, so it keeps up as IIS rotates its logs. The
+// directory does not need to exist yet.
+type Tailer struct {
+	Directory    string
+	Pattern      string
+	PollInterval time.Duration
+
+	offsets map[string]int64
+}
+
+func NewTailer(directory string) *Tailer {
+	return &Tailer{
+		Directory:    directory,
+		Pattern:      "*.log",
+		PollInterval: time.Second,
+	}
+}
+
+// Run calls handle for each complete line until stop is closed.
+func (t *Tailer) Run(stop <-chan struct{}, handle func(Line)) error {
+	t.offsets = map[string]int64{}
+	if err := t.poll(handle, true); err != nil {
+		return err
+	}
+
+	ticker := time.NewTicker(t.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return t.poll(handle, false)
+		case <-ticker.C:
+			if err := t.poll(handle, false); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// Follow parses the lines read by t and calls handle for every request logged
+// after t started. customFields are passed to each file's Parser.
+func Follow(t *Tailer, customFields []string, stop <-chan struct{}, handle func(Entry)) error {
+	parsers := map[string]*Parser{}
+	return t.Run(stop, func(line Line) {
+		parser, ok := parsers[line.Path]
+		if !ok {
+			parser = &Parser{CustomFields: customFields}
+			parsers[line.Path] = parser
+		}
+
+		entry, ok, err := parser.Parse(line.Text)
+		if err != nil || !ok || line.Backlog {
+			return
+		}
+		handle(entry)
+	})
+}
+
+func (t *Tailer) poll(handle func(Line), backlog bool) error {
+	paths, err := filepath.Glob(filepath.Join(t.Directory, t.Pattern))
+	if err != nil {
+		return err
+	}
+
+	type logFile struct {
+		path    string
+		modTime time.Time
+	}
+	var files []logFile
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil || info.IsDir() {
+			continue
+		}
+		files = append(files, logFile{path: path, modTime: info.ModTime()})
+	}
+	sort.SliceStable(files, func(i, j int) bool {
+		if files[i].modTime.Equal(files[j].modTime) {
+			return files[i].path < files[j].path
+		}
+		return files[i].modTime.Before(files[j].modTime)
+	})
+
+	seen := map[string]bool{}
+	for _, f := range files {
+		seen[f.path] = true
+		if err := t.read(f.path, handle, backlog); err != nil {
+			return err
+		}
+	}
+
+	for path := range t.offsets {
+		if !seen[path] {
+			delete(t.offsets, path)
+		}
+	}
+	return nil
+}
+
+func (t *Tailer) read(path string, handle func(Line), backlog bool) error {
+	file, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return err
+	}
+
+	offset := t.offsets[path]
+	if info.Size() < offset {
+		offset = 0
+	}
+	if info.Size() == offset {
+		return nil
+	}
+
+	if _, err := file.Seek(offset, io.SeekStart); err != nil {
+		return err
+	}
+	data, err := ioutil.ReadAll(file)
+	if err != nil {
+		return err
+	}
+
+	end := bytes.LastIndexByte(data, '\n')
+	if end < 0 {
+		return nil
+	}
+
+	for _, text := range bytes.Split(data[:end], []byte{'\n'}) {
+		handle(Line{Path: path, Text: string(bytes.TrimRight(text, "\r")), Backlog: backlog})
+	}
+	t.offsets[path] = offset + int64(end) + 1
+	return nil
+}
@@ -0,0 +1,123 @@
+package w3clog_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"code.cloudfoundry.org/hwc/w3clog"
+)
+
+var _ = Describe("Tailer", func() {
+	var (
+		logDirectory string
+		tailer       *w3clog.Tailer
+		stop         chan struct{}
+		done         chan error
+		entries      chan w3clog.Entry
+	)
+
+	var appendTo = func(name, text string) {
+		file, err := os.OpenFile(filepath.Join(logDirectory, name), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0666)
+		Expect(err).ToNot(HaveOccurred())
+		defer file.Close()
+		_, err = file.WriteString(text)
+		Expect(err).ToNot(HaveOccurred())
+	}
+
+	var copyFixture = func(name string) {
+		data, err := ioutil.ReadFile("../fixtures/w3clogs/" + name)
+		Expect(err).ToNot(HaveOccurred())
+		appendTo(name, string(data))
+	}
+
+	var uriStem = func(e w3clog.Entry) string {
+		stem, _ := e.Get("cs-uri-stem")
+		return stem
+	}
+
+	BeforeEach(func() {
+		dir, err := ioutil.TempDir("", "w3clog")
+		Expect(err).ToNot(HaveOccurred())
+		logDirectory = filepath.Join(dir, "W3SVC8080")
+
+		tailer = w3clog.NewTailer(logDirectory)
+		tailer.PollInterval = 10 * time.Millisecond
+		stop = make(chan struct{})
+		done = make(chan error, 1)
+		entries = make(chan w3clog.Entry, 100)
+	})
+
+	JustBeforeEach(func() {
+		go func() {
+			done <- w3clog.Follow(tailer, nil, stop, func(e w3clog.Entry) { entries <- e })
+		}()
+	})
+
+	AfterEach(func() {
+		close(stop)
+		Eventually(done).Should(Receive(BeNil()))
+		Expect(os.RemoveAll(filepath.Dir(logDirectory))).To(Succeed())
+	})
+
+	Context("when the log directory does not exist yet", func() {
+		It("picks up log files once they are created", func() {
+			Consistently(entries, 50*time.Millisecond).ShouldNot(Receive())
+
+			Expect(os.MkdirAll(logDirectory, 0777)).To(Succeed())
+			copyFixture("u_ex240101.log")
+
+			var e w3clog.Entry
+			Eventually(entries).Should(Receive(&e))
+			Expect(uriStem(e)).To(Equal("/"))
+			Eventually(entries).Should(Receive(&e))
+			Expect(uriStem(e)).To(Equal("/Content/site.css"))
+			Eventually(entries).Should(Receive(&e))
+			Expect(uriStem(e)).To(Equal("/api/orders"))
+		})
+	})
+
+	Context("when a log file already exists", func() {
+		BeforeEach(func() {
+			Expect(os.MkdirAll(logDirectory, 0777)).To(Succeed())
+			copyFixture("u_ex240101.log")
+		})
+
+		It("only reports requests logged after it started", func() {
+			Consistently(entries, 50*time.Millisecond).ShouldNot(Receive())
+
+			appendTo("u_ex240101.log", "2024-01-01 10:00:05 ::1 GET /later - 8080 - ::1 curl/8.4.0 - 200 0 0 1\n")
+
+			var e w3clog.Entry
+			Eventually(entries).Should(Receive(&e))
+			Expect(uriStem(e)).To(Equal("/later"))
+		})
+
+		It("waits for partially written lines to be completed", func() {
+			appendTo("u_ex240101.log", "2024-01-01 10:00:05 ::1 GET /partial - 8080 - ::1 ")
+			Consistently(entries, 50*time.Millisecond).ShouldNot(Receive())
+
+			appendTo("u_ex240101.log", "curl/8.4.0 - 200 0 0 1\r\n")
+
+			var e w3clog.Entry
+			Eventually(entries).Should(Receive(&e))
+			Expect(uriStem(e)).To(Equal("/partial"))
+		})
+
+		It("follows the logs as they rotate", func() {
+			time.Sleep(20 * time.Millisecond)
+			copyFixture("u_ex240102.log")
+
+			var e w3clog.Entry
+			Eventually(entries).Should(Receive(&e))
+			Expect(uriStem(e)).To(Equal("/healthcheck"))
+			Eventually(entries).Should(Receive(&e))
+			bytes, _ := e.Get("sc-bytes")
+			Expect(bytes).To(Equal("52"))
+		})
+	})
+})
@@ -0,0 +1,156 @@
+// Package w3clog follows the W3C extended log files written by IIS and turns
+// each logged request into a single line suitable for stdout.
+package w3clog
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Field is a single logged value, named after its W3C field identifier such as
+// cs-uri-stem or cs(User-Agent).
+type Field struct {
+	Name  string
+	Value string
+}
+
+// Entry is one logged request. Fields keep the order of the #Fields directive.
+type Entry []Field
+
+func (e Entry) Get(name string) (string, bool) {
+	for _, f := range e {
+		if f.Name == name {
+			return f.Value, true
+		}
+	}
+	return "", false
+}
+
+// Parser tracks the #Fields directive of a log file and parses the request
+// lines that follow it.
+type Parser struct {
+	// CustomFields are the logFieldNames of the site's custom log fields,
+	// which IIS escapes like header values.
+	CustomFields []string
+
+	fields []string
+}
+
+// Parse returns the entry for a request line. Directives and blank lines
+// update the parser state and return false.
+func (p *Parser) Parse(line string) (Entry, bool, error) {
+	line = strings.TrimRight(line, "\r\n")
+	if line == "" {
+		return nil, false, nil
+	}
+
+	if strings.HasPrefix(line, "#") {
+		if strings.HasPrefix(line, "#Fields:") {
+			p.fields = strings.Fields(strings.TrimPrefix(line, "#Fields:"))
+		}
+		return nil, false, nil
+	}
+
+	if p.fields == nil {
+		return nil, false, fmt.Errorf("w3c log line before #Fields directive: %s", line)
+	}
+
+	values := strings.Split(line, " ")
+	if len(values) != len(p.fields) {
+		return nil, false, fmt.Errorf("w3c log line has %d values but #Fields lists %d: %s", len(values), len(p.fields), line)
+	}
+
+	entry := make(Entry, len(values))
+	for i, value := range values {
+		entry[i] = Field{Name: p.fields[i], Value: p.decodeValue(p.fields[i], value)}
+	}
+	return entry, true, nil
+}
+
+// decodeValue undoes the escaping IIS applies to logged values. IIS logs a
+// missing value as "-" and replaces spaces in header and custom field values
+// with "+".
+func (p *Parser) decodeValue(name, value string) string {
+	if value == "-" {
+		return ""
+	}
+	if strings.HasSuffix(name, ")") || p.isCustomField(name) {
+		return strings.Replace(value, "+", " ", -1)
+	}
+	return value
+}
+
+func (p *Parser) isCustomField(name string) bool {
+	for _, field := range p.CustomFields {
+		if strings.EqualFold(field, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// numericFields are written as JSON numbers rather than strings.
+var numericFields = map[string]bool{
+	"s-port":          true,
+	"sc-status":       true,
+	"sc-substatus":    true,
+	"sc-win32-status": true,
+	"sc-bytes":        true,
+	"cs-bytes":        true,
+	"time-taken":      true,
+}
+
+// Formatter renders an entry as a single line without a trailing newline.
+type Formatter func(Entry) string
+
+func NewFormatter(format string) (Formatter, error) {
+	switch format {
+	case "text":
+		return FormatText, nil
+	case "json":
+		return FormatJSON, nil
+	default:
+		return nil, fmt.Errorf("Unknown access log format %q (expected text or json)", format)
+	}
+}
+
+// FormatText renders an entry as space separated name=value pairs. Values
+// containing spaces or quotes are quoted.
+func FormatText(e Entry) string {
+	parts := make([]string, 0, len(e))
+	for _, f := range e {
+		value := f.Value
+		if value == "" {
+			value = "-"
+		} else if strings.ContainsAny(value, " \"") {
+			value = strconv.Quote(value)
+		}
+		parts = append(parts, f.Name+"="+value)
+	}
+	return strings.Join(parts, " ")
+}
+
+// FormatJSON renders an entry as a JSON object with keys in field order.
+func FormatJSON(e Entry) string {
+	var b bytes.Buffer
+	b.WriteByte('{')
+	for i, f := range e {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		name, _ := json.Marshal(f.Name)
+		b.Write(name)
+		b.WriteByte(':')
+		if _, err := strconv.ParseInt(f.Value, 10, 64); err == nil && numericFields[f.Name] {
+			b.WriteString(f.Value)
+		} else {
+			value, _ := json.Marshal(f.Value)
+			b.Write(value)
+		}
+	}
+	b.WriteByte('}')
+	return b.String()
+}
@@ -0,0 +1,13 @@
+package w3clog_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func TestW3clog(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "W3clog Suite")
+}
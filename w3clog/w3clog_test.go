@@ -0,0 +1,119 @@
+package w3clog_test
+
+import (
+	"io/ioutil"
+	"strings"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"code.cloudfoundry.org/hwc/w3clog"
+)
+
+func parseFixture(name string, customFields ...string) []w3clog.Entry {
+	data, err := ioutil.ReadFile("../fixtures/w3clogs/" + name)
+	Expect(err).ToNot(HaveOccurred())
+
+	parser := w3clog.Parser{CustomFields: customFields}
+	var entries []w3clog.Entry
+	for _, line := range strings.Split(string(data), "\n") {
+		entry, ok, err := parser.Parse(line)
+		Expect(err).ToNot(HaveOccurred())
+		if ok {
+			entries = append(entries, entry)
+		}
+	}
+	return entries
+}
+
+var _ = Describe("W3C log parsing", func() {
+	Describe("Parser", func() {
+		It("parses request lines using the #Fields directive", func() {
+			entries := parseFixture("u_ex240101.log")
+			Expect(entries).To(HaveLen(3))
+
+			Expect(entries[0][0]).To(Equal(w3clog.Field{Name: "date", Value: "2024-01-01"}))
+			method, _ := entries[2].Get("cs-method")
+			Expect(method).To(Equal("POST"))
+			status, _ := entries[2].Get("sc-status")
+			Expect(status).To(Equal("500"))
+		})
+
+		It("decodes missing values and spaces in header values", func() {
+			entries := parseFixture("u_ex240101.log")
+
+			query, ok := entries[0].Get("cs-uri-query")
+			Expect(ok).To(BeTrue())
+			Expect(query).To(BeEmpty())
+
+			userAgent, _ := entries[0].Get("cs(User-Agent)")
+			Expect(userAgent).To(Equal("Mozilla/5.0 (Windows NT 10.0; Win64; x64)"))
+
+			query, _ = entries[1].Get("cs-uri-query")
+			Expect(query).To(Equal("v=1+2"))
+		})
+
+		It("decodes spaces in custom field values", func() {
+			entries := parseFixture("u_ex240103.log", "X-Forwarded-For", "X-Vcap-Request-Id")
+
+			forwardedFor, _ := entries[0].Get("X-Forwarded-For")
+			Expect(forwardedFor).To(Equal("1.2.3.4, 5.6.7.8"))
+			requestID, _ := entries[0].Get("X-Vcap-Request-Id")
+			Expect(requestID).To(Equal("4d3f0c1e-8d2b-4b3a-9a55-7c1f2e6d9b10"))
+
+			stem, _ := entries[0].Get("cs-uri-stem")
+			Expect(stem).To(Equal("/a+b"))
+		})
+
+		It("follows #Fields directives that change mid-file", func() {
+			entries := parseFixture("u_ex240102.log")
+			Expect(entries).To(HaveLen(2))
+			Expect(entries[0]).To(HaveLen(6))
+			Expect(entries[1]).To(HaveLen(7))
+
+			bytes, _ := entries[1].Get("sc-bytes")
+			Expect(bytes).To(Equal("52"))
+		})
+
+		It("errors on request lines before a #Fields directive", func() {
+			var parser w3clog.Parser
+			_, _, err := parser.Parse("2024-01-01 10:00:00 GET /")
+			Expect(err).To(HaveOccurred())
+		})
+
+		It("errors when a line does not match the #Fields directive", func() {
+			var parser w3clog.Parser
+			_, ok, err := parser.Parse("#Fields: date time cs-method")
+			Expect(ok).To(BeFalse())
+			Expect(err).ToNot(HaveOccurred())
+
+			_, _, err = parser.Parse("2024-01-01 10:00:00")
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	Describe("Formatters", func() {
+		var entry w3clog.Entry
+
+		BeforeEach(func() {
+			entry = parseFixture("u_ex240101.log")[0]
+		})
+
+		It("formats entries as name=value text", func() {
+			Expect(w3clog.FormatText(entry)).To(Equal(`date=2024-01-01 time=10:00:00 s-ip=::1 cs-method=GET cs-uri-stem=/ cs-uri-query=- s-port=8080 cs-username=- c-ip=::1 cs(User-Agent)="Mozilla/5.0 (Windows NT 10.0; Win64; x64)" cs(Referer)=- sc-status=200 sc-substatus=0 sc-win32-status=0 time-taken=1734`))
+		})
+
+		It("formats entries as JSON", func() {
+			Expect(w3clog.FormatJSON(entry)).To(Equal(`{"date":"2024-01-01","time":"10:00:00","s-ip":"::1","cs-method":"GET","cs-uri-stem":"/","cs-uri-query":"","s-port":8080,"cs-username":"","c-ip":"::1","cs(User-Agent)":"Mozilla/5.0 (Windows NT 10.0; Win64; x64)","cs(Referer)":"","sc-status":200,"sc-substatus":0,"sc-win32-status":0,"time-taken":1734}`))
+		})
+
+		It("selects a formatter by name", func() {
+			formatter, err := w3clog.NewFormatter("json")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(formatter(entry)).To(HavePrefix("{"))
+
+			_, err = w3clog.NewFormatter("xml")
+			Expect(err).To(MatchError(`Unknown access log format "xml" (expected text or json)`))
+		})
+	})
+})
@@ -0,0 +1,96 @@
+// Package warmup requests a list of URLs from the site hwc hosts right after
+// it starts, so the first real requests don't pay for JIT compilation and
+// app start up.
+package warmup
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"sync"
+	"time"
+)
+
+type Warmer struct {
+	Host        string
+	Concurrency int
+	Timeout     time.Duration
+}
+
+type Result struct {
+	URL        string
+	StatusCode int
+	Duration   time.Duration
+	Err        error
+}
+
+func (r Result) String() string {
+	if r.Err != nil {
+		return fmt.Sprintf("Warm-up request GET %s failed after %s: %v", r.URL, r.Duration, r.Err)
+	}
+	return fmt.Sprintf("Warm-up request GET %s returned %d in %s", r.URL, r.StatusCode, r.Duration)
+}
+
+func New(host string, concurrency int, timeout time.Duration) *Warmer {
+	return &Warmer{
+		Host:        host,
+		Concurrency: concurrency,
+		Timeout:     timeout,
+	}
+}
+
+// Run requests urls, at most Concurrency at a time, and calls report as each
+// request completes. It returns the results in the order of urls.
+func (w *Warmer) Run(urls []string, report func(Result)) []Result {
+	client := &http.Client{Timeout: w.Timeout}
+	concurrency := w.Concurrency
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	results := make([]Result, len(urls))
+	slots := make(chan struct{}, concurrency)
+	var reporting sync.Mutex
+	var wg sync.WaitGroup
+	for i, url := range urls {
+		wg.Add(1)
+		slots <- struct{}{}
+		go func(i int, url string) {
+			defer wg.Done()
+			defer func() { <-slots }()
+
+			results[i] = w.get(client, url)
+			reporting.Lock()
+			defer reporting.Unlock()
+			report(results[i])
+		}(i, url)
+	}
+	wg.Wait()
+	return results
+}
+
+func (w *Warmer) get(client *http.Client, url string) (result Result) {
+	result.URL = url
+	start := time.Now()
+	defer func() { result.Duration = time.Since(start) }()
+
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		result.Err = err
+		return
+	}
+	if w.Host != "" {
+		req.Host = w.Host
+	}
+
+	res, err := client.Do(req)
+	if err != nil {
+		result.Err = err
+		return
+	}
+	defer res.Body.Close()
+	io.Copy(ioutil.Discard, res.Body)
+	result.StatusCode = res.StatusCode
+	return
+}
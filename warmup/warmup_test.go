@@ -0,0 +1,97 @@
+package warmup_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"code.cloudfoundry.org/hwc/warmup"
+)
+
+var _ = Describe("Warmer", func() {
+	var (
+		server      *httptest.Server
+		hosts       chan string
+		inFlight    int32
+		maxInFlight int32
+		reported    []warmup.Result
+		mutex       sync.Mutex
+	)
+
+	var report = func(r warmup.Result) {
+		mutex.Lock()
+		defer mutex.Unlock()
+		reported = append(reported, r)
+	}
+
+	BeforeEach(func() {
+		hosts = make(chan string, 10)
+		inFlight = 0
+		maxInFlight = 0
+		reported = nil
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			n := atomic.AddInt32(&inFlight, 1)
+			defer atomic.AddInt32(&inFlight, -1)
+			for {
+				max := atomic.LoadInt32(&maxInFlight)
+				if n <= max || atomic.CompareAndSwapInt32(&maxInFlight, max, n) {
+					break
+				}
+			}
+			hosts <- r.Host
+
+			switch r.URL.Path {
+			case "/slow":
+				time.Sleep(200 * time.Millisecond)
+			case "/missing":
+				w.WriteHeader(http.StatusNotFound)
+				return
+			default:
+				time.Sleep(20 * time.Millisecond)
+			}
+			w.WriteHeader(http.StatusOK)
+		}))
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	It("requests each URL and reports how long it took", func() {
+		results := warmup.New("", 1, time.Second).Run([]string{server.URL + "/", server.URL + "/missing"}, report)
+
+		Expect(results).To(HaveLen(2))
+		Expect(results[0].URL).To(Equal(server.URL + "/"))
+		Expect(results[0].StatusCode).To(Equal(200))
+		Expect(results[0].Duration).To(BeNumerically(">=", 20*time.Millisecond))
+		Expect(results[1].StatusCode).To(Equal(404))
+		Expect(reported).To(ConsistOf(results))
+		Expect(results[0].String()).To(MatchRegexp(`^Warm-up request GET http://\S+/ returned 200 in \S+$`))
+	})
+
+	It("makes at most Concurrency requests at a time", func() {
+		var urls []string
+		for i := 0; i < 6; i++ {
+			urls = append(urls, server.URL+"/")
+		}
+		warmup.New("", 2, time.Second).Run(urls, report)
+		Expect(atomic.LoadInt32(&maxInFlight)).To(Equal(int32(2)))
+		Expect(reported).To(HaveLen(6))
+	})
+
+	It("sends the configured Host header", func() {
+		warmup.New("myapp.example.com", 1, time.Second).Run([]string{server.URL + "/"}, report)
+		Expect(hosts).To(Receive(Equal("myapp.example.com")))
+	})
+
+	It("reports requests that time out", func() {
+		results := warmup.New("", 1, 50*time.Millisecond).Run([]string{server.URL + "/slow"}, report)
+		Expect(results[0].Err).To(HaveOccurred())
+		Expect(results[0].String()).To(HavePrefix("Warm-up request GET " + server.URL + "/slow failed after"))
+	})
+})
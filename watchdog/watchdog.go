@@ -0,0 +1,101 @@
+// Package watchdog notices when the site hwc hosts has stopped responding, or
+// the app has written a fatal error marker to stderr, so hwc can exit and let
+// the platform restart the instance.
+package watchdog
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// outputBufferSize is the longest piece of a line ScanOutput searches at once.
+const outputBufferSize = 64 * 1024
+
+type Prober interface {
+	Check() error
+}
+
+type Watchdog struct {
+	Prober           Prober
+	Interval         time.Duration
+	FailureThreshold int
+	Markers          []string
+
+	markers chan string
+}
+
+func New(prober Prober, interval time.Duration, failureThreshold int, markers []string) *Watchdog {
+	return &Watchdog{
+		Prober:           prober,
+		Interval:         interval,
+		FailureThreshold: failureThreshold,
+		Markers:          markers,
+		markers:          make(chan string, 1),
+	}
+}
+
+// Run probes the site every Interval until stop is closed, which returns nil.
+// It returns an error once FailureThreshold probes in a row have failed, or
+// as soon as ScanOutput sees a marker.
+func (w *Watchdog) Run(stop <-chan struct{}) error {
+	ticker := time.NewTicker(w.Interval)
+	defer ticker.Stop()
+
+	failures := 0
+	for {
+		select {
+		case <-stop:
+			return nil
+		case line := <-w.markers:
+			return fmt.Errorf("Watchdog: app wrote a fatal error: %s", line)
+		case <-ticker.C:
+			err := w.Prober.Check()
+			if err == nil {
+				failures = 0
+				continue
+			}
+			failures++
+			if failures >= w.FailureThreshold {
+				return fmt.Errorf("Watchdog: %d health checks in a row failed, last error: %v", failures, err)
+			}
+		}
+	}
+}
+
+// ScanOutput copies r to out line by line, looking for Markers. A line longer
+// than outputBufferSize is copied and searched in pieces. If reading r fails,
+// the rest of it is still copied to out, so whatever writes to r never
+// blocks, and the error is returned.
+func (w *Watchdog) ScanOutput(r io.Reader, out io.Writer) error {
+	reader := bufio.NewReaderSize(r, outputBufferSize)
+	for {
+		line, err := reader.ReadSlice('\n')
+		if len(line) > 0 {
+			out.Write(line)
+			w.scanLine(strings.TrimRight(string(line), "\r\n"))
+		}
+		switch err {
+		case nil, bufio.ErrBufferFull:
+		case io.EOF:
+			return nil
+		default:
+			io.Copy(out, r)
+			return err
+		}
+	}
+}
+
+func (w *Watchdog) scanLine(line string) {
+	for _, marker := range w.Markers {
+		if strings.Contains(line, marker) {
+			select {
+			case w.markers <- line:
+			default:
+			}
+			return
+		}
+	}
+}
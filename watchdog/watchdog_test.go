@@ -0,0 +1,160 @@
+package watchdog_test
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gbytes"
+
+	"code.cloudfoundry.org/hwc/watchdog"
+)
+
+type fakeProber struct {
+	sync.Mutex
+	results []error
+	checks  int
+}
+
+func (p *fakeProber) Check() error {
+	p.Lock()
+	defer p.Unlock()
+	p.checks++
+	if len(p.results) == 0 {
+		return nil
+	}
+	err := p.results[0]
+	p.results = p.results[1:]
+	return err
+}
+
+// failingReader fails its first read and then reads from rest.
+type failingReader struct {
+	err  error
+	rest io.Reader
+}
+
+func (r *failingReader) Read(p []byte) (int, error) {
+	if r.err != nil {
+		err := r.err
+		r.err = nil
+		return 0, err
+	}
+	return r.rest.Read(p)
+}
+
+var _ = Describe("Watchdog", func() {
+	var (
+		prober *fakeProber
+		dog    *watchdog.Watchdog
+		stop   chan struct{}
+		done   chan error
+	)
+
+	var failure = errors.New("GET http://localhost:8080/ returned 503")
+
+	BeforeEach(func() {
+		prober = &fakeProber{}
+		dog = watchdog.New(prober, 5*time.Millisecond, 3, []string{"StackOverflowException"})
+		stop = make(chan struct{})
+		done = make(chan error, 1)
+	})
+
+	JustBeforeEach(func() {
+		go func(dog *watchdog.Watchdog, stop chan struct{}, done chan error) {
+			done <- dog.Run(stop)
+		}(dog, stop, done)
+	})
+
+	Context("when the site stays healthy", func() {
+		It("keeps running until stopped", func() {
+			Consistently(done, 50*time.Millisecond).ShouldNot(Receive())
+			close(stop)
+			Eventually(done).Should(Receive(BeNil()))
+		})
+	})
+
+	Context("when checks fail but recover before the threshold", func() {
+		BeforeEach(func() {
+			prober.results = []error{failure, failure, nil, failure, failure, nil}
+		})
+
+		It("keeps running", func() {
+			Consistently(done, 100*time.Millisecond).ShouldNot(Receive())
+			close(stop)
+			Eventually(done).Should(Receive(BeNil()))
+		})
+	})
+
+	Context("when the threshold is reached", func() {
+		BeforeEach(func() {
+			prober.results = []error{failure, nil, failure, failure, failure}
+		})
+
+		It("returns an error", func() {
+			var err error
+			Eventually(done).Should(Receive(&err))
+			Expect(err).To(MatchError("Watchdog: 3 health checks in a row failed, last error: " + failure.Error()))
+			prober.Lock()
+			defer prober.Unlock()
+			Expect(prober.checks).To(Equal(5))
+		})
+	})
+
+	Describe("ScanOutput", func() {
+		var (
+			writer *io.PipeWriter
+			out    *gbytes.Buffer
+		)
+
+		JustBeforeEach(func() {
+			var reader *io.PipeReader
+			reader, writer = io.Pipe()
+			out = gbytes.NewBuffer()
+			go dog.ScanOutput(reader, out)
+		})
+
+		AfterEach(func() {
+			writer.Close()
+		})
+
+		It("passes the output through", func() {
+			_, err := io.WriteString(writer, "warning: something\n")
+			Expect(err).ToNot(HaveOccurred())
+			Eventually(out).Should(gbytes.Say("warning: something\n"))
+			Consistently(done, 20*time.Millisecond).ShouldNot(Receive())
+			close(stop)
+			Eventually(done).Should(Receive(BeNil()))
+		})
+
+		It("returns an error when a marker is written", func() {
+			_, err := io.WriteString(writer, "Process is terminated due to StackOverflowException.\n")
+			Expect(err).ToNot(HaveOccurred())
+
+			Eventually(done).Should(Receive(MatchError("Watchdog: app wrote a fatal error: Process is terminated due to StackOverflowException.")))
+			Expect(out).To(gbytes.Say("StackOverflowException"))
+		})
+
+		It("keeps reading after a line longer than the buffer", func() {
+			long := strings.Repeat("x", 200*1024) + "\n"
+			go io.WriteString(writer, long+"Process is terminated due to StackOverflowException.\n")
+
+			Eventually(done).Should(Receive(MatchError("Watchdog: app wrote a fatal error: Process is terminated due to StackOverflowException.")))
+			Eventually(out.Contents).Should(HaveLen(len(long) + len("Process is terminated due to StackOverflowException.\n")))
+		})
+	})
+
+	Describe("ScanOutput when reading fails", func() {
+		It("copies the rest of the output and returns the error", func() {
+			out := gbytes.NewBuffer()
+			r := &failingReader{err: errors.New("read failed"), rest: strings.NewReader("more output\n")}
+			err := dog.ScanOutput(io.MultiReader(strings.NewReader("first\n"), r), out)
+			Expect(err).To(MatchError("read failed"))
+			Expect(string(out.Contents())).To(Equal("first\nmore output\n"))
+		})
+	})
+})
@@ -0,0 +1,146 @@
+package webconfig
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+// OriginalFileName is the name the app's own Web.config is kept under while
+// hwc's effective Web.config is in its place. IIS refuses to serve .config
+// files.
+const OriginalFileName = "Web.original.config"
+
+// ChecksumFileName records a hash of the effective Web.config hwc wrote, so a
+// Web.config changed since then is never mistaken for hwc's.
+const ChecksumFileName = "Web.effective.sha256"
+
+const emptyWebConfig = `<?xml version="1.0" encoding="utf-8"?>
+<configuration>
+</configuration>
+`
+
+// Transform rewrites the text of a Web.config.
+type Transform func([]byte) ([]byte, error)
+
+// WriteEffective writes the Web.config IIS loads for the app in appPath by
+// applying transforms to the app's own Web.config. IIS only reads an app's
+// Web.config from its directory, so the effective one replaces it there and
+// the app's copy is saved as OriginalFileName until RestoreOriginal puts it
+// back. If hwc didn't get to restore it, the saved copy is used as the source
+// on the next start, unless Web.config was changed in the meantime, in which
+// case the changed file is the app's own.
+func WriteEffective(appPath string, transforms ...Transform) error {
+	source, ok, err := original(appPath)
+	if err != nil {
+		return err
+	}
+	if err := RestoreOriginal(appPath); err != nil {
+		return err
+	}
+	if !ok {
+		source = []byte(emptyWebConfig)
+	}
+
+	data := source
+	for _, transform := range transforms {
+		data, err = transform(data)
+		if err != nil {
+			return err
+		}
+	}
+	if bytes.Equal(data, source) {
+		return nil
+	}
+
+	if ok {
+		if err := ioutil.WriteFile(filepath.Join(appPath, OriginalFileName), source, 0644); err != nil {
+			return err
+		}
+	}
+	// The checksum goes first, so a Web.config that wasn't rewritten never
+	// matches it.
+	if err := ioutil.WriteFile(filepath.Join(appPath, ChecksumFileName), []byte(checksum(data)), 0644); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(filepath.Join(appPath, "Web.config"), data, 0644)
+}
+
+// RestoreOriginal puts the app's own Web.config back in appPath, so the
+// effective one, which may hold service credentials, doesn't outlive hwc. A
+// Web.config changed since WriteEffective wrote it is left alone.
+func RestoreOriginal(appPath string) error {
+	path := filepath.Join(appPath, "Web.config")
+	originalPath := filepath.Join(appPath, OriginalFileName)
+
+	written, err := isEffective(appPath)
+	if err != nil {
+		return err
+	}
+	if written {
+		_, err := os.Stat(originalPath)
+		if err == nil {
+			err = os.Rename(originalPath, path)
+		} else if os.IsNotExist(err) {
+			// The app had no Web.config.
+			err = os.Remove(path)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, name := range []string{OriginalFileName, ChecksumFileName} {
+		if err := os.Remove(filepath.Join(appPath, name)); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+// original returns the app's own Web.config and whether it has one.
+func original(appPath string) ([]byte, bool, error) {
+	written, err := isEffective(appPath)
+	if err != nil {
+		return nil, false, err
+	}
+	path := filepath.Join(appPath, "Web.config")
+	if written {
+		path = filepath.Join(appPath, OriginalFileName)
+	}
+
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, false, nil
+	} else if err != nil {
+		return nil, false, err
+	}
+	return data, true, nil
+}
+
+// isEffective reports whether the Web.config in appPath is the one
+// WriteEffective last wrote.
+func isEffective(appPath string) (bool, error) {
+	sum, err := ioutil.ReadFile(filepath.Join(appPath, ChecksumFileName))
+	if os.IsNotExist(err) {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+
+	data, err := ioutil.ReadFile(filepath.Join(appPath, "Web.config"))
+	if os.IsNotExist(err) {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+	return string(sum) == checksum(data), nil
+}
+
+func checksum(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
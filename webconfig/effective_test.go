@@ -0,0 +1,114 @@
+package webconfig_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"code.cloudfoundry.org/hwc/webconfig"
+)
+
+var _ = Describe("WriteEffective", func() {
+	var (
+		appPath string
+		suffix  string
+	)
+
+	const appWebConfig = "<configuration>\n</configuration>\n"
+
+	var appendSuffix = func(data []byte) ([]byte, error) {
+		return append(data, suffix...), nil
+	}
+
+	var readFile = func(name string) string {
+		data, err := ioutil.ReadFile(filepath.Join(appPath, name))
+		Expect(err).ToNot(HaveOccurred())
+		return string(data)
+	}
+
+	BeforeEach(func() {
+		var err error
+		appPath, err = ioutil.TempDir("", "webconfig")
+		Expect(err).ToNot(HaveOccurred())
+		suffix = "<!-- effective -->\n"
+	})
+
+	AfterEach(func() {
+		Expect(os.RemoveAll(appPath)).To(Succeed())
+	})
+
+	Context("when the app has a Web.config", func() {
+		BeforeEach(func() {
+			Expect(ioutil.WriteFile(filepath.Join(appPath, "Web.config"), []byte(appWebConfig), 0644)).To(Succeed())
+		})
+
+		It("keeps the app's copy and rewrites Web.config", func() {
+			Expect(webconfig.WriteEffective(appPath, appendSuffix)).To(Succeed())
+			Expect(readFile(webconfig.OriginalFileName)).To(Equal(appWebConfig))
+			Expect(readFile("Web.config")).To(Equal(appWebConfig + suffix))
+		})
+
+		It("starts from the app's copy every time", func() {
+			Expect(webconfig.WriteEffective(appPath, appendSuffix)).To(Succeed())
+			Expect(webconfig.WriteEffective(appPath, appendSuffix)).To(Succeed())
+			Expect(readFile("Web.config")).To(Equal(appWebConfig + suffix))
+
+			suffix = ""
+			Expect(webconfig.WriteEffective(appPath, appendSuffix)).To(Succeed())
+			Expect(readFile("Web.config")).To(Equal(appWebConfig))
+		})
+
+		It("leaves the app untouched when nothing changes", func() {
+			suffix = ""
+			Expect(webconfig.WriteEffective(appPath, appendSuffix)).To(Succeed())
+			Expect(filepath.Join(appPath, webconfig.OriginalFileName)).ToNot(BeAnExistingFile())
+			Expect(filepath.Join(appPath, webconfig.ChecksumFileName)).ToNot(BeAnExistingFile())
+		})
+
+		It("uses a Web.config changed since it was written as the app's own", func() {
+			Expect(webconfig.WriteEffective(appPath, appendSuffix)).To(Succeed())
+			const edited = "<configuration><appSettings /></configuration>\n"
+			Expect(ioutil.WriteFile(filepath.Join(appPath, "Web.config"), []byte(edited), 0644)).To(Succeed())
+
+			Expect(webconfig.WriteEffective(appPath, appendSuffix)).To(Succeed())
+			Expect(readFile(webconfig.OriginalFileName)).To(Equal(edited))
+			Expect(readFile("Web.config")).To(Equal(edited + suffix))
+		})
+
+		Describe("RestoreOriginal", func() {
+			It("puts the app's copy back", func() {
+				Expect(webconfig.WriteEffective(appPath, appendSuffix)).To(Succeed())
+				Expect(webconfig.RestoreOriginal(appPath)).To(Succeed())
+				Expect(readFile("Web.config")).To(Equal(appWebConfig))
+				Expect(filepath.Join(appPath, webconfig.OriginalFileName)).ToNot(BeAnExistingFile())
+				Expect(filepath.Join(appPath, webconfig.ChecksumFileName)).ToNot(BeAnExistingFile())
+			})
+
+			It("leaves a Web.config changed since it was written alone", func() {
+				Expect(webconfig.WriteEffective(appPath, appendSuffix)).To(Succeed())
+				Expect(ioutil.WriteFile(filepath.Join(appPath, "Web.config"), []byte("edited"), 0644)).To(Succeed())
+				Expect(webconfig.RestoreOriginal(appPath)).To(Succeed())
+				Expect(readFile("Web.config")).To(Equal("edited"))
+				Expect(filepath.Join(appPath, webconfig.OriginalFileName)).ToNot(BeAnExistingFile())
+			})
+		})
+	})
+
+	Context("when the app has no Web.config", func() {
+		It("writes one from an empty configuration", func() {
+			Expect(webconfig.WriteEffective(appPath, appendSuffix)).To(Succeed())
+			Expect(readFile("Web.config")).To(HaveSuffix("<configuration>\n</configuration>\n" + suffix))
+		})
+
+		It("removes it again when restoring", func() {
+			Expect(webconfig.WriteEffective(appPath, appendSuffix)).To(Succeed())
+			Expect(webconfig.WriteEffective(appPath, appendSuffix)).To(Succeed())
+			Expect(readFile("Web.config")).To(HaveSuffix("</configuration>\n" + suffix))
+			Expect(webconfig.RestoreOriginal(appPath)).To(Succeed())
+			Expect(filepath.Join(appPath, "Web.config")).ToNot(BeAnExistingFile())
+		})
+	})
+})
@@ -0,0 +1,69 @@
+package webconfig
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"html"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+var placeholderPattern = regexp.MustCompile(`\$?\$\{([A-Za-z_][A-Za-z0-9_]*)(:-[^}]*)?\}`)
+
+// unexpandedPattern matches the markup placeholders are left alone in:
+// comments, processing instructions, CDATA sections and the document type.
+var unexpandedPattern = regexp.MustCompile(`(?s)<!--.*?-->|<\?.*?\?>|<!\[CDATA\[.*?\]\]>|<!DOCTYPE[^>]*>`)
+
+// ExpandEnv returns a Transform that replaces ${VAR} and ${VAR:-default} in
+// the attribute values and text of a Web.config with values from the
+// environment, escaped for XML. The default is used when VAR is unset or
+// empty, and $${VAR} is left as the literal text ${VAR}. In strict mode a
+// placeholder without a default whose variable is unset is an error;
+// otherwise it expands to an empty string.
+func ExpandEnv(strict bool) Transform {
+	return func(data []byte) ([]byte, error) {
+		unset := map[string]bool{}
+		expand := func(match []byte) []byte {
+			if bytes.HasPrefix(match, []byte("$$")) {
+				return match[1:]
+			}
+			groups := placeholderPattern.FindSubmatch(match)
+			name := string(groups[1])
+			value, ok := os.LookupEnv(name)
+			if len(groups[2]) > 0 && value == "" {
+				// The default is written escaped, like the rest of the
+				// document.
+				value, ok = html.UnescapeString(string(groups[2][2:])), true
+			}
+			if !ok {
+				unset[name] = true
+			}
+
+			var b bytes.Buffer
+			xml.EscapeText(&b, []byte(value))
+			return b.Bytes()
+		}
+
+		var expanded []byte
+		start := 0
+		for _, loc := range unexpandedPattern.FindAllIndex(data, -1) {
+			expanded = append(expanded, placeholderPattern.ReplaceAllFunc(data[start:loc[0]], expand)...)
+			expanded = append(expanded, data[loc[0]:loc[1]]...)
+			start = loc[1]
+		}
+		expanded = append(expanded, placeholderPattern.ReplaceAllFunc(data[start:], expand)...)
+
+		if strict && len(unset) > 0 {
+			var names []string
+			for name := range unset {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+			return nil, fmt.Errorf("Web.config refers to unset environment variables: %s", strings.Join(names, ", "))
+		}
+		return expanded, nil
+	}
+}
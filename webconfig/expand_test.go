@@ -0,0 +1,76 @@
+package webconfig_test
+
+import (
+	"os"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"code.cloudfoundry.org/hwc/webconfig"
+)
+
+var _ = Describe("ExpandEnv", func() {
+	var expand = func(strict bool, webConfig string) (string, error) {
+		data, err := webconfig.ExpandEnv(strict)([]byte(webConfig))
+		return string(data), err
+	}
+
+	BeforeEach(func() {
+		Expect(os.Setenv("HWC_TEST_API_URL", "https://api.example.com/?a=1&b=<2>")).To(Succeed())
+		Expect(os.Setenv("HWC_TEST_EMPTY", "")).To(Succeed())
+		Expect(os.Unsetenv("HWC_TEST_UNSET")).To(Succeed())
+	})
+
+	AfterEach(func() {
+		Expect(os.Unsetenv("HWC_TEST_API_URL")).To(Succeed())
+		Expect(os.Unsetenv("HWC_TEST_EMPTY")).To(Succeed())
+	})
+
+	It("replaces placeholders with escaped environment values", func() {
+		webConfig, err := expand(false, `<add key="ApiUrl" value="${HWC_TEST_API_URL}" />`)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(webConfig).To(Equal(`<add key="ApiUrl" value="https://api.example.com/?a=1&amp;b=&lt;2&gt;" />`))
+	})
+
+	It("uses the default when the variable is unset or empty", func() {
+		webConfig, err := expand(true, `<add value="${HWC_TEST_UNSET:-fallback}" /><add value="${HWC_TEST_EMPTY:-}" /><add value="${HWC_TEST_EMPTY:-x}" />`)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(webConfig).To(Equal(`<add value="fallback" /><add value="" /><add value="x" />`))
+	})
+
+	It("expands unset variables to an empty string", func() {
+		webConfig, err := expand(false, `<add value="${HWC_TEST_UNSET}" />`)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(webConfig).To(Equal(`<add value="" />`))
+	})
+
+	It("keeps escaped and malformed placeholders", func() {
+		webConfig, err := expand(true, `<add value="$${HWC_TEST_UNSET}" /><add value="${not a var}" /><add value="$HWC_TEST_API_URL" />`)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(webConfig).To(Equal(`<add value="${HWC_TEST_UNSET}" /><add value="${not a var}" /><add value="$HWC_TEST_API_URL" />`))
+	})
+
+	It("unescapes defaults before escaping them again", func() {
+		webConfig, err := expand(false, `<add value="${HWC_TEST_UNSET:-a&amp;b&lt;c}" />`)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(webConfig).To(Equal(`<add value="a&amp;b&lt;c" />`))
+	})
+
+	It("expands text but leaves comments and CDATA sections alone", func() {
+		webConfig, err := expand(false, `<!-- ${HWC_TEST_API_URL} --><url>${HWC_TEST_EMPTY:-text}</url><![CDATA[${HWC_TEST_API_URL}]]>`)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(webConfig).To(Equal(`<!-- ${HWC_TEST_API_URL} --><url>text</url><![CDATA[${HWC_TEST_API_URL}]]>`))
+	})
+
+	Context("in strict mode", func() {
+		It("ignores unset variables in comments", func() {
+			webConfig := `<configuration><!-- <add value="${HWC_TEST_UNSET}" /> --></configuration>`
+			Expect(expand(true, webConfig)).To(Equal(webConfig))
+		})
+
+		It("fails on unset variables", func() {
+			_, err := expand(true, `<add value="${HWC_TEST_UNSET}" /><add value="${HWC_TEST_ALSO_UNSET}${HWC_TEST_UNSET}" /><add value="${HWC_TEST_EMPTY}" />`)
+			Expect(err).To(MatchError("Web.config refers to unset environment variables: HWC_TEST_ALSO_UNSET, HWC_TEST_UNSET"))
+		})
+	})
+})
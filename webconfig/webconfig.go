@@ -0,0 +1,268 @@
+// Package webconfig edits an app's Web.config in place. Edits are spliced
+// into the original text so comments, formatting and anything hwc doesn't
+// touch are left exactly as the app wrote them.
+package webconfig
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Add is an <add> element in a collection such as <appSettings>.
+type Add []xml.Attr
+
+// Collection is a set of <add> elements hwc merges into a top level
+// configuration section. Existing entries with the same Key attribute are
+// replaced.
+type Collection struct {
+	Section string
+	Key     string
+	Adds    []Add
+}
+
+type element struct {
+	name         string
+	attr         []xml.Attr
+	start        int
+	contentStart int
+	contentEnd   int
+	end          int
+	selfClosing  bool
+	children     []*element
+}
+
+func (e *element) attrValue(name string) (string, bool) {
+	for _, a := range e.attr {
+		if a.Name.Space == "" && a.Name.Local == name {
+			return a.Value, true
+		}
+	}
+	return "", false
+}
+
+func (e *element) child(name string) *element {
+	for _, c := range e.children {
+		if c.name == name {
+			return c
+		}
+	}
+	return nil
+}
+
+// Merge merges each collection into the <configuration> element of data.
+func Merge(data []byte, collections ...Collection) ([]byte, error) {
+	var err error
+	for _, c := range collections {
+		if len(c.Adds) == 0 {
+			continue
+		}
+		data, err = removeEntries(data, c)
+		if err != nil {
+			return nil, err
+		}
+		data, err = insertEntries(data, c)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return data, nil
+}
+
+func removeEntries(data []byte, c Collection) ([]byte, error) {
+	section, err := findSection(data, c.Section)
+	if err != nil || section == nil {
+		return data, err
+	}
+
+	keys := map[string]bool{}
+	for _, add := range c.Adds {
+		for _, a := range add {
+			if a.Name.Local == c.Key {
+				keys[strings.ToLower(a.Value)] = true
+			}
+		}
+	}
+
+	for i := len(section.children) - 1; i >= 0; i-- {
+		child := section.children[i]
+		if child.name != "add" {
+			continue
+		}
+		if key, ok := child.attrValue(c.Key); ok && keys[strings.ToLower(key)] {
+			start, end := lineSpan(data, child.start, child.end)
+			data = append(data[:start:start], data[end:]...)
+		}
+	}
+	return data, nil
+}
+
+func insertEntries(data []byte, c Collection) ([]byte, error) {
+	root, err := parse(data)
+	if err != nil {
+		return nil, err
+	}
+	newline := "\n"
+	if bytes.Contains(data, []byte("\r\n")) {
+		newline = "\r\n"
+	}
+
+	section := root.child(c.Section)
+	if section == nil {
+		indent := childIndent(data, root)
+		text := newline + indent + "<" + c.Section + ">" +
+			addsText(c, newline, indent+indentUnit(indent)) +
+			newline + indent + "</" + c.Section + ">"
+		return splice(data, trimContentEnd(data, root), text), nil
+	}
+
+	indent := childIndent(data, section)
+	adds := addsText(c, newline, indent)
+	if !section.selfClosing {
+		return splice(data, trimContentEnd(data, section), adds), nil
+	}
+
+	startTag := strings.TrimRight(strings.TrimSuffix(string(data[section.start:section.end]), "/>"), " \t\r\n")
+	text := startTag + ">" + adds + newline + lineIndent(data, section.start) + "</" + c.Section + ">"
+	return append(data[:section.start:section.start], append([]byte(text), data[section.end:]...)...), nil
+}
+
+func findSection(data []byte, name string) (*element, error) {
+	root, err := parse(data)
+	if err != nil {
+		return nil, err
+	}
+	section := root.child(name)
+	if section == nil {
+		return nil, nil
+	}
+	if source, ok := section.attrValue("configSource"); ok {
+		return nil, fmt.Errorf("Web.config <%s> is read from %s and can't be merged", name, source)
+	}
+	return section, nil
+}
+
+func parse(data []byte) (*element, error) {
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	var root *element
+	var stack []*element
+	for {
+		offset := int(decoder.InputOffset())
+		token, err := decoder.RawToken()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("Parsing Web.config: %v", err)
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			e := &element{
+				name:         t.Name.Local,
+				attr:         append([]xml.Attr(nil), t.Attr...),
+				start:        offset,
+				contentStart: int(decoder.InputOffset()),
+			}
+			if len(stack) > 0 {
+				parent := stack[len(stack)-1]
+				parent.children = append(parent.children, e)
+			} else if root == nil {
+				root = e
+			}
+			stack = append(stack, e)
+		case xml.EndElement:
+			if len(stack) == 0 {
+				return nil, fmt.Errorf("Parsing Web.config: unexpected </%s>", t.Name.Local)
+			}
+			e := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			e.end = int(decoder.InputOffset())
+			e.contentEnd = offset
+			if e.end == offset {
+				e.selfClosing = true
+				e.contentEnd = e.end
+			}
+		}
+	}
+
+	if root == nil || root.name != "configuration" || len(stack) > 0 {
+		return nil, fmt.Errorf("Parsing Web.config: expected a <configuration> element")
+	}
+	if root.selfClosing {
+		return nil, fmt.Errorf("Parsing Web.config: <configuration> must not be empty")
+	}
+	return root, nil
+}
+
+func addsText(c Collection, newline, indent string) string {
+	var b strings.Builder
+	for _, add := range c.Adds {
+		b.WriteString(newline + indent + "<add")
+		for _, a := range add {
+			b.WriteString(" " + a.Name.Local + `="`)
+			xml.EscapeText(&b, []byte(a.Value))
+			b.WriteString(`"`)
+		}
+		b.WriteString(" />")
+	}
+	return b.String()
+}
+
+// childIndent is the indentation of e's first child element, or one level
+// deeper than e if it has none.
+func childIndent(data []byte, e *element) string {
+	if len(e.children) > 0 {
+		if indent := lineIndent(data, e.children[0].start); indent != "" {
+			return indent
+		}
+	}
+	indent := lineIndent(data, e.start)
+	return indent + indentUnit(indent)
+}
+
+func indentUnit(indent string) string {
+	if strings.Contains(indent, "\t") {
+		return "\t"
+	}
+	return "  "
+}
+
+// lineIndent is the whitespace before pos on its line, or an empty string
+// if anything else precedes it.
+func lineIndent(data []byte, pos int) string {
+	start := bytes.LastIndexByte(data[:pos], '\n') + 1
+	indent := string(data[start:pos])
+	if strings.TrimLeft(indent, " \t") != "" {
+		return ""
+	}
+	return indent
+}
+
+// lineSpan widens [start, end) to whole lines when nothing else shares them.
+func lineSpan(data []byte, start, end int) (int, int) {
+	lineStart := bytes.LastIndexByte(data[:start], '\n') + 1
+	if len(bytes.TrimLeft(data[lineStart:start], " \t")) != 0 {
+		return start, end
+	}
+	rest := data[end:]
+	trailing := len(rest) - len(bytes.TrimLeft(rest, " \t"))
+	rest = rest[trailing:]
+	switch {
+	case bytes.HasPrefix(rest, []byte("\r\n")):
+		return lineStart, end + trailing + 2
+	case bytes.HasPrefix(rest, []byte("\n")):
+		return lineStart, end + trailing + 1
+	}
+	return start, end
+}
+
+func trimContentEnd(data []byte, e *element) int {
+	return e.contentStart + len(bytes.TrimRight(data[e.contentStart:e.contentEnd], " \t\r\n"))
+}
+
+func splice(data []byte, pos int, text string) []byte {
+	return append(data[:pos:pos], append([]byte(text), data[pos:]...)...)
+}
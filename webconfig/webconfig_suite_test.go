@@ -0,0 +1,13 @@
+package webconfig_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func TestWebconfig(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Webconfig Suite")
+}
@@ -0,0 +1,103 @@
+package webconfig_test
+
+import (
+	"encoding/xml"
+	"strings"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"code.cloudfoundry.org/hwc/webconfig"
+)
+
+func attr(name, value string) xml.Attr {
+	return xml.Attr{Name: xml.Name{Local: name}, Value: value}
+}
+
+var _ = Describe("Merge", func() {
+	var connectionStrings = webconfig.Collection{
+		Section: "connectionStrings",
+		Key:     "name",
+		Adds: []webconfig.Add{
+			{attr("name", "orders"), attr("connectionString", `Server=db;Password="p&<"`), attr("providerName", "System.Data.SqlClient")},
+		},
+	}
+
+	var merge = func(webConfig string, collections ...webconfig.Collection) string {
+		data, err := webconfig.Merge([]byte(webConfig), collections...)
+		Expect(err).ToNot(HaveOccurred())
+		return string(data)
+	}
+
+	It("adds a missing section at the end of the configuration", func() {
+		Expect(merge(`<?xml version="1.0"?>
+<configuration>
+  <!-- app settings -->
+  <system.web>
+    <compilation debug="false" />
+  </system.web>
+</configuration>
+`, connectionStrings)).To(Equal(`<?xml version="1.0"?>
+<configuration>
+  <!-- app settings -->
+  <system.web>
+    <compilation debug="false" />
+  </system.web>
+  <connectionStrings>
+    <add name="orders" connectionString="Server=db;Password=&#34;p&amp;&lt;&#34;" providerName="System.Data.SqlClient" />
+  </connectionStrings>
+</configuration>
+`))
+	})
+
+	It("replaces entries with the same key and keeps the others", func() {
+		Expect(merge(`<configuration>
+	<connectionStrings>
+		<clear />
+		<add name="Orders" connectionString="Server=localhost" />
+		<add name="audit" connectionString="Server=audit" />
+	</connectionStrings>
+</configuration>`, connectionStrings)).To(Equal(`<configuration>
+	<connectionStrings>
+		<clear />
+		<add name="audit" connectionString="Server=audit" />
+		<add name="orders" connectionString="Server=db;Password=&#34;p&amp;&lt;&#34;" providerName="System.Data.SqlClient" />
+	</connectionStrings>
+</configuration>`))
+	})
+
+	It("expands an empty section", func() {
+		Expect(merge("<configuration>\r\n  <appSettings />\r\n</configuration>\r\n", webconfig.Collection{
+			Section: "appSettings",
+			Key:     "key",
+			Adds:    []webconfig.Add{{attr("key", "a"), attr("value", "1")}, {attr("key", "b"), attr("value", "2")}},
+		})).To(Equal("<configuration>\r\n  <appSettings>\r\n    <add key=\"a\" value=\"1\" />\r\n    <add key=\"b\" value=\"2\" />\r\n  </appSettings>\r\n</configuration>\r\n"))
+	})
+
+	It("leaves sections inside <location> alone", func() {
+		merged := merge(`<configuration>
+  <location path="admin">
+    <connectionStrings>
+      <add name="orders" connectionString="Server=admin" />
+    </connectionStrings>
+  </location>
+</configuration>`, connectionStrings)
+		Expect(merged).To(ContainSubstring(`<add name="orders" connectionString="Server=admin" />`))
+		Expect(strings.Count(merged, "<connectionStrings>")).To(Equal(2))
+	})
+
+	It("does nothing when there is nothing to merge", func() {
+		webConfig := "<configuration><appSettings/></configuration>"
+		Expect(merge(webConfig, webconfig.Collection{Section: "appSettings", Key: "key"})).To(Equal(webConfig))
+	})
+
+	It("refuses to merge into a section read from another file", func() {
+		_, err := webconfig.Merge([]byte(`<configuration><connectionStrings configSource="connections.config" /></configuration>`), connectionStrings)
+		Expect(err).To(MatchError("Web.config <connectionStrings> is read from connections.config and can't be merged"))
+	})
+
+	It("rejects documents without a configuration element", func() {
+		_, err := webconfig.Merge([]byte(`<settings></settings>`), connectionStrings)
+		Expect(err).To(MatchError("Parsing Web.config: expected a <configuration> element"))
+	})
+})
@@ -7,6 +7,8 @@ import (
 	"os"
 	"syscall"
 	"unsafe"
+
+	"code.cloudfoundry.org/hwc/hwclog"
 )
 
 type WebCore struct {
@@ -46,7 +48,6 @@ func (w *WebCore) Activate(appHostConfigPath, rootWebConfigPath, instanceName st
 			return fmt.Errorf("HWC Failed to start: return code: 0x%02x", r1)
 		}
 
-		fmt.Printf("Server Started for %+v\n", instanceName)
 		w.activated = true
 	}
 
@@ -66,7 +67,7 @@ func (w *WebCore) Shutdown(immediate int, instanceName string) error {
 		if exitCode != 0 {
 			return fmt.Errorf("WebCoreShutdown returned exit code: %d", exitCode)
 		}
-		fmt.Printf("Server Shutdown for %+v\n", instanceName)
+		hwclog.Default().Info("server-shutdown", fmt.Sprintf("Server Shutdown for %+v", instanceName), hwclog.Fields{"instance": instanceName})
 	}
 
 	return nil